@@ -0,0 +1,430 @@
+package mongodb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/topology"
+)
+
+// DefaultTokenCollection stores the resume tokens of MongoTokenStore
+const DefaultTokenCollection = "_change_stream_tokens"
+
+// ErrChangeStreamInvalidated is returned by ChangeStreamConsumer.Run when the stream is
+// invalidated, e.g. because the watched collection was dropped or renamed
+var ErrChangeStreamInvalidated = errors.New("MongoDB change stream invalidated")
+
+// ErrChangeStreamHistoryLost is returned by ChangeStreamConsumer.Run when the saved resume
+// token is no longer in the oplog, so the events since the checkpoint cannot be delivered
+var ErrChangeStreamHistoryLost = errors.New("MongoDB change stream history lost")
+
+// codeChangeStreamHistoryLost is the server error when the resume point fell off the oplog
+const codeChangeStreamHistoryLost = 286
+
+// ChangeEvent is an event of a change stream
+type ChangeEvent struct {
+	OperationType     string              `bson:"operationType"` // insert, update, replace, delete, drop, rename, dropDatabase or invalidate
+	Namespace         ChangeNamespace     `bson:"ns"`
+	DocumentKey       bson.Raw            `bson:"documentKey"`
+	FullDocument      bson.Raw            `bson:"fullDocument"` // Set for inserts and replaces, and for updates with the updateLookup full document option
+	UpdateDescription bson.Raw            `bson:"updateDescription"`
+	ClusterTime       primitive.Timestamp `bson:"clusterTime"`
+
+	// ResumeToken is the token resuming the stream after this event
+	ResumeToken bson.Raw `bson:"-"`
+	// Raw is the whole event document
+	Raw bson.Raw `bson:"-"`
+}
+
+// ChangeNamespace is the database and collection of a change event
+type ChangeNamespace struct {
+	Database   string `bson:"db"`
+	Collection string `bson:"coll"`
+}
+
+// DecodeFullDocument decodes the full document of the event into v
+func (e ChangeEvent) DecodeFullDocument(v interface{}) error {
+	if e.FullDocument == nil {
+		return fmt.Errorf("%s event has no full document", e.OperationType)
+	}
+	return bson.Unmarshal(e.FullDocument, v)
+}
+
+// ChangeHandler processes a change event; an error stops the consumer before the event is checkpointed
+type ChangeHandler func(ctx context.Context, event ChangeEvent) error
+
+// TokenStore persists the resume tokens of change stream consumers by consumer name
+type TokenStore interface {
+	// Load returns the saved token of a consumer, nil when there is none
+	Load(ctx context.Context, name string) (bson.Raw, error)
+
+	// Save replaces the token of a consumer
+	Save(ctx context.Context, name string, token bson.Raw) error
+}
+
+// MemoryTokenStore keeps resume tokens in memory, for tests and consumers that may start over.
+//
+// The zero value is ready to use.
+type MemoryTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]bson.Raw
+}
+
+// NewMemoryTokenStore creates an empty in-memory token store
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{}
+}
+
+// Load returns the saved token of a consumer, nil when there is none
+func (s *MemoryTokenStore) Load(_ context.Context, name string) (bson.Raw, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokens[name], nil
+}
+
+// Save replaces the token of a consumer
+func (s *MemoryTokenStore) Save(_ context.Context, name string, token bson.Raw) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tokens == nil {
+		s.tokens = make(map[string]bson.Raw)
+	}
+	s.tokens[name] = append(bson.Raw(nil), token...)
+	return nil
+}
+
+// MongoTokenStore keeps resume tokens in a collection of the manager's default database,
+// one document per consumer name
+type MongoTokenStore struct {
+	manager    Manager
+	collection string
+}
+
+// NewMongoTokenStore creates a token store in the named collection, DefaultTokenCollection when empty
+func NewMongoTokenStore(manager Manager, collection string) *MongoTokenStore {
+	if collection == "" {
+		collection = DefaultTokenCollection
+	}
+	return &MongoTokenStore{manager: manager, collection: collection}
+}
+
+// Load returns the saved token of a consumer, nil when there is none
+func (s *MongoTokenStore) Load(ctx context.Context, name string) (bson.Raw, error) {
+	if err := s.manager.Connect(ctx); err != nil {
+		return nil, err
+	}
+	var saved struct {
+		Token bson.Raw `bson:"token"`
+	}
+	err := s.manager.Collection(s.collection).FindOne(ctx, bson.D{{Key: "_id", Value: name}}).Decode(&saved)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	return saved.Token, err
+}
+
+// Save replaces the token of a consumer
+func (s *MongoTokenStore) Save(ctx context.Context, name string, token bson.Raw) error {
+	if err := s.manager.Connect(ctx); err != nil {
+		return err
+	}
+	_, err := s.manager.Collection(s.collection).UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: name}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "token", Value: token}, {Key: "updated_at", Value: time.Now().UTC()}}}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+// ConsumerOption configures a ChangeStreamConsumer
+type ConsumerOption func(*ChangeStreamConsumer)
+
+// WithConsumerCollection watches a collection of the default database instead of the whole database
+func WithConsumerCollection(collection string) ConsumerOption {
+	return func(c *ChangeStreamConsumer) {
+		c.collection = collection
+		c.allDatabases = false
+	}
+}
+
+// WithConsumerAllDatabases watches every database of the deployment instead of the default database
+func WithConsumerAllDatabases() ConsumerOption {
+	return func(c *ChangeStreamConsumer) {
+		c.collection = ""
+		c.allDatabases = true
+	}
+}
+
+// WithConsumerPipeline filters or reshapes the events with an aggregation pipeline
+func WithConsumerPipeline(pipeline interface{}) ConsumerOption {
+	return func(c *ChangeStreamConsumer) {
+		c.pipeline = pipeline
+	}
+}
+
+// WithConsumerStreamOptions sets the change stream options, e.g. the full document mode.
+//
+// The consumer sets StartAfter itself when it resumes.
+func WithConsumerStreamOptions(opts *options.ChangeStreamOptions) ConsumerOption {
+	return func(c *ChangeStreamConsumer) {
+		c.streamOptions = opts
+	}
+}
+
+// WithTokenStore persists resume tokens in store instead of memory
+func WithTokenStore(store TokenStore) ConsumerOption {
+	return func(c *ChangeStreamConsumer) {
+		c.store = store
+	}
+}
+
+// WithCheckpointEvery saves the resume token after every n handled events instead of after each one.
+//
+// The token is also saved when the consumer stops; events after the last checkpoint are
+// delivered again after a crash.
+func WithCheckpointEvery(n int) ConsumerOption {
+	return func(c *ChangeStreamConsumer) {
+		c.checkpointEvery = max(n, 1)
+	}
+}
+
+// WithConsumerRetry sets the reconnect backoff; MaxAttempts limits consecutive failed
+// reconnects, 0 retries until the context is done
+func WithConsumerRetry(retry ConnectRetryConfig) ConsumerOption {
+	return func(c *ChangeStreamConsumer) {
+		c.retry = retry
+	}
+}
+
+// WithRestartOnInvalidate starts a new stream after an invalidate event instead of returning
+// ErrChangeStreamInvalidated
+func WithRestartOnInvalidate() ConsumerOption {
+	return func(c *ChangeStreamConsumer) {
+		c.restartOnInvalidate = true
+	}
+}
+
+// WithRestartOnHistoryLost starts a new stream from the current time when the saved resume
+// token is no longer in the oplog instead of returning ErrChangeStreamHistoryLost.
+//
+// The events between the checkpoint and the restart are skipped.
+func WithRestartOnHistoryLost() ConsumerOption {
+	return func(c *ChangeStreamConsumer) {
+		c.restartOnHistoryLost = true
+	}
+}
+
+// WithConsumerLogger logs reconnects to logger instead of slog.Default()
+func WithConsumerLogger(logger *slog.Logger) ConsumerOption {
+	return func(c *ChangeStreamConsumer) {
+		c.logger = logger
+	}
+}
+
+// ChangeStreamConsumer runs a handler for every event of a change stream and checkpoints
+// the resume token of the handled events, so that a restarted consumer continues where it
+// stopped. After each batch the post-batch resume token is checkpointed as well, so that
+// a consumer of a quiet stream does not fall behind the oplog.
+//
+// Streams are resumed with startAfter, which also continues after invalidate events and
+// needs MongoDB 4.2 or later. Events are delivered at least once: an event whose handler
+// succeeded may be delivered again when the consumer stops before its checkpoint.
+type ChangeStreamConsumer struct {
+	manager              Manager
+	name                 string
+	handler              ChangeHandler
+	collection           string
+	allDatabases         bool
+	pipeline             interface{}
+	streamOptions        *options.ChangeStreamOptions
+	store                TokenStore
+	checkpointEvery      int
+	retry                ConnectRetryConfig
+	restartOnInvalidate  bool
+	restartOnHistoryLost bool
+	logger               *slog.Logger
+}
+
+// NewChangeStreamConsumer creates a consumer watching the default database; name identifies
+// its tokens in the token store and must be unique among consumers sharing a store
+func NewChangeStreamConsumer(manager Manager, name string, handler ChangeHandler, opts ...ConsumerOption) *ChangeStreamConsumer {
+	c := &ChangeStreamConsumer{
+		manager:         manager,
+		name:            name,
+		handler:         handler,
+		pipeline:        mongo.Pipeline{},
+		store:           NewMemoryTokenStore(),
+		checkpointEvery: 1,
+		retry:           ConnectRetryConfig{Jitter: DefaultConnectJitter},
+		logger:          slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Run consumes events until ctx is done, the handler fails or a non-resumable error occurs.
+//
+// Resumable errors, such as network errors and primary elections, reopen the stream from
+// the last checkpoint with the configured backoff. Run returns nil when ctx is done, the
+// handler error, ErrChangeStreamInvalidated after an invalidate event unless
+// WithRestartOnInvalidate is set, or ErrChangeStreamHistoryLost when the saved token is no
+// longer in the oplog unless WithRestartOnHistoryLost is set.
+func (c *ChangeStreamConsumer) Run(ctx context.Context) error {
+	failures := 0
+	fromNow := false
+	for {
+		err := c.consume(ctx, fromNow, func() { failures = 0 })
+		fromNow = false
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case errors.Is(err, ErrChangeStreamInvalidated) && c.restartOnInvalidate:
+			c.logger.Info("MongoDB change stream invalidated, restarting", slog.String("consumer", c.name))
+			continue
+		case errors.Is(err, ErrChangeStreamHistoryLost) && c.restartOnHistoryLost:
+			c.logger.Warn("MongoDB change stream history lost, restarting from now", slog.String("consumer", c.name))
+			fromNow = true
+			continue
+		case err == nil:
+			// The server closed the stream without an error, reopen it
+		case !isResumableChangeStreamError(err):
+			return err
+		}
+
+		failures++
+		if c.retry.MaxAttempts > 0 && failures > c.retry.MaxAttempts {
+			return fmt.Errorf("change stream %s: giving up after %d reconnects: %w", c.name, c.retry.MaxAttempts, err)
+		}
+		delay := c.retry.delay(failures)
+		c.logger.Warn("MongoDB change stream interrupted, reconnecting",
+			slog.String("consumer", c.name),
+			slog.Int("attempt", failures),
+			slog.Duration("delay", delay),
+			slog.Any("error", err),
+		)
+		if sleepContext(ctx, delay) != nil {
+			return nil
+		}
+	}
+}
+
+// consume opens the stream after the saved token, or at the current time when fromNow is
+// set, and handles events until it ends; progress is called after each handled event
+func (c *ChangeStreamConsumer) consume(ctx context.Context, fromNow bool, progress func()) error {
+	var token bson.Raw
+	if !fromNow {
+		var err error
+		if token, err = c.store.Load(ctx, c.name); err != nil {
+			return fmt.Errorf("load resume token of %s: %w", c.name, err)
+		}
+	}
+	stream, err := c.open(ctx, token)
+	if err != nil {
+		return c.historyLost(err)
+	}
+	defer stream.Close(context.WithoutCancel(ctx))
+
+	saved := token
+	var pending bson.Raw
+	handled := 0
+	checkpoint := func() error {
+		if pending == nil {
+			return nil
+		}
+		if err := c.store.Save(context.WithoutCancel(ctx), c.name, pending); err != nil {
+			return fmt.Errorf("save resume token of %s: %w", c.name, err)
+		}
+		saved, pending = pending, nil
+		return nil
+	}
+
+	for {
+		if !stream.TryNext(ctx) {
+			if stream.Err() != nil || stream.ID() == 0 {
+				break
+			}
+			// The batch is exhausted and every event of it handled, so the post-batch resume
+			// token can be saved; it moves on even when no event matched the stream
+			if token := stream.ResumeToken(); token != nil && !bytes.Equal(token, saved) {
+				pending = append(bson.Raw(nil), token...)
+				if err := checkpoint(); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		var event ChangeEvent
+		if err := stream.Decode(&event); err != nil {
+			return errors.Join(err, checkpoint())
+		}
+		event.Raw = append(bson.Raw(nil), stream.Current...)
+		event.ResumeToken = append(bson.Raw(nil), stream.ResumeToken()...)
+
+		if err := c.handler(ctx, event); err != nil {
+			return errors.Join(fmt.Errorf("change stream %s: handler: %w", c.name, err), checkpoint())
+		}
+		pending = event.ResumeToken
+		handled++
+		progress()
+
+		if event.OperationType == "invalidate" {
+			return errors.Join(ErrChangeStreamInvalidated, checkpoint())
+		}
+		if handled%c.checkpointEvery == 0 {
+			if err := checkpoint(); err != nil {
+				return err
+			}
+		}
+	}
+	return errors.Join(c.historyLost(stream.Err()), checkpoint())
+}
+
+// historyLost marks err with ErrChangeStreamHistoryLost when the server no longer has the
+// oplog entry of the resume token
+func (c *ChangeStreamConsumer) historyLost(err error) error {
+	var server mongo.ServerError
+	if errors.As(err, &server) && server.HasErrorCode(codeChangeStreamHistoryLost) {
+		return fmt.Errorf("change stream %s: %w: %w", c.name, ErrChangeStreamHistoryLost, err)
+	}
+	return err
+}
+
+// open opens the change stream, starting after token when it is set
+func (c *ChangeStreamConsumer) open(ctx context.Context, token bson.Raw) (*mongo.ChangeStream, error) {
+	opts := options.MergeChangeStreamOptions(c.streamOptions)
+	if token != nil {
+		opts.SetStartAfter(token)
+	}
+	switch {
+	case c.collection != "":
+		return c.manager.WatchCollection(ctx, c.collection, c.pipeline, opts)
+	case c.allDatabases:
+		return c.manager.WatchAllDatabases(ctx, c.pipeline, opts)
+	}
+	return c.manager.Watch(ctx, c.pipeline, opts)
+}
+
+// isResumableChangeStreamError reports whether the stream can be reopened after err
+func isResumableChangeStreamError(err error) bool {
+	if mongo.IsNetworkError(err) || mongo.IsTimeout(err) || errors.Is(err, ErrConnectFailed) {
+		return true
+	}
+	var selection topology.ServerSelectionError
+	if errors.As(err, &selection) {
+		return true
+	}
+	var server mongo.ServerError
+	return errors.As(err, &server) && server.HasErrorLabel("ResumableChangeStreamError")
+}
@@ -0,0 +1,297 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// changeEvent returns a change event document with a resume token
+func changeEvent(token, operation string, id int) bson.D {
+	return bson.D{
+		{Key: "_id", Value: bson.D{{Key: "_data", Value: token}}},
+		{Key: "operationType", Value: operation},
+		{Key: "ns", Value: bson.D{{Key: "db", Value: "testdb"}, {Key: "coll", Value: "orders"}}},
+		{Key: "documentKey", Value: bson.D{{Key: "_id", Value: id}}},
+		{Key: "fullDocument", Value: bson.D{{Key: "_id", Value: id}, {Key: "total", Value: 10 * id}}},
+	}
+}
+
+// quietBatch returns a change stream cursor response without events and with a post-batch resume token
+func quietBatch(batch mtest.BatchIdentifier, token string) bson.D {
+	return bson.D{
+		{Key: "ok", Value: 1},
+		{Key: "cursor", Value: bson.D{
+			{Key: "id", Value: int64(1)},
+			{Key: "ns", Value: "testdb.orders"},
+			{Key: string(batch), Value: bson.A{}},
+			{Key: "postBatchResumeToken", Value: bson.D{{Key: "_data", Value: token}}},
+		}},
+	}
+}
+
+// savedToken returns the _data of the token saved for a consumer
+func savedToken(t *testing.T, store TokenStore, name string) string {
+	token, err := store.Load(context.Background(), name)
+	assert.NoError(t, err)
+	if token == nil {
+		return ""
+	}
+	return token.Lookup("_data").StringValue()
+}
+
+func TestMemoryTokenStore(t *testing.T) {
+	ctx := context.Background()
+	var store MemoryTokenStore
+
+	token, err := store.Load(ctx, "orders")
+	assert.NoError(t, err)
+	assert.Nil(t, token)
+
+	saved, err := bson.Marshal(bson.D{{Key: "_data", Value: "a"}})
+	assert.NoError(t, err)
+	assert.NoError(t, store.Save(ctx, "orders", saved))
+	assert.Equal(t, "a", savedToken(t, &store, "orders"))
+	assert.Equal(t, "", savedToken(t, &store, "invoices"))
+}
+
+func TestMongoTokenStore(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	ctx := context.Background()
+	cfg := Config{URI: "mongodb://localhost:27017", Database: "testdb"}
+
+	mt.Run("load missing", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "testdb."+DefaultTokenCollection, mtest.FirstBatch))
+		token, err := NewMongoTokenStore(createTestManager(mt, cfg), "").Load(ctx, "orders")
+		assert.NoError(t, err)
+		assert.Nil(t, token)
+	})
+
+	mt.Run("save and load", func(mt *mtest.T) {
+		store := NewMongoTokenStore(createTestManager(mt, cfg), "tokens")
+		raw, err := bson.Marshal(bson.D{{Key: "_data", Value: "a"}})
+		assert.NoError(t, err)
+		token := bson.Raw(raw)
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		assert.NoError(t, store.Save(ctx, "orders", token))
+		update := mt.GetStartedEvent().Command
+		assert.Equal(t, "tokens", update.Lookup("update").StringValue())
+		assert.Equal(t, "orders", update.Lookup("updates", "0", "q", "_id").StringValue())
+		assert.True(t, update.Lookup("updates", "0", "upsert").Boolean())
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "testdb.tokens", mtest.FirstBatch, bson.D{{Key: "_id", Value: "orders"}, {Key: "token", Value: token}}))
+		assert.Equal(t, "a", savedToken(t, store, "orders"))
+	})
+}
+
+func TestChangeStreamConsumer(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	cfg := Config{URI: "mongodb://localhost:27017", Database: "testdb"}
+	fastRetry := WithConsumerRetry(ConnectRetryConfig{BaseDelay: 1, MaxDelay: 1})
+
+	mt.Run("handles events and checkpoints tokens", func(mt *mtest.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		store := NewMemoryTokenStore()
+		resume, err := bson.Marshal(bson.D{{Key: "_data", Value: "t0"}})
+		assert.NoError(t, err)
+		assert.NoError(t, store.Save(ctx, "orders", resume))
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(1, "testdb.orders", mtest.FirstBatch,
+			changeEvent("t1", "insert", 1), changeEvent("t2", "update", 2)))
+
+		var handled []ChangeEvent
+		consumer := NewChangeStreamConsumer(createTestManager(mt, cfg), "orders", func(_ context.Context, event ChangeEvent) error {
+			handled = append(handled, event)
+			if len(handled) == 2 {
+				cancel()
+			}
+			return nil
+		}, WithConsumerCollection("orders"), WithTokenStore(store))
+
+		assert.NoError(t, consumer.Run(ctx))
+		assert.Len(t, handled, 2)
+		assert.Equal(t, "insert", handled[0].OperationType)
+		assert.Equal(t, ChangeNamespace{Database: "testdb", Collection: "orders"}, handled[0].Namespace)
+		assert.Equal(t, "t1", handled[0].ResumeToken.Lookup("_data").StringValue())
+		var order struct {
+			Total int `bson:"total"`
+		}
+		assert.NoError(t, handled[1].DecodeFullDocument(&order))
+		assert.Equal(t, 20, order.Total)
+		assert.Equal(t, "t2", savedToken(t, store, "orders"))
+
+		aggregate := mt.GetAllStartedEvents()[0].Command
+		assert.Equal(t, "orders", aggregate.Lookup("aggregate").StringValue())
+		assert.Equal(t, "t0", aggregate.Lookup("pipeline", "0", "$changeStream", "startAfter", "_data").StringValue())
+	})
+
+	mt.Run("checkpoints the post-batch token of quiet batches", func(mt *mtest.T) {
+		store := NewMemoryTokenStore()
+		mt.AddMockResponses(
+			quietBatch(mtest.FirstBatch, "t1"),
+			quietBatch(mtest.NextBatch, "t2"),
+			mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 13, Name: "Unauthorized", Message: "not authorized"}),
+		)
+
+		consumer := NewChangeStreamConsumer(createTestManager(mt, cfg), "orders", func(context.Context, ChangeEvent) error {
+			return nil
+		}, WithConsumerCollection("orders"), WithTokenStore(store))
+
+		assert.ErrorContains(t, consumer.Run(context.Background()), "not authorized")
+		assert.Equal(t, "t2", savedToken(t, store, "orders"))
+	})
+
+	mt.Run("handler error stops before the checkpoint", func(mt *mtest.T) {
+		failure := errors.New("handler failed")
+		store := NewMemoryTokenStore()
+		mt.AddMockResponses(mtest.CreateCursorResponse(1, "testdb.orders", mtest.FirstBatch,
+			changeEvent("t1", "insert", 1), changeEvent("t2", "insert", 2)))
+
+		consumer := NewChangeStreamConsumer(createTestManager(mt, cfg), "orders", func(_ context.Context, event ChangeEvent) error {
+			if event.DocumentKey.Lookup("_id").Int32() == 2 {
+				return failure
+			}
+			return nil
+		}, WithTokenStore(store), WithCheckpointEvery(10))
+
+		err := consumer.Run(context.Background())
+		assert.ErrorIs(t, err, failure)
+		assert.Equal(t, "t1", savedToken(t, store, "orders"))
+		assert.Equal(t, int32(1), mt.GetAllStartedEvents()[0].Command.Lookup("aggregate").Int32())
+	})
+
+	mt.Run("invalidate", func(mt *mtest.T) {
+		store := NewMemoryTokenStore()
+		mt.AddMockResponses(mtest.CreateCursorResponse(1, "testdb.orders", mtest.FirstBatch,
+			changeEvent("t1", "drop", 1), changeEvent("t2", "invalidate", 0)))
+
+		consumer := NewChangeStreamConsumer(createTestManager(mt, cfg), "orders", func(context.Context, ChangeEvent) error {
+			return nil
+		}, WithConsumerCollection("orders"), WithTokenStore(store))
+
+		assert.ErrorIs(t, consumer.Run(context.Background()), ErrChangeStreamInvalidated)
+		assert.Equal(t, "t2", savedToken(t, store, "orders"))
+	})
+
+	mt.Run("restart on invalidate", func(mt *mtest.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "testdb.orders", mtest.FirstBatch, changeEvent("t1", "invalidate", 0)),
+			mtest.CreateCursorResponse(1, "testdb.orders", mtest.FirstBatch, changeEvent("t2", "insert", 1)),
+		)
+
+		var operations []string
+		consumer := NewChangeStreamConsumer(createTestManager(mt, cfg), "orders", func(_ context.Context, event ChangeEvent) error {
+			operations = append(operations, event.OperationType)
+			if event.OperationType == "insert" {
+				cancel()
+			}
+			return nil
+		}, WithConsumerCollection("orders"), WithRestartOnInvalidate(), fastRetry)
+
+		assert.NoError(t, consumer.Run(ctx))
+		assert.Equal(t, []string{"invalidate", "insert"}, operations)
+
+		var aggregates []bson.Raw
+		for _, evt := range mt.GetAllStartedEvents() {
+			if evt.CommandName == "aggregate" {
+				aggregates = append(aggregates, evt.Command)
+			}
+		}
+		assert.GreaterOrEqual(t, len(aggregates), 2)
+		assert.Equal(t, "t1", aggregates[1].Lookup("pipeline", "0", "$changeStream", "startAfter", "_data").StringValue())
+	})
+
+	mt.Run("history lost", func(mt *mtest.T) {
+		store := NewMemoryTokenStore()
+		resume, err := bson.Marshal(bson.D{{Key: "_data", Value: "t0"}})
+		assert.NoError(t, err)
+		assert.NoError(t, store.Save(context.Background(), "orders", resume))
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 286, Name: "ChangeStreamHistoryLost", Message: "resume point no longer in the oplog"}))
+
+		consumer := NewChangeStreamConsumer(createTestManager(mt, cfg), "orders", func(context.Context, ChangeEvent) error {
+			return nil
+		}, WithTokenStore(store), fastRetry)
+
+		assert.ErrorIs(t, consumer.Run(context.Background()), ErrChangeStreamHistoryLost)
+		assert.Equal(t, "t0", savedToken(t, store, "orders"))
+	})
+
+	mt.Run("restart on history lost", func(mt *mtest.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		store := NewMemoryTokenStore()
+		resume, err := bson.Marshal(bson.D{{Key: "_data", Value: "t0"}})
+		assert.NoError(t, err)
+		assert.NoError(t, store.Save(ctx, "orders", resume))
+		mt.AddMockResponses(
+			mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 286, Name: "ChangeStreamHistoryLost", Message: "resume point no longer in the oplog"}),
+			mtest.CreateCursorResponse(1, "testdb.orders", mtest.FirstBatch, changeEvent("t1", "insert", 1)),
+		)
+
+		consumer := NewChangeStreamConsumer(createTestManager(mt, cfg), "orders", func(context.Context, ChangeEvent) error {
+			cancel()
+			return nil
+		}, WithConsumerCollection("orders"), WithTokenStore(store), WithRestartOnHistoryLost(), fastRetry)
+
+		assert.NoError(t, consumer.Run(ctx))
+		assert.Equal(t, "t1", savedToken(t, store, "orders"))
+
+		events := mt.GetAllStartedEvents()
+		assert.GreaterOrEqual(t, len(events), 2)
+		assert.Equal(t, "t0", events[0].Command.Lookup("pipeline", "0", "$changeStream", "startAfter", "_data").StringValue())
+		_, err = events[1].Command.LookupErr("pipeline", "0", "$changeStream", "startAfter")
+		assert.Error(t, err)
+	})
+
+	mt.Run("reconnects after resumable errors", func(mt *mtest.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		resumable := mtest.CommandError{Code: 999, Name: "Interrupted", Message: "interrupted", Labels: []string{"ResumableChangeStreamError"}}
+		mt.AddMockResponses(
+			mtest.CreateCommandErrorResponse(resumable),
+			mtest.CreateCursorResponse(1, "testdb.orders", mtest.FirstBatch, changeEvent("t1", "insert", 1)),
+		)
+
+		handled := 0
+		consumer := NewChangeStreamConsumer(createTestManager(mt, cfg), "orders", func(context.Context, ChangeEvent) error {
+			handled++
+			cancel()
+			return nil
+		}, fastRetry)
+
+		assert.NoError(t, consumer.Run(ctx))
+		assert.Equal(t, 1, handled)
+	})
+
+	mt.Run("gives up after max attempts", func(mt *mtest.T) {
+		resumable := mtest.CommandError{Code: 999, Name: "Interrupted", Message: "interrupted", Labels: []string{"ResumableChangeStreamError"}}
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(resumable), mtest.CreateCommandErrorResponse(resumable))
+
+		consumer := NewChangeStreamConsumer(createTestManager(mt, cfg), "orders", func(context.Context, ChangeEvent) error {
+			return nil
+		}, WithConsumerRetry(ConnectRetryConfig{MaxAttempts: 1, BaseDelay: 1, MaxDelay: 1}))
+
+		err := consumer.Run(context.Background())
+		assert.ErrorContains(t, err, "giving up after 1 reconnects")
+	})
+
+	mt.Run("non-resumable error", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 13, Name: "Unauthorized", Message: "not authorized"}))
+
+		consumer := NewChangeStreamConsumer(createTestManager(mt, cfg), "orders", func(context.Context, ChangeEvent) error {
+			return nil
+		}, fastRetry)
+
+		err := consumer.Run(context.Background())
+		assert.ErrorContains(t, err, "not authorized")
+		assert.Len(t, mt.GetAllStartedEvents(), 1)
+	})
+}
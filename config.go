@@ -1,7 +1,11 @@
 package mongodb
 
 import (
+	"fmt"
+	"strings"
 	"time"
+
+	"go.mongodb.org/mongo-driver/x/mongo/driver/connstring"
 )
 
 // Config holds MongoDB configuration.
@@ -40,6 +44,9 @@ type Config struct {
 	// Modes: primary, primaryPreferred, secondary, secondaryPreferred, nearest
 	ReadPreference ReadPreferenceConfig `yaml:"read_preference" mapstructure:"read_preference"`
 
+	// Named read preference profiles that can be applied per database/collection
+	ReadPreferenceProfiles map[string]ReadPreferenceConfig `yaml:"read_preference_profiles" mapstructure:"read_preference_profiles"`
+
 	// Read concern configuration
 	// Levels: local, available, majority, linearizable, snapshot
 	ReadConcern ReadConcernConfig `yaml:"read_concern" mapstructure:"read_concern"`
@@ -51,6 +58,13 @@ type Config struct {
 	RetryWrites bool `yaml:"retry_writes" mapstructure:"retry_writes"` // Enable retryable writes
 	RetryReads  bool `yaml:"retry_reads" mapstructure:"retry_reads"`   // Enable retryable reads
 
+	// Retry policy for establishing the initial connection
+	ConnectRetry ConnectRetryConfig `yaml:"connect_retry" mapstructure:"connect_retry"`
+
+	// When the service provider establishes the connection
+	// Modes: lazy (on first use), eager (in Boot, failing startup), background (warm-up started in Boot)
+	ConnectMode string `yaml:"connect_mode" mapstructure:"connect_mode"`
+
 	// Compression configuration
 	Compressors []string `yaml:"compressors" mapstructure:"compressors"` // Compression algorithms: snappy, zlib, zstd
 	ZlibLevel   int      `yaml:"zlib_level" mapstructure:"zlib_level"`   // Compression level for zlib (1-9)
@@ -73,6 +87,24 @@ type Config struct {
 	ServerMonitoringMode     string `yaml:"server_monitoring_mode" mapstructure:"server_monitoring_mode"`           // Server monitoring mode: auto, stream, poll
 	DisableOCSPEndpointCheck bool   `yaml:"disable_ocsp_endpoint_check" mapstructure:"disable_ocsp_endpoint_check"` // Disable OCSP endpoint check for TLS
 
+	// Command logging configuration
+	Logging LoggingConfig `yaml:"logging" mapstructure:"logging"`
+
+	// Slow query detection configuration
+	SlowQuery SlowQueryConfig `yaml:"slow_query" mapstructure:"slow_query"`
+
+	// Health report configuration
+	Health HealthConfig `yaml:"health" mapstructure:"health"`
+
+	// Keyset pagination configuration
+	Pagination PaginationConfig `yaml:"pagination" mapstructure:"pagination"`
+
+	// Declared indexes by collection of the default database, see IndexSync
+	Indexes map[string][]IndexConfig `yaml:"indexes" mapstructure:"indexes"`
+
+	// Collection validator settings applied by ApplyValidator
+	Validation ValidationConfig `yaml:"validation" mapstructure:"validation"`
+
 	// BSON configuration
 	BSON BSONConfig `yaml:"bson" mapstructure:"bson"`
 
@@ -86,7 +118,9 @@ type TLSConfig struct {
 	InsecureSkipVerify bool   `yaml:"insecure_skip_verify" mapstructure:"insecure_skip_verify"` // Skip certificate verification (for testing only)
 	CAFile             string `yaml:"ca_file" mapstructure:"ca_file"`                           // Path to CA certificate file
 	CertFile           string `yaml:"cert_file" mapstructure:"cert_file"`                       // Path to client certificate file
-	KeyFile            string `yaml:"key_file" mapstructure:"key_file"`                         // Path to client private key file
+	KeyFile            string `yaml:"key_file" mapstructure:"key_file"`                         // Path to client private key file (may be omitted when cert_file is a combined PEM)
+	KeyPassword        string `yaml:"key_password" mapstructure:"key_password"`                 // Password for an encrypted private key (PKCS#8 or legacy PEM)
+	Reload             bool   `yaml:"reload" mapstructure:"reload"`                             // Reload CA and client certificates when the files change on disk
 }
 
 // AuthConfig holds authentication configuration.
@@ -102,7 +136,7 @@ type AuthConfig struct {
 type ReadPreferenceConfig struct {
 	Mode         string              `yaml:"mode" mapstructure:"mode"`                   // Read preference mode
 	TagSets      []map[string]string `yaml:"tag_sets" mapstructure:"tag_sets"`           // Tag sets for read preference
-	MaxStaleness int                 `yaml:"max_staleness" mapstructure:"max_staleness"` // Maximum staleness in seconds
+	MaxStaleness int                 `yaml:"max_staleness" mapstructure:"max_staleness"` // Maximum staleness in seconds (0 = no maximum, otherwise at least 90; not allowed with primary)
 	HedgeEnabled bool                `yaml:"hedge_enabled" mapstructure:"hedge_enabled"` // Enable hedge reads for sharded clusters
 }
 
@@ -118,6 +152,64 @@ type WriteConcernConfig struct {
 	WTimeout int         `yaml:"w_timeout" mapstructure:"w_timeout"` // Write timeout in milliseconds
 }
 
+// ConnectRetryConfig holds the retry policy used while establishing the connection.
+type ConnectRetryConfig struct {
+	MaxAttempts int     `yaml:"max_attempts" mapstructure:"max_attempts"` // Maximum number of connection attempts (0 = default)
+	BaseDelay   uint64  `yaml:"base_delay" mapstructure:"base_delay"`     // Delay (ms) after the first failure, doubled after each further failure (0 = default)
+	MaxDelay    uint64  `yaml:"max_delay" mapstructure:"max_delay"`       // Upper bound (ms) for the delay between attempts (0 = default)
+	Jitter      float64 `yaml:"jitter" mapstructure:"jitter"`             // Random fraction (0-1) removed from each delay
+}
+
+// LoggingConfig holds command logging configuration.
+type LoggingConfig struct {
+	Enabled       bool              `yaml:"enabled" mapstructure:"enabled"`                 // Log command started/succeeded/failed events
+	Level         string            `yaml:"level" mapstructure:"level"`                     // Level of started and succeeded events: debug, info, warn, error (failures are logged at error)
+	CommandLevels map[string]string `yaml:"command_levels" mapstructure:"command_levels"`   // Per command name level overrides, "off" silences a command
+	MaxBodyLength int               `yaml:"max_body_length" mapstructure:"max_body_length"` // Maximum length of the redacted command body (0 = do not log bodies)
+}
+
+// SlowQueryConfig holds slow query detection configuration.
+type SlowQueryConfig struct {
+	Enabled        bool     `yaml:"enabled" mapstructure:"enabled"`                 // Report commands slower than the threshold
+	Threshold      uint64   `yaml:"threshold" mapstructure:"threshold"`             // Duration (ms) from which a command is reported
+	Explain        bool     `yaml:"explain" mapstructure:"explain"`                 // Attach the explain summary of the winning plan to reports
+	IgnoreCommands []string `yaml:"ignore_commands" mapstructure:"ignore_commands"` // Command names never reported
+}
+
+// HealthConfig holds health report configuration.
+type HealthConfig struct {
+	Checks               []string `yaml:"checks" mapstructure:"checks"`                                 // Checks run by HealthReport and the readiness handler (empty = ping)
+	LivenessChecks       []string `yaml:"liveness_checks" mapstructure:"liveness_checks"`               // Checks run by the liveness handler (empty = do not contact MongoDB)
+	Timeout              uint64   `yaml:"timeout" mapstructure:"timeout"`                               // Timeout (ms) of a report when the context has no deadline
+	MaxReplicationLag    uint64   `yaml:"max_replication_lag" mapstructure:"max_replication_lag"`       // Lag (ms) of a secondary from which the report is degraded
+	MaxPoolUsage         float64  `yaml:"max_pool_usage" mapstructure:"max_pool_usage"`                 // Fraction (0-1) of max_pool_size in use from which the report is degraded
+	WriteProbeCollection string   `yaml:"write_probe_collection" mapstructure:"write_probe_collection"` // Collection of the default database written by the write check
+}
+
+// PaginationConfig holds keyset pagination configuration.
+type PaginationConfig struct {
+	Secret          string `yaml:"secret" mapstructure:"secret"`                       // Key signing page tokens (empty = random per process, tokens do not survive restarts or work across instances)
+	DefaultPageSize int    `yaml:"default_page_size" mapstructure:"default_page_size"` // Page size when the query does not set one
+	MaxPageSize     int    `yaml:"max_page_size" mapstructure:"max_page_size"`         // Upper bound of the page size (0 = unlimited)
+}
+
+// IndexConfig declares an index of a collection.
+type IndexConfig struct {
+	Name               string                 `yaml:"name" mapstructure:"name"`                                 // Index name (empty = generated from the keys, e.g. status_1_created_at_-1)
+	Keys               []string               `yaml:"keys" mapstructure:"keys"`                                 // Fields in order as "field" or "field:direction", direction being 1, -1, text, hashed, 2d or 2dsphere
+	Unique             bool                   `yaml:"unique" mapstructure:"unique"`                             // Reject duplicate keys
+	Sparse             bool                   `yaml:"sparse" mapstructure:"sparse"`                             // Skip documents without the indexed fields
+	Hidden             bool                   `yaml:"hidden" mapstructure:"hidden"`                             // Hide the index from the query planner
+	ExpireAfterSeconds *int32                 `yaml:"expire_after_seconds" mapstructure:"expire_after_seconds"` // TTL of the documents (nil = no TTL)
+	PartialFilter      map[string]interface{} `yaml:"partial_filter" mapstructure:"partial_filter"`             // Only index documents matching this filter
+}
+
+// ValidationConfig holds the collection validation settings applied with validators.
+type ValidationConfig struct {
+	Level  string `yaml:"level" mapstructure:"level"`   // Validation level: strict, moderate, off
+	Action string `yaml:"action" mapstructure:"action"` // Validation action: error, warn
+}
+
 // SRVConfig holds SRV configuration for DNS-based discovery.
 type SRVConfig struct {
 	MaxHosts    int    `yaml:"max_hosts" mapstructure:"max_hosts"`       // Maximum number of hosts to connect to (0 = no limit)
@@ -148,6 +240,14 @@ type AutoEncryptionConfig struct {
 	ExtraOptions         map[string]interface{} `yaml:"extra_options" mapstructure:"extra_options"`                   // Extra options for auto-encryption
 }
 
+// ConnectionsConfig holds the named connection settings read from the mongodb section.
+//
+// Each entry under connections accepts the same keys as the top-level mongodb section.
+type ConnectionsConfig struct {
+	DefaultConnection string                 `yaml:"default_connection" mapstructure:"default_connection"` // Name of the default connection ("default" = the top-level settings)
+	Connections       map[string]interface{} `yaml:"connections" mapstructure:"connections"`               // Named connection settings
+}
+
 // DefaultConfig returns default MongoDB configuration.
 func DefaultConfig() *Config {
 	return &Config{
@@ -176,9 +276,10 @@ func DefaultConfig() *Config {
 		ReadPreference: ReadPreferenceConfig{
 			Mode:         "primary",
 			TagSets:      []map[string]string{},
-			MaxStaleness: DefaultMaxStaleness,
+			MaxStaleness: 0,
 			HedgeEnabled: false,
 		},
+		ReadPreferenceProfiles: make(map[string]ReadPreferenceConfig),
 		ReadConcern: ReadConcernConfig{
 			Level: "majority",
 		},
@@ -187,8 +288,15 @@ func DefaultConfig() *Config {
 			Journal:  true,
 			WTimeout: DefaultWTimeout,
 		},
-		RetryWrites:  true,
-		RetryReads:   true,
+		RetryWrites: true,
+		RetryReads:  true,
+		ConnectRetry: ConnectRetryConfig{
+			MaxAttempts: DefaultConnectMaxAttempts,
+			BaseDelay:   DefaultConnectBaseDelay,
+			MaxDelay:    DefaultConnectMaxDelay,
+			Jitter:      DefaultConnectJitter,
+		},
+		ConnectMode:  ConnectModeLazy,
 		Compressors:  []string{},
 		ZlibLevel:    DefaultZlibLevel,
 		ZstdLevel:    DefaultZstdLevel,
@@ -205,6 +313,36 @@ func DefaultConfig() *Config {
 		},
 		ServerMonitoringMode:     "auto",
 		DisableOCSPEndpointCheck: false,
+		Logging: LoggingConfig{
+			Enabled:       false,
+			Level:         "debug",
+			CommandLevels: make(map[string]string),
+			MaxBodyLength: DefaultLogMaxBodyLength,
+		},
+		SlowQuery: SlowQueryConfig{
+			Enabled:        false,
+			Threshold:      DefaultSlowQueryThreshold,
+			Explain:        false,
+			IgnoreCommands: []string{"getMore", "hello", "isMaster", "ping", "endSessions"},
+		},
+		Health: HealthConfig{
+			Checks:               []string{HealthCheckPing, HealthCheckPrimary, HealthCheckReplicationLag, HealthCheckPool},
+			LivenessChecks:       []string{},
+			Timeout:              uint64(DefaultHealthCheckTimeout / time.Millisecond),
+			MaxReplicationLag:    DefaultHealthMaxReplicationLag,
+			MaxPoolUsage:         DefaultHealthMaxPoolUsage,
+			WriteProbeCollection: DefaultHealthWriteProbeCollection,
+		},
+		Pagination: PaginationConfig{
+			Secret:          "",
+			DefaultPageSize: DefaultPageSize,
+			MaxPageSize:     DefaultMaxPageSize,
+		},
+		Indexes: make(map[string][]IndexConfig),
+		Validation: ValidationConfig{
+			Level:  ValidationLevelStrict,
+			Action: ValidationActionError,
+		},
 		BSON: BSONConfig{
 			UseJSONStructTags:     false,
 			ErrorOnInlineMap:      false,
@@ -220,6 +358,80 @@ func DefaultConfig() *Config {
 	}
 }
 
+// Validate checks the configuration without connecting to MongoDB.
+//
+// Configured TLS files are read so that missing or malformed certificates are reported.
+// The returned error wraps ErrInvalidURI or ErrInvalidConfig.
+func (c *Config) Validate() error {
+	if err := validateURI(c.URI); err != nil {
+		return err
+	}
+	if err := validateReadPreferences(*c); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	if _, err := newServerAPIOptions(c.ServerAPI); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	if _, err := newAutoEncryptionOptions(c.AutoEncryption); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	if c.TLS.Enabled {
+		if _, err := newTLSConfig(c.TLS); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+		}
+	}
+	if c.ConnectRetry.MaxAttempts < 0 {
+		return fmt.Errorf("%w: connect_retry max_attempts must not be negative", ErrInvalidConfig)
+	}
+	if c.ConnectRetry.Jitter < 0 || c.ConnectRetry.Jitter > 1 {
+		return fmt.Errorf("%w: connect_retry jitter must be between 0 and 1", ErrInvalidConfig)
+	}
+	if err := c.Logging.validate(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	if err := c.SlowQuery.validate(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	if err := c.Health.validate(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	if err := c.Pagination.validate(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	if err := validateIndexes(c.Indexes); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	if err := c.Validation.validate(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	switch c.ConnectMode {
+	case "", ConnectModeLazy, ConnectModeEager, ConnectModeBackground:
+	default:
+		return fmt.Errorf("%w: connect_mode %q must be one of %s, %s, %s", ErrInvalidConfig, c.ConnectMode, ConnectModeLazy, ConnectModeEager, ConnectModeBackground)
+	}
+	return nil
+}
+
+// validateURI checks the connection URI without resolving any host.
+func validateURI(uri string) error {
+	switch {
+	case uri == "":
+		return fmt.Errorf("%w: URI is required", ErrInvalidURI)
+	case strings.HasPrefix(uri, connstring.SchemeMongoDBSRV+"://"):
+		// SRV URIs are resolved through DNS when connecting, only the host is checked here
+		if strings.TrimPrefix(uri, connstring.SchemeMongoDBSRV+"://") == "" {
+			return fmt.Errorf("%w: missing host in %q", ErrInvalidURI, uri)
+		}
+		return nil
+	case strings.HasPrefix(uri, connstring.SchemeMongoDB+"://"):
+		if _, err := connstring.ParseAndValidate(uri); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidURI, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: scheme must be %q or %q", ErrInvalidURI, connstring.SchemeMongoDB, connstring.SchemeMongoDBSRV)
+}
+
 // GetConnectTimeout returns connection timeout as time.Duration.
 func (c *Config) GetConnectTimeout() time.Duration {
 	return time.Duration(c.ConnectTimeout) * time.Millisecond
@@ -1,6 +1,7 @@
 package mongodb_test
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 	"time"
@@ -35,9 +36,10 @@ func TestDefaultConfig(t *testing.T) {
 		ReadPreference: mongodb.ReadPreferenceConfig{
 			Mode:         "primary",
 			TagSets:      []map[string]string{},
-			MaxStaleness: 90,
+			MaxStaleness: 0,
 			HedgeEnabled: false,
 		},
+		ReadPreferenceProfiles: make(map[string]mongodb.ReadPreferenceConfig),
 		ReadConcern: mongodb.ReadConcernConfig{
 			Level: "majority",
 		},
@@ -46,8 +48,15 @@ func TestDefaultConfig(t *testing.T) {
 			Journal:  true,
 			WTimeout: 30000,
 		},
-		RetryWrites:  true,
-		RetryReads:   true,
+		RetryWrites: true,
+		RetryReads:  true,
+		ConnectRetry: mongodb.ConnectRetryConfig{
+			MaxAttempts: 3,
+			BaseDelay:   1000,
+			MaxDelay:    30000,
+			Jitter:      0.2,
+		},
+		ConnectMode:  "lazy",
 		Compressors:  []string{},
 		ZlibLevel:    6,
 		ZstdLevel:    6,
@@ -64,6 +73,36 @@ func TestDefaultConfig(t *testing.T) {
 		},
 		ServerMonitoringMode:     "auto",
 		DisableOCSPEndpointCheck: false,
+		Logging: mongodb.LoggingConfig{
+			Enabled:       false,
+			Level:         "debug",
+			CommandLevels: make(map[string]string),
+			MaxBodyLength: 1000,
+		},
+		SlowQuery: mongodb.SlowQueryConfig{
+			Enabled:        false,
+			Threshold:      100,
+			Explain:        false,
+			IgnoreCommands: []string{"getMore", "hello", "isMaster", "ping", "endSessions"},
+		},
+		Health: mongodb.HealthConfig{
+			Checks:               []string{"ping", "primary", "replication_lag", "pool"},
+			LivenessChecks:       []string{},
+			Timeout:              5000,
+			MaxReplicationLag:    10000,
+			MaxPoolUsage:         0.9,
+			WriteProbeCollection: "_health",
+		},
+		Pagination: mongodb.PaginationConfig{
+			Secret:          "",
+			DefaultPageSize: 20,
+			MaxPageSize:     100,
+		},
+		Indexes: map[string][]mongodb.IndexConfig{},
+		Validation: mongodb.ValidationConfig{
+			Level:  "strict",
+			Action: "error",
+		},
 		BSON: mongodb.BSONConfig{
 			UseJSONStructTags:     false,
 			ErrorOnInlineMap:      false,
@@ -159,3 +198,49 @@ func TestConfig_GetWTimeout(t *testing.T) {
 		t.Errorf("GetWTimeout() = %v, want %v", actual, expected)
 	}
 }
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(cfg *mongodb.Config)
+		wantErr error
+	}{
+		{name: "default config", mutate: func(cfg *mongodb.Config) {}},
+		{name: "srv uri", mutate: func(cfg *mongodb.Config) { cfg.URI = "mongodb+srv://cluster0.example.net" }},
+		{name: "empty uri", mutate: func(cfg *mongodb.Config) { cfg.URI = "" }, wantErr: mongodb.ErrInvalidURI},
+		{name: "unknown scheme", mutate: func(cfg *mongodb.Config) { cfg.URI = "invalid-uri://bad" }, wantErr: mongodb.ErrInvalidURI},
+		{name: "malformed uri", mutate: func(cfg *mongodb.Config) { cfg.URI = "mongodb://localhost:99999" }, wantErr: mongodb.ErrInvalidURI},
+		{name: "srv without host", mutate: func(cfg *mongodb.Config) { cfg.URI = "mongodb+srv://" }, wantErr: mongodb.ErrInvalidURI},
+		{name: "invalid read preference", mutate: func(cfg *mongodb.Config) { cfg.ReadPreference.Mode = "fastest" }, wantErr: mongodb.ErrInvalidConfig},
+		{name: "unsupported server API", mutate: func(cfg *mongodb.Config) { cfg.ServerAPI.Version = "99" }, wantErr: mongodb.ErrInvalidConfig},
+		{name: "negative attempts", mutate: func(cfg *mongodb.Config) { cfg.ConnectRetry.MaxAttempts = -1 }, wantErr: mongodb.ErrInvalidConfig},
+		{name: "jitter out of range", mutate: func(cfg *mongodb.Config) { cfg.ConnectRetry.Jitter = 1.5 }, wantErr: mongodb.ErrInvalidConfig},
+		{name: "invalid log level", mutate: func(cfg *mongodb.Config) { cfg.Logging.Level = "verbose" }, wantErr: mongodb.ErrInvalidConfig},
+		{name: "zero slow query threshold", mutate: func(cfg *mongodb.Config) { cfg.SlowQuery.Enabled = true; cfg.SlowQuery.Threshold = 0 }, wantErr: mongodb.ErrInvalidConfig},
+		{name: "unknown health check", mutate: func(cfg *mongodb.Config) { cfg.Health.Checks = []string{"listDatabases"} }, wantErr: mongodb.ErrInvalidConfig},
+		{name: "default page size above maximum", mutate: func(cfg *mongodb.Config) { cfg.Pagination.DefaultPageSize = 500 }, wantErr: mongodb.ErrInvalidConfig},
+		{name: "invalid index key", mutate: func(cfg *mongodb.Config) {
+			cfg.Indexes = map[string][]mongodb.IndexConfig{"users": {{Keys: []string{"email:up"}}}}
+		}, wantErr: mongodb.ErrInvalidConfig},
+		{name: "unknown validation level", mutate: func(cfg *mongodb.Config) { cfg.Validation.Level = "lenient" }, wantErr: mongodb.ErrInvalidConfig},
+		{name: "unknown connect mode", mutate: func(cfg *mongodb.Config) { cfg.ConnectMode = "sometimes" }, wantErr: mongodb.ErrInvalidConfig},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := mongodb.DefaultConfig()
+			tt.mutate(cfg)
+
+			err := cfg.Validate()
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() = %v, want error wrapping %v", err, tt.wantErr)
+			}
+		})
+	}
+}
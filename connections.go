@@ -0,0 +1,183 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"go.fork.vn/config"
+)
+
+// DefaultConnectionName is the name of the connection built from the top-level mongodb settings
+const DefaultConnectionName = "default"
+
+// reservedConnectionNames cannot be used as connection names because the names are
+// registered in the DI container as "mongodb.<name>" next to the provider's own bindings
+var reservedConnectionNames = map[string]bool{
+	"client":      true,
+	"database":    true,
+	"connections": true,
+}
+
+// Connections defines a registry of named MongoDB managers
+type Connections interface {
+	// Connection returns the manager of a named connection (empty name for the default)
+	Connection(name string) Manager
+
+	// Has reports whether a connection with the given name is configured
+	Has(name string) bool
+
+	// Default returns the manager of the default connection
+	Default() Manager
+
+	// DefaultName returns the name of the default connection
+	DefaultName() string
+
+	// Names returns the configured connection names in sorted order
+	Names() []string
+
+	// Shutdown gracefully closes every connection, see Manager.Shutdown
+	Shutdown(ctx context.Context) error
+}
+
+// connections implements the Connections interface
+type connections struct {
+	managers    map[string]Manager
+	defaultName string
+}
+
+// NewConnections creates a registry with one manager per named configuration.
+//
+// Every configuration is validated up front, connections themselves are established
+// lazily on first use. defaultName must be one of the configured names. A metrics
+// recorder implementing ConnectionMetricsRecorder records each connection under its name.
+func NewConnections(configs map[string]Config, defaultName string, opts ...Option) (Connections, error) {
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("%w: at least one connection is required", ErrInvalidConfig)
+	}
+	if _, ok := configs[defaultName]; !ok {
+		return nil, fmt.Errorf("%w: default connection %q is not configured", ErrInvalidConfig, defaultName)
+	}
+
+	managers := make(map[string]Manager, len(configs))
+	for name, cfg := range configs {
+		if err := validateConnectionName(name); err != nil {
+			return nil, err
+		}
+		m, err := newManager(cfg, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("connection %q: %w", name, err)
+		}
+		if m.metrics != nil {
+			m.metrics = m.metrics.forConnection(name)
+		}
+		managers[name] = m
+	}
+
+	return &connections{managers: managers, defaultName: defaultName}, nil
+}
+
+// validateConnectionName rejects names that cannot be registered in the DI container
+func validateConnectionName(name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: connection name is required", ErrInvalidConfig)
+	}
+	if reservedConnectionNames[name] {
+		return fmt.Errorf("%w: connection name %q is reserved", ErrInvalidConfig, name)
+	}
+	return nil
+}
+
+// Connection returns the manager of a named connection (empty name for the default)
+//
+// Connection panics when no connection with that name is configured; use Has to check first.
+func (c *connections) Connection(name string) Manager {
+	if name == "" {
+		name = c.defaultName
+	}
+	m, ok := c.managers[name]
+	if !ok {
+		panic(fmt.Sprintf("MongoDB connection %q is not configured", name))
+	}
+	return m
+}
+
+// Has reports whether a connection with the given name is configured
+func (c *connections) Has(name string) bool {
+	_, ok := c.managers[name]
+	return ok
+}
+
+// Default returns the manager of the default connection
+func (c *connections) Default() Manager {
+	return c.managers[c.defaultName]
+}
+
+// DefaultName returns the name of the default connection
+func (c *connections) DefaultName() string {
+	return c.defaultName
+}
+
+// Names returns the configured connection names in sorted order
+func (c *connections) Names() []string {
+	names := make([]string, 0, len(c.managers))
+	for name := range c.managers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Shutdown gracefully closes every connection concurrently, see Manager.Shutdown
+func (c *connections) Shutdown(ctx context.Context) error {
+	names := c.Names()
+	errs := make([]error, len(names))
+
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.managers[name].Shutdown(ctx); err != nil {
+				errs[i] = fmt.Errorf("connection %q: %w", name, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// loadConnectionConfigs reads the default and named connection settings from the config service.
+//
+// The top-level mongodb settings form the connection named DefaultConnectionName unless
+// mongodb.connections defines an entry with that name. Every named connection starts from
+// DefaultConfig, so only the keys that differ need to be set.
+func loadConnectionConfigs(configService config.Manager) (map[string]Config, string, error) {
+	mongoConfig := DefaultConfig()
+	if err := configService.UnmarshalKey("mongodb", mongoConfig); err != nil {
+		return nil, "", err
+	}
+
+	var connectionsConfig ConnectionsConfig
+	if err := configService.UnmarshalKey("mongodb", &connectionsConfig); err != nil {
+		return nil, "", err
+	}
+
+	configs := map[string]Config{DefaultConnectionName: *mongoConfig}
+	for name := range connectionsConfig.Connections {
+		cfg := DefaultConfig()
+		if err := configService.UnmarshalKey("mongodb.connections."+name, cfg); err != nil {
+			return nil, "", fmt.Errorf("connection %q: %w", name, err)
+		}
+		configs[name] = *cfg
+	}
+
+	defaultName := connectionsConfig.DefaultConnection
+	if defaultName == "" {
+		defaultName = DefaultConnectionName
+	}
+	return configs, defaultName, nil
+}
@@ -0,0 +1,129 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	config_mocks "go.fork.vn/config/mocks"
+)
+
+func TestNewConnections(t *testing.T) {
+	configs := map[string]Config{
+		DefaultConnectionName: {URI: "mongodb://localhost:27017", Database: "app"},
+		"analytics":           {URI: "mongodb://analytics:27017", Database: "events"},
+		"audit":               {URI: "mongodb://audit:27017", Database: "audit"},
+	}
+
+	conns, err := NewConnections(configs, DefaultConnectionName)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"analytics", "audit", "default"}, conns.Names())
+	assert.Equal(t, DefaultConnectionName, conns.DefaultName())
+	assert.Equal(t, "app", conns.Default().Config().Database)
+	assert.Same(t, conns.Default(), conns.Connection(""))
+	assert.Equal(t, "events", conns.Connection("analytics").Config().Database)
+	assert.True(t, conns.Has("audit"))
+	assert.False(t, conns.Has("billing"))
+	assert.PanicsWithValue(t, `MongoDB connection "billing" is not configured`, func() {
+		conns.Connection("billing")
+	})
+
+	t.Run("custom default", func(t *testing.T) {
+		conns, err := NewConnections(configs, "analytics")
+		assert.NoError(t, err)
+		assert.Equal(t, "events", conns.Default().Config().Database)
+	})
+
+	t.Run("missing default", func(t *testing.T) {
+		_, err := NewConnections(configs, "billing")
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+	})
+
+	t.Run("reserved name", func(t *testing.T) {
+		_, err := NewConnections(map[string]Config{
+			DefaultConnectionName: {URI: "mongodb://localhost:27017"},
+			"client":              {URI: "mongodb://localhost:27017"},
+		}, DefaultConnectionName)
+		assert.ErrorContains(t, err, `"client" is reserved`)
+	})
+
+	t.Run("invalid connection config", func(t *testing.T) {
+		_, err := NewConnections(map[string]Config{
+			DefaultConnectionName: {URI: "mongodb://localhost:27017"},
+			"analytics":           {URI: "invalid-uri://bad"},
+		}, DefaultConnectionName)
+		assert.ErrorIs(t, err, ErrInvalidURI)
+		assert.ErrorContains(t, err, `connection "analytics"`)
+	})
+}
+
+func TestLoadConnectionConfigs(t *testing.T) {
+	t.Run("named connections", func(t *testing.T) {
+		configService := config_mocks.NewMockManager(t)
+		configService.EXPECT().UnmarshalKey("mongodb", mock.Anything).Run(func(_ string, out interface{}) {
+			switch v := out.(type) {
+			case *Config:
+				v.URI = "mongodb://primary:27017"
+				v.Database = "app"
+			case *ConnectionsConfig:
+				v.DefaultConnection = "analytics"
+				v.Connections = map[string]interface{}{"analytics": map[string]interface{}{}}
+			}
+		}).Return(nil)
+		configService.EXPECT().UnmarshalKey("mongodb.connections.analytics", mock.Anything).Run(func(_ string, out interface{}) {
+			out.(*Config).Database = "events"
+		}).Return(nil)
+
+		configs, defaultName, err := loadConnectionConfigs(configService)
+		assert.NoError(t, err)
+		assert.Equal(t, "analytics", defaultName)
+		assert.Len(t, configs, 2)
+		assert.Equal(t, "app", configs[DefaultConnectionName].Database)
+
+		// Named connections start from the defaults
+		assert.Equal(t, "events", configs["analytics"].Database)
+		assert.Equal(t, DefaultConfig().URI, configs["analytics"].URI)
+		assert.Equal(t, DefaultConfig().MaxPoolSize, configs["analytics"].MaxPoolSize)
+	})
+
+	t.Run("single connection", func(t *testing.T) {
+		configService := config_mocks.NewMockManager(t)
+		configService.EXPECT().UnmarshalKey("mongodb", mock.Anything).Return(nil)
+
+		configs, defaultName, err := loadConnectionConfigs(configService)
+		assert.NoError(t, err)
+		assert.Equal(t, DefaultConnectionName, defaultName)
+		assert.Equal(t, []string{DefaultConnectionName}, keys(configs))
+	})
+
+	t.Run("unmarshal error", func(t *testing.T) {
+		configService := config_mocks.NewMockManager(t)
+		configService.EXPECT().UnmarshalKey("mongodb", mock.Anything).Return(errors.New("bad yaml"))
+
+		_, _, err := loadConnectionConfigs(configService)
+		assert.EqualError(t, err, "bad yaml")
+	})
+}
+
+// keys returns the keys of a connection config map
+func keys(configs map[string]Config) []string {
+	names := make([]string, 0, len(configs))
+	for name := range configs {
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestConnections_Shutdown(t *testing.T) {
+	conns, err := NewConnections(map[string]Config{
+		DefaultConnectionName: {URI: "mongodb://localhost:27017"},
+		"analytics":           {URI: "mongodb://analytics:27017"},
+	}, DefaultConnectionName)
+	assert.NoError(t, err)
+
+	// Connections that were never used have nothing to close
+	assert.NoError(t, conns.Shutdown(context.Background()))
+}
@@ -32,8 +32,109 @@ const (
 	DefaultHeartbeat      = 10000
 	DefaultLocalThreshold = 15000
 	DefaultTimeout        = 30000
-	DefaultMaxStaleness   = 90
 	DefaultWTimeout       = 30000
 	DefaultZlibLevel      = 6
 	DefaultZstdLevel      = 6
 )
+
+// DefaultMaxStaleness was the default read preference max staleness in seconds.
+//
+// Deprecated: max_staleness defaults to 0 (no maximum) and cannot be used with mode
+// primary; 90 is only the smallest value MongoDB accepts for the other modes.
+const DefaultMaxStaleness = 90
+
+// Connect retry constants
+const (
+	// DefaultConnectMaxAttempts is the default number of attempts to establish the connection
+	DefaultConnectMaxAttempts = 3
+
+	// DefaultConnectBaseDelay is the default delay (ms) before retrying a failed connection
+	DefaultConnectBaseDelay = 1000
+
+	// DefaultConnectMaxDelay is the default upper bound (ms) for the delay between connection attempts
+	DefaultConnectMaxDelay = 30000
+
+	// DefaultConnectJitter is the default random fraction removed from each retry delay
+	DefaultConnectJitter = 0.2
+)
+
+// Connect modes used by the service provider
+const (
+	// ConnectModeLazy connects on first use
+	ConnectModeLazy = "lazy"
+
+	// ConnectModeEager connects in Boot and fails application startup when MongoDB is unavailable
+	ConnectModeEager = "eager"
+
+	// ConnectModeBackground starts connecting in Boot without blocking application startup
+	ConnectModeBackground = "background"
+)
+
+// Command logging constants
+const (
+	// DefaultLogMaxBodyLength is the default maximum length of a logged command body
+	DefaultLogMaxBodyLength = 1000
+
+	// LogLevelOff silences a command in LoggingConfig.CommandLevels
+	LogLevelOff = "off"
+)
+
+// Slow query constants
+const (
+	// DefaultSlowQueryThreshold is the default duration (ms) from which a command is reported as slow
+	DefaultSlowQueryThreshold = 100
+
+	// DefaultSlowQueryExplainTimeout bounds the explain run for a slow query report
+	DefaultSlowQueryExplainTimeout = 5 * time.Second
+
+	// DefaultSlowQueryMaxExplains bounds the explains running at once for slow query reports
+	DefaultSlowQueryMaxExplains = 4
+)
+
+// Metrics constants
+const (
+	// CommandOutcomeSuccess labels commands that succeeded in MetricsRecorder.ObserveCommand
+	CommandOutcomeSuccess = "success"
+
+	// CommandOutcomeFailure labels commands that failed in MetricsRecorder.ObserveCommand
+	CommandOutcomeFailure = "failure"
+)
+
+// Health check names accepted in HealthConfig and HealthReport
+const (
+	// HealthCheckPing pings the server and reports the round trip latency
+	HealthCheckPing = "ping"
+
+	// HealthCheckPrimary checks that a replica set has a reachable primary
+	HealthCheckPrimary = "primary"
+
+	// HealthCheckReplicationLag checks the replication lag of the secondaries
+	HealthCheckReplicationLag = "replication_lag"
+
+	// HealthCheckPool checks the connection pool usage against max_pool_size
+	HealthCheckPool = "pool"
+
+	// HealthCheckWrite upserts a document into the write probe collection
+	HealthCheckWrite = "write"
+)
+
+// Health report constants
+const (
+	// DefaultHealthMaxReplicationLag is the default lag (ms) from which a secondary degrades the report
+	DefaultHealthMaxReplicationLag = 10000
+
+	// DefaultHealthMaxPoolUsage is the default pool usage from which the report is degraded
+	DefaultHealthMaxPoolUsage = 0.9
+
+	// DefaultHealthWriteProbeCollection is the default collection written by the write check
+	DefaultHealthWriteProbeCollection = "_health"
+)
+
+// Pagination constants
+const (
+	// DefaultPageSize is the default number of documents per page
+	DefaultPageSize = 20
+
+	// DefaultMaxPageSize is the default upper bound of the page size
+	DefaultMaxPageSize = 100
+)
@@ -0,0 +1,200 @@
+package mongodb
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/mongo/driver/mongocrypt"
+)
+
+// LocalKMSProvider is the name of the KMS provider backed by a locally held master key
+const LocalKMSProvider = "local"
+
+// localMasterKeySize is the size in bytes of a local KMS master key
+const localMasterKeySize = 96
+
+// errEncryptionNotSupported is returned instead of the driver panic when encryption is used
+// in a binary built without the "cse" build tag
+var errEncryptionNotSupported = errors.New(`client-side encryption requires building with the "cse" build tag and libmongocrypt`)
+
+// encryptionSupported reports whether the driver was built with libmongocrypt
+func encryptionSupported() bool {
+	return mongocrypt.Version() != ""
+}
+
+// GenerateLocalMasterKey returns a random base64 encoded master key for the local KMS provider.
+//
+// The local provider keeps the master key in configuration and needs no external KMS,
+// which makes it suitable for development and tests:
+//
+//	auto_encryption:
+//	  key_vault_namespace: "encryption.__keyVault"
+//	  kms_providers:
+//	    local:
+//	      key: "<output of GenerateLocalMasterKey>"
+func GenerateLocalMasterKey() (string, error) {
+	key := make([]byte, localMasterKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// newKMSProviders converts the configured KMS providers into the form expected by the driver.
+//
+// The local provider key is given base64 encoded in configuration and decoded here.
+func newKMSProviders(cfg AutoEncryptionConfig) (map[string]map[string]interface{}, error) {
+	if len(cfg.KMSProviders) == 0 {
+		return nil, errors.New("at least one KMS provider is required")
+	}
+
+	providers := make(map[string]map[string]interface{}, len(cfg.KMSProviders))
+	for name, raw := range cfg.KMSProviders {
+		settings, ok := toStringMap(raw)
+		if !ok {
+			return nil, fmt.Errorf("KMS provider %q must be a map of settings", name)
+		}
+
+		if name == LocalKMSProvider || strings.HasPrefix(name, LocalKMSProvider+":") {
+			encoded, ok := settings["key"].(string)
+			if !ok || encoded == "" {
+				return nil, fmt.Errorf("KMS provider %q requires a base64 encoded key", name)
+			}
+			key, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return nil, fmt.Errorf("KMS provider %q key is not valid base64: %w", name, err)
+			}
+			if len(key) != localMasterKeySize {
+				return nil, fmt.Errorf("KMS provider %q key must be %d bytes, got %d", name, localMasterKeySize, len(key))
+			}
+			settings["key"] = key
+		}
+
+		providers[name] = settings
+	}
+	return providers, nil
+}
+
+// newSchemaMap converts the configured schema map into documents accepted by the driver.
+//
+// Schemas may be given either as maps or as extended JSON strings.
+func newSchemaMap(schemaMap map[string]interface{}) (map[string]interface{}, error) {
+	if len(schemaMap) == 0 {
+		return nil, nil
+	}
+
+	result := make(map[string]interface{}, len(schemaMap))
+	for ns, schema := range schemaMap {
+		if err := validateNamespace(ns); err != nil {
+			return nil, fmt.Errorf("schema map: %w", err)
+		}
+		if text, ok := schema.(string); ok {
+			var doc bson.D
+			if err := bson.UnmarshalExtJSON([]byte(text), false, &doc); err != nil {
+				return nil, fmt.Errorf("schema map %q is not valid extended JSON: %w", ns, err)
+			}
+			schema = doc
+		}
+		result[ns] = schema
+	}
+	return result, nil
+}
+
+// newAutoEncryptionOptions builds the driver auto-encryption options from configuration.
+//
+// It returns nil when auto-encryption is disabled.
+func newAutoEncryptionOptions(cfg AutoEncryptionConfig) (*options.AutoEncryptionOptions, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	ns, providers, err := newKeyVaultSettings(cfg)
+	if err != nil {
+		return nil, err
+	}
+	schemaMap, err := newSchemaMap(cfg.SchemaMap)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auto-encryption configuration: %w", err)
+	}
+
+	opts := options.AutoEncryption().
+		SetKeyVaultNamespace(ns).
+		SetKmsProviders(providers).
+		SetBypassAutoEncryption(cfg.BypassAutoEncryption)
+	if schemaMap != nil {
+		opts.SetSchemaMap(schemaMap)
+	}
+	if len(cfg.ExtraOptions) > 0 {
+		opts.SetExtraOptions(cfg.ExtraOptions)
+	}
+	return opts, nil
+}
+
+// newClientEncryptionOptions builds the options used for explicit encryption and data key management.
+func newClientEncryptionOptions(cfg AutoEncryptionConfig) (*options.ClientEncryptionOptions, error) {
+	ns, providers, err := newKeyVaultSettings(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return options.ClientEncryption().
+		SetKeyVaultNamespace(ns).
+		SetKmsProviders(providers), nil
+}
+
+// newKeyVaultSettings validates the key vault namespace and KMS providers shared by
+// automatic and explicit encryption.
+func newKeyVaultSettings(cfg AutoEncryptionConfig) (string, map[string]map[string]interface{}, error) {
+	if err := validateNamespace(cfg.KeyVaultNamespace); err != nil {
+		return "", nil, fmt.Errorf("invalid auto-encryption configuration: key_vault_namespace: %w", err)
+	}
+	providers, err := newKMSProviders(cfg)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid auto-encryption configuration: %w", err)
+	}
+	return cfg.KeyVaultNamespace, providers, nil
+}
+
+// validateNamespace checks that ns has the form "database.collection".
+func validateNamespace(ns string) error {
+	db, coll, ok := strings.Cut(ns, ".")
+	if !ok || db == "" || coll == "" {
+		return fmt.Errorf("namespace %q must have the form database.collection", ns)
+	}
+	return nil
+}
+
+// toStringMap copies a decoded configuration map into a map[string]interface{}.
+func toStringMap(value interface{}) (map[string]interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			copied[key] = val
+		}
+		return copied, true
+	case map[interface{}]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			name, ok := key.(string)
+			if !ok {
+				return nil, false
+			}
+			copied[name] = val
+		}
+		return copied, true
+	case map[string]string:
+		copied := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			copied[key] = val
+		}
+		return copied, true
+	case nil:
+		return map[string]interface{}{}, true
+	}
+	return nil, false
+}
@@ -0,0 +1,75 @@
+//go:build cse
+
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx/bsoncore"
+)
+
+// Run with: go test -tags cse -run TestClientEncryption_LocalKMS (requires libmongocrypt)
+func TestClientEncryption_LocalKMS(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	ctx := context.Background()
+
+	mt.Run("explicit encrypt and decrypt round trip", func(mt *mtest.T) {
+		masterKey, err := GenerateLocalMasterKey()
+		if !assert.NoError(t, err) {
+			return
+		}
+		manager := createTestManager(mt, Config{
+			URI:      "mongodb://localhost:27017",
+			Database: "testdb",
+			AutoEncryption: AutoEncryptionConfig{
+				KeyVaultNamespace: "encryption.__keyVault",
+				KMSProviders:      map[string]interface{}{LocalKMSProvider: map[string]interface{}{"key": masterKey}},
+			},
+		})
+
+		clientEncryption, err := manager.ClientEncryption()
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		// The data key is inserted into the key vault, then read back by libmongocrypt when encrypting
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}))
+		keyID, err := clientEncryption.CreateDataKey(ctx, LocalKMSProvider, options.DataKey())
+		if !assert.NoError(t, err) {
+			return
+		}
+		insert := mt.GetStartedEvent()
+		if !assert.NotNil(t, insert) {
+			return
+		}
+		assert.Equal(t, "__keyVault", insert.Command.Lookup("insert").StringValue())
+		var key bson.D
+		if !assert.NoError(t, bson.Unmarshal(insert.Command.Lookup("documents", "0").Document(), &key)) {
+			return
+		}
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "encryption.__keyVault", mtest.FirstBatch, key),
+			mtest.CreateCursorResponse(0, "encryption.__keyVault", mtest.FirstBatch, key),
+		)
+
+		plaintext := bson.RawValue{Type: bson.TypeString, Value: bsoncore.AppendString(nil, "4111-1111-1111-1111")}
+		encrypted, err := clientEncryption.Encrypt(ctx, plaintext,
+			options.Encrypt().SetAlgorithm("AEAD_AES_256_CBC_HMAC_SHA_512-Deterministic").SetKeyID(keyID))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, byte(6), encrypted.Subtype)
+		assert.NotContains(t, string(encrypted.Data), "4111-1111-1111-1111")
+
+		decrypted, err := clientEncryption.Decrypt(ctx, encrypted)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "4111-1111-1111-1111", decrypted.StringValue())
+	})
+}
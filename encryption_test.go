@@ -0,0 +1,145 @@
+package mongodb
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestGenerateLocalMasterKey(t *testing.T) {
+	encoded, err := GenerateLocalMasterKey()
+	assert.NoError(t, err)
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	assert.NoError(t, err)
+	assert.Len(t, key, localMasterKeySize)
+
+	other, err := GenerateLocalMasterKey()
+	assert.NoError(t, err)
+	assert.NotEqual(t, encoded, other)
+}
+
+func TestNewAutoEncryptionOptions(t *testing.T) {
+	localKey, err := GenerateLocalMasterKey()
+	assert.NoError(t, err)
+
+	t.Run("disabled", func(t *testing.T) {
+		opts, err := newAutoEncryptionOptions(AutoEncryptionConfig{})
+		assert.NoError(t, err)
+		assert.Nil(t, opts)
+	})
+
+	t.Run("local kms", func(t *testing.T) {
+		cfg := AutoEncryptionConfig{
+			Enabled:           true,
+			KeyVaultNamespace: "encryption.__keyVault",
+			KMSProviders: map[string]interface{}{
+				"local": map[string]interface{}{"key": localKey},
+				"aws":   map[string]interface{}{},
+			},
+			SchemaMap: map[string]interface{}{
+				"app.users": `{"bsonType": "object", "properties": {"ssn": {"encrypt": {"bsonType": "string", "algorithm": "AEAD_AES_256_CBC_HMAC_SHA_512-Deterministic"}}}}`,
+				"app.notes": map[string]interface{}{"bsonType": "object"},
+			},
+			BypassAutoEncryption: true,
+			ExtraOptions:         map[string]interface{}{"mongocryptdBypassSpawn": true},
+		}
+
+		opts, err := newAutoEncryptionOptions(cfg)
+		assert.NoError(t, err)
+		assert.Equal(t, "encryption.__keyVault", opts.KeyVaultNamespace)
+		assert.True(t, *opts.BypassAutoEncryption)
+		assert.Equal(t, true, opts.ExtraOptions["mongocryptdBypassSpawn"])
+
+		providers := opts.KmsProviders
+		assert.Len(t, providers["local"]["key"], localMasterKeySize)
+		assert.Empty(t, providers["aws"])
+
+		assert.IsType(t, bson.D{}, opts.SchemaMap["app.users"])
+		assert.Equal(t, map[string]interface{}{"bsonType": "object"}, opts.SchemaMap["app.notes"])
+
+		// The configuration itself is left untouched
+		assert.Equal(t, localKey, cfg.KMSProviders["local"].(map[string]interface{})["key"])
+	})
+
+	tests := []struct {
+		name    string
+		cfg     AutoEncryptionConfig
+		wantErr string
+	}{
+		{
+			name:    "missing key vault namespace",
+			cfg:     AutoEncryptionConfig{Enabled: true, KMSProviders: map[string]interface{}{"local": map[string]interface{}{"key": localKey}}},
+			wantErr: "key_vault_namespace",
+		},
+		{
+			name:    "missing kms providers",
+			cfg:     AutoEncryptionConfig{Enabled: true, KeyVaultNamespace: "encryption.__keyVault"},
+			wantErr: "at least one KMS provider",
+		},
+		{
+			name:    "local key not base64",
+			cfg:     AutoEncryptionConfig{Enabled: true, KeyVaultNamespace: "encryption.__keyVault", KMSProviders: map[string]interface{}{"local": map[string]interface{}{"key": "not base64!"}}},
+			wantErr: "not valid base64",
+		},
+		{
+			name:    "local key wrong size",
+			cfg:     AutoEncryptionConfig{Enabled: true, KeyVaultNamespace: "encryption.__keyVault", KMSProviders: map[string]interface{}{"local": map[string]interface{}{"key": "c2hvcnQ="}}},
+			wantErr: "must be 96 bytes",
+		},
+		{
+			name:    "provider settings not a map",
+			cfg:     AutoEncryptionConfig{Enabled: true, KeyVaultNamespace: "encryption.__keyVault", KMSProviders: map[string]interface{}{"aws": "secret"}},
+			wantErr: "must be a map",
+		},
+		{
+			name: "invalid schema json",
+			cfg: AutoEncryptionConfig{
+				Enabled:           true,
+				KeyVaultNamespace: "encryption.__keyVault",
+				KMSProviders:      map[string]interface{}{"local": map[string]interface{}{"key": localKey}},
+				SchemaMap:         map[string]interface{}{"app.users": "{"},
+			},
+			wantErr: "not valid extended JSON",
+		},
+		{
+			name: "invalid schema namespace",
+			cfg: AutoEncryptionConfig{
+				Enabled:           true,
+				KeyVaultNamespace: "encryption.__keyVault",
+				KMSProviders:      map[string]interface{}{"local": map[string]interface{}{"key": localKey}},
+				SchemaMap:         map[string]interface{}{"users": map[string]interface{}{}},
+			},
+			wantErr: "database.collection",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newAutoEncryptionOptions(tt.cfg)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestNewClientEncryptionOptions(t *testing.T) {
+	localKey, err := GenerateLocalMasterKey()
+	assert.NoError(t, err)
+
+	t.Run("does not require auto-encryption", func(t *testing.T) {
+		opts, err := newClientEncryptionOptions(AutoEncryptionConfig{
+			KeyVaultNamespace: "encryption.__keyVault",
+			KMSProviders:      map[string]interface{}{"local": map[string]string{"key": localKey}},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "encryption.__keyVault", opts.KeyVaultNamespace)
+		assert.Len(t, opts.KmsProviders["local"]["key"], localMasterKeySize)
+	})
+
+	t.Run("not configured", func(t *testing.T) {
+		_, err := newClientEncryptionOptions(AutoEncryptionConfig{})
+		assert.Error(t, err)
+	})
+}
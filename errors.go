@@ -0,0 +1,29 @@
+package mongodb
+
+import "errors"
+
+var (
+	// ErrInvalidURI is returned when the connection URI is missing or malformed
+	ErrInvalidURI = errors.New("invalid MongoDB URI")
+
+	// ErrInvalidConfig is returned when the configuration is rejected before connecting
+	ErrInvalidConfig = errors.New("invalid MongoDB configuration")
+
+	// ErrConnectFailed is returned when no connection to MongoDB could be established
+	ErrConnectFailed = errors.New("failed to connect to MongoDB")
+
+	// ErrClosed is returned by the manager once Shutdown was called
+	ErrClosed = errors.New("MongoDB manager is shut down")
+
+	// ErrNotFound is returned by Repository when no document matches
+	ErrNotFound = errors.New("MongoDB document not found")
+
+	// ErrInvalidID is returned by Repository when an ID cannot be converted to the type of the _id field
+	ErrInvalidID = errors.New("invalid MongoDB document ID")
+
+	// ErrInvalidPageToken is returned by Paginate when a page token is malformed, altered or belongs to another query
+	ErrInvalidPageToken = errors.New("invalid MongoDB page token")
+
+	// ErrInvalidPageQuery is returned by Paginate when the sort or filter of a page query is invalid
+	ErrInvalidPageQuery = errors.New("invalid MongoDB page query")
+)
@@ -0,0 +1,305 @@
+package mongodb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// HealthStatus is the outcome of a health check or report
+type HealthStatus string
+
+// Health statuses, from best to worst
+const (
+	HealthStatusUp       HealthStatus = "up"
+	HealthStatusDegraded HealthStatus = "degraded" // Usable, but a threshold is exceeded
+	HealthStatusDown     HealthStatus = "down"
+)
+
+// healthSeverity orders the statuses so a report takes the worst one
+var healthSeverity = map[HealthStatus]int{
+	HealthStatusUp:       0,
+	HealthStatusDegraded: 1,
+	HealthStatusDown:     2,
+}
+
+// healthChecks are the known checks by name
+var healthChecks = map[string]func(m *manager, ctx context.Context) HealthCheckResult{
+	HealthCheckPing:           (*manager).checkPing,
+	HealthCheckPrimary:        (*manager).checkPrimary,
+	HealthCheckReplicationLag: (*manager).checkReplicationLag,
+	HealthCheckPool:           (*manager).checkPool,
+	HealthCheckWrite:          (*manager).checkWrite,
+}
+
+// HealthCheckResult is the result of a single check
+type HealthCheckResult struct {
+	Name     string                 `json:"name"`
+	Status   HealthStatus           `json:"status"`
+	Message  string                 `json:"message,omitempty"`
+	Duration time.Duration          `json:"-"`
+	Details  map[string]interface{} `json:"details,omitempty"`
+}
+
+// MarshalJSON adds the duration in milliseconds
+func (r HealthCheckResult) MarshalJSON() ([]byte, error) {
+	type result HealthCheckResult
+	return json.Marshal(struct {
+		result
+		DurationMS float64 `json:"duration_ms"`
+	}{result(r), milliseconds(r.Duration)})
+}
+
+// HealthReport is the result of a set of checks, its status is the worst status of the checks
+type HealthReport struct {
+	Status   HealthStatus        `json:"status"`
+	Checks   []HealthCheckResult `json:"checks"`
+	Time     time.Time           `json:"time"`
+	Duration time.Duration       `json:"-"`
+}
+
+// MarshalJSON adds the duration in milliseconds
+func (r HealthReport) MarshalJSON() ([]byte, error) {
+	type report HealthReport
+	return json.Marshal(struct {
+		report
+		DurationMS float64 `json:"duration_ms"`
+	}{report(r), milliseconds(r.Duration)})
+}
+
+// milliseconds converts a duration to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// validate checks the check names and thresholds
+func (c HealthConfig) validate() error {
+	for _, names := range [][]string{c.Checks, c.LivenessChecks} {
+		for _, name := range names {
+			if _, ok := healthChecks[name]; !ok {
+				return fmt.Errorf("health: unknown check %q", name)
+			}
+		}
+	}
+	if c.MaxPoolUsage < 0 || c.MaxPoolUsage > 1 {
+		return fmt.Errorf("health: max_pool_usage must be between 0 and 1")
+	}
+	return nil
+}
+
+// HealthReport runs the given checks, or the configured health checks when none are given
+// (the ping check when none are configured either)
+//
+// The checks only need the privileges of the application's own operations: ping, the
+// server monitoring state and, for the write check, write access to the write probe
+// collection. Checks run in order and the report takes the worst status.
+func (m *manager) HealthReport(ctx context.Context, checks ...string) HealthReport {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if len(checks) == 0 {
+		checks = m.config.Health.Checks
+	}
+	if len(checks) == 0 {
+		checks = []string{HealthCheckPing}
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		timeout := time.Duration(m.config.Health.Timeout) * time.Millisecond
+		if timeout == 0 {
+			timeout = DefaultHealthCheckTimeout
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	report := HealthReport{
+		Status: HealthStatusUp,
+		Checks: make([]HealthCheckResult, 0, len(checks)),
+		Time:   time.Now(),
+	}
+	for _, name := range checks {
+		start := time.Now()
+		var result HealthCheckResult
+		if check, ok := healthChecks[name]; ok {
+			result = check(m, ctx)
+		} else {
+			result = HealthCheckResult{Status: HealthStatusDown, Message: "unknown check"}
+		}
+		result.Name = name
+		result.Duration = time.Since(start)
+
+		if healthSeverity[result.Status] > healthSeverity[report.Status] {
+			report.Status = result.Status
+		}
+		report.Checks = append(report.Checks, result)
+	}
+	report.Duration = time.Since(report.Time)
+	return report
+}
+
+// checkPing pings the server
+func (m *manager) checkPing(ctx context.Context) HealthCheckResult {
+	start := time.Now()
+	if err := m.Ping(ctx); err != nil {
+		return HealthCheckResult{Status: HealthStatusDown, Message: err.Error()}
+	}
+	return HealthCheckResult{
+		Status:  HealthStatusUp,
+		Details: map[string]interface{}{"latency_ms": milliseconds(time.Since(start))},
+	}
+}
+
+// checkPrimary checks that writes can be routed, which needs a primary in replica sets
+func (m *manager) checkPrimary(ctx context.Context) HealthCheckResult {
+	if _, err := m.ensureClient(ctx); err != nil {
+		return HealthCheckResult{Status: HealthStatusDown, Message: err.Error()}
+	}
+
+	topology := m.Topology()
+	details := map[string]interface{}{"topology": topology.Kind}
+	switch topology.Kind {
+	case TopologyKindReplicaSet:
+		if topology.Primary == "" {
+			return HealthCheckResult{Status: HealthStatusDown, Message: "replica set has no primary", Details: details}
+		}
+		details["primary"] = topology.Primary
+	case TopologyKindUnknown:
+		return HealthCheckResult{Status: HealthStatusDown, Message: "topology is unknown", Details: details}
+	}
+	return HealthCheckResult{Status: HealthStatusUp, Details: details}
+}
+
+// checkReplicationLag checks the lag of the secondaries against the configured maximum
+func (m *manager) checkReplicationLag(ctx context.Context) HealthCheckResult {
+	if _, err := m.ensureClient(ctx); err != nil {
+		return HealthCheckResult{Status: HealthStatusDown, Message: err.Error()}
+	}
+
+	topology := m.Topology()
+	if topology.Kind != TopologyKindReplicaSet {
+		return HealthCheckResult{Status: HealthStatusUp, Message: "not a replica set"}
+	}
+
+	var lagging ServerState
+	for _, server := range topology.Servers {
+		if server.Type == ServerTypeSecondary && server.Lag >= lagging.Lag {
+			lagging = server
+		}
+	}
+	maxLag := time.Duration(m.config.Health.MaxReplicationLag) * time.Millisecond
+	result := HealthCheckResult{
+		Status:  HealthStatusUp,
+		Details: map[string]interface{}{"max_lag_ms": milliseconds(lagging.Lag)},
+	}
+	if lagging.Address != "" {
+		result.Details["server"] = lagging.Address
+	}
+	if maxLag > 0 && lagging.Lag > maxLag {
+		result.Status = HealthStatusDegraded
+		result.Message = fmt.Sprintf("secondary %s lags %s behind the primary", lagging.Address, lagging.Lag)
+	}
+	return result
+}
+
+// checkPool checks the pool usage of every server against the configured maximum
+func (m *manager) checkPool(_ context.Context) HealthCheckResult {
+	maxPoolSize := m.config.MaxPoolSize
+	result := HealthCheckResult{
+		Status:  HealthStatusUp,
+		Details: map[string]interface{}{"max_pool_size": maxPoolSize},
+	}
+	if maxPoolSize == 0 {
+		result.Message = "pool size is unlimited"
+		return result
+	}
+
+	var busiest PoolStats
+	for _, stats := range m.PoolStats() {
+		if stats.InUse+stats.Pending >= busiest.InUse+busiest.Pending {
+			busiest = stats
+		}
+	}
+	usage := float64(busiest.InUse) / float64(maxPoolSize)
+	result.Details["usage"] = usage
+	result.Details["in_use"] = busiest.InUse
+	result.Details["pending"] = busiest.Pending
+	result.Details["wait_queue_timeouts"] = busiest.WaitQueueTimeouts
+	if busiest.Address != "" {
+		result.Details["server"] = busiest.Address
+	}
+	if m.config.Health.MaxPoolUsage > 0 && usage >= m.config.Health.MaxPoolUsage {
+		result.Status = HealthStatusDegraded
+		result.Message = fmt.Sprintf("pool of %s is %.0f%% in use", busiest.Address, usage*100)
+	}
+	return result
+}
+
+// checkWrite upserts a document named after the application into the write probe collection
+func (m *manager) checkWrite(ctx context.Context) HealthCheckResult {
+	database, err := m.ensureDatabase(ctx)
+	if err != nil {
+		return HealthCheckResult{Status: HealthStatusDown, Message: err.Error()}
+	}
+
+	collection := m.config.Health.WriteProbeCollection
+	if collection == "" {
+		collection = DefaultHealthWriteProbeCollection
+	}
+	id := m.config.AppName
+	if id == "" {
+		id = "mongodb"
+	}
+	_, err = database.Collection(collection).UpdateOne(ctx,
+		bson.D{{Key: "_id", Value: id}},
+		bson.D{{Key: "$set", Value: bson.D{{Key: "checked_at", Value: time.Now()}}}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return HealthCheckResult{Status: HealthStatusDown, Message: err.Error()}
+	}
+	return HealthCheckResult{Status: HealthStatusUp, Details: map[string]interface{}{"collection": collection}}
+}
+
+// ReadinessHandler returns an http.Handler serving the health report of the configured
+// checks as JSON, for use as /readyz
+//
+// It responds 503 Service Unavailable when the report is down and 200 OK otherwise.
+func ReadinessHandler(m Manager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeHealthReport(w, m.HealthReport(r.Context()))
+	})
+}
+
+// LivenessHandler returns an http.Handler serving the health report of the configured
+// liveness checks as JSON, for use as /healthz
+//
+// Without liveness checks it reports up without contacting MongoDB, so that an outage of
+// the database does not get the application restarted.
+func LivenessHandler(m Manager) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		checks := m.Config().Health.LivenessChecks
+		if len(checks) == 0 {
+			writeHealthReport(w, HealthReport{Status: HealthStatusUp, Checks: []HealthCheckResult{}, Time: time.Now()})
+			return
+		}
+		writeHealthReport(w, m.HealthReport(r.Context(), checks...))
+	})
+}
+
+// writeHealthReport writes a report as JSON with the status code matching its status
+func writeHealthReport(w http.ResponseWriter, report HealthReport) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status == HealthStatusDown {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	_ = json.NewEncoder(w).Encode(report)
+}
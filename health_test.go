@@ -0,0 +1,165 @@
+package mongodb
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// healthTestManager returns a mock manager with the default health configuration
+func healthTestManager(mt *mtest.T) *manager {
+	cfg := DefaultConfig()
+	cfg.Database = "testdb"
+	return createTestManager(mt, *cfg).(*manager)
+}
+
+func TestManager_HealthReport(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("healthy replica set", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		m := healthTestManager(mt)
+		m.topology.topologyChanged(&event.TopologyDescriptionChangedEvent{NewDescription: replicaSet("db-1:27017", "db-2:27017", time.Now())})
+
+		report := m.HealthReport(context.Background())
+		assert.Equal(t, HealthStatusUp, report.Status)
+		assert.Len(t, report.Checks, 4)
+		for i, name := range []string{HealthCheckPing, HealthCheckPrimary, HealthCheckReplicationLag, HealthCheckPool} {
+			assert.Equal(t, name, report.Checks[i].Name)
+			assert.Equal(t, HealthStatusUp, report.Checks[i].Status, name)
+		}
+		assert.Equal(t, "db-1:27017", report.Checks[1].Details["primary"])
+	})
+
+	mt.Run("ping failure is down", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 13, Message: "not authorized"}))
+		m := healthTestManager(mt)
+
+		report := m.HealthReport(context.Background(), HealthCheckPing)
+		assert.Equal(t, HealthStatusDown, report.Status)
+		assert.Contains(t, report.Checks[0].Message, "not authorized")
+	})
+
+	mt.Run("replica set without primary is down", func(mt *mtest.T) {
+		m := healthTestManager(mt)
+		topology := replicaSet("db-1:27017", "db-2:27017", time.Now())
+		topology.Servers = topology.Servers[:1]
+		m.topology.topologyChanged(&event.TopologyDescriptionChangedEvent{NewDescription: topology})
+
+		report := m.HealthReport(context.Background(), HealthCheckPrimary)
+		assert.Equal(t, HealthStatusDown, report.Status)
+		assert.Equal(t, "replica set has no primary", report.Checks[0].Message)
+	})
+
+	mt.Run("lag and pool usage degrade", func(mt *mtest.T) {
+		m := healthTestManager(mt)
+		m.config.Health.MaxReplicationLag = 1000
+		m.config.MaxPoolSize = 2
+		m.config.Health.MaxPoolUsage = 0.5
+		m.topology.topologyChanged(&event.TopologyDescriptionChangedEvent{NewDescription: replicaSet("db-1:27017", "db-2:27017", time.Now())})
+		for _, evt := range []*event.PoolEvent{
+			{Type: event.ConnectionCreated, Address: "db-1:27017"},
+			{Type: event.GetStarted, Address: "db-1:27017"},
+			{Type: event.GetSucceeded, Address: "db-1:27017"},
+		} {
+			m.pools.event(evt)
+		}
+
+		report := m.HealthReport(context.Background(), HealthCheckReplicationLag, HealthCheckPool)
+		assert.Equal(t, HealthStatusDegraded, report.Status)
+		assert.Equal(t, HealthStatusDegraded, report.Checks[0].Status)
+		assert.Equal(t, "db-2:27017", report.Checks[0].Details["server"])
+		assert.Equal(t, HealthStatusDegraded, report.Checks[1].Status)
+		assert.Equal(t, 0.5, report.Checks[1].Details["usage"])
+	})
+
+	mt.Run("write probe", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}))
+		m := healthTestManager(mt)
+
+		report := m.HealthReport(context.Background(), HealthCheckWrite)
+		assert.Equal(t, HealthStatusUp, report.Status)
+		assert.Equal(t, "_health", report.Checks[0].Details["collection"])
+
+		started := mt.GetStartedEvent()
+		assert.Equal(t, "update", started.CommandName)
+		assert.Equal(t, "_health", started.Command.Lookup("update").StringValue())
+	})
+
+	mt.Run("unknown check is down", func(mt *mtest.T) {
+		m := healthTestManager(mt)
+		report := m.HealthReport(context.Background(), "listDatabases")
+		assert.Equal(t, HealthStatusDown, report.Status)
+		assert.Equal(t, "unknown check", report.Checks[0].Message)
+	})
+}
+
+func TestHealthHandlers(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("readiness", func(mt *mtest.T) {
+		m := healthTestManager(mt)
+		m.config.Health.Checks = []string{HealthCheckPrimary}
+
+		rec := httptest.NewRecorder()
+		ReadinessHandler(m).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+		var body map[string]interface{}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, "down", body["status"])
+		assert.Contains(t, body, "duration_ms")
+		check := body["checks"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "primary", check["name"])
+		assert.Equal(t, "topology is unknown", check["message"])
+		assert.Contains(t, check, "duration_ms")
+	})
+
+	mt.Run("readiness without checks pings", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 13, Message: "not authorized"}))
+		m := healthTestManager(mt)
+		m.config.Health.Checks = nil
+
+		rec := httptest.NewRecorder()
+		ReadinessHandler(m).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"name":"ping"`)
+	})
+
+	mt.Run("liveness", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		m := healthTestManager(mt)
+		m.config.Health.LivenessChecks = []string{HealthCheckPing}
+
+		rec := httptest.NewRecorder()
+		LivenessHandler(m).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"name":"ping"`)
+	})
+
+	mt.Run("liveness without checks does not contact MongoDB", func(mt *mtest.T) {
+		m := healthTestManager(mt)
+		assert.Empty(t, m.config.Health.LivenessChecks, "liveness checks are empty by default")
+
+		rec := httptest.NewRecorder()
+		LivenessHandler(m).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"checks":[]`)
+		assert.Nil(t, mt.GetStartedEvent())
+	})
+}
+
+func TestHealthConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultConfig().Health.validate())
+	assert.ErrorContains(t, HealthConfig{LivenessChecks: []string{"dbStats"}}.validate(), `unknown check "dbStats"`)
+	assert.Error(t, HealthConfig{MaxPoolUsage: 1.5}.validate())
+}
@@ -0,0 +1,687 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// IndexChangeType is the kind of difference between declared and existing indexes
+type IndexChangeType string
+
+// Index change types reported in IndexPlan
+const (
+	// IndexMissing is a declared index that does not exist
+	IndexMissing IndexChangeType = "missing"
+
+	// IndexChanged is an existing index whose keys, name or options differ from its declaration
+	IndexChanged IndexChangeType = "changed"
+
+	// IndexExtra is an existing index that is not declared
+	IndexExtra IndexChangeType = "extra"
+)
+
+// IndexChange is a difference between the declared and existing indexes of a collection
+type IndexChange struct {
+	Type        IndexChangeType
+	Collection  string
+	Name        string           // Name of the declared index, or of the existing one for IndexExtra
+	Existing    string           // Name of the existing index for IndexChanged, which differs from Name when the index is renamed
+	Differences []string         // Changed properties for IndexChanged, e.g. "expireAfterSeconds: 3600 -> 7200"
+	Model       mongo.IndexModel // Declared index, zero for IndexExtra
+
+	// rebuild is set when the index must be replaced rather than modified
+	rebuild bool
+	// modification holds the collMod index options applying the change in place
+	modification bson.D
+}
+
+// Rebuild reports whether applying the change replaces the index with a new one.
+//
+// TTL and hidden changes are applied in place with collMod; key, name, unique, sparse,
+// partial filter and text weight changes need a rebuild.
+func (c IndexChange) Rebuild() bool {
+	return c.rebuild
+}
+
+// String describes the change, e.g. "users.email_1: changed (unique: false -> true)"
+func (c IndexChange) String() string {
+	s := fmt.Sprintf("%s.%s: %s", c.Collection, c.Name, c.Type)
+	if len(c.Differences) > 0 {
+		s += " (" + strings.Join(c.Differences, ", ") + ")"
+	}
+	return s
+}
+
+// IndexPlan lists the differences between the declared and existing indexes
+type IndexPlan struct {
+	Changes []IndexChange
+}
+
+// Empty reports whether the existing indexes match the declarations
+func (p *IndexPlan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// Of returns the changes of the given type
+func (p *IndexPlan) Of(changeType IndexChangeType) []IndexChange {
+	var changes []IndexChange
+	for _, change := range p.Changes {
+		if change.Type == changeType {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// IndexSyncOption configures an IndexSync
+type IndexSyncOption func(*IndexSync)
+
+// WithDropExtraIndexes lets Apply drop existing indexes that are not declared
+func WithDropExtraIndexes() IndexSyncOption {
+	return func(s *IndexSync) {
+		s.dropExtra = true
+	}
+}
+
+// IndexSync compares declared indexes with the indexes of the manager's default database
+// and applies the differences.
+//
+// Indexes are declared in the indexes configuration and with Declare. Only declared
+// collections are compared, and the _id index is never reported.
+type IndexSync struct {
+	manager     Manager
+	collections []string
+	declared    map[string][]mongo.IndexModel
+	dropExtra   bool
+}
+
+// NewIndexSync creates an IndexSync with the indexes of the manager's configuration
+func NewIndexSync(manager Manager, opts ...IndexSyncOption) (*IndexSync, error) {
+	s := &IndexSync{manager: manager, declared: make(map[string][]mongo.IndexModel)}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	collections := make([]string, 0, len(manager.Config().Indexes))
+	for collection := range manager.Config().Indexes {
+		collections = append(collections, collection)
+	}
+	sort.Strings(collections)
+	for _, collection := range collections {
+		for _, index := range manager.Config().Indexes[collection] {
+			model, err := index.Model()
+			if err != nil {
+				return nil, fmt.Errorf("%w: indexes.%s: %w", ErrInvalidConfig, collection, err)
+			}
+			s.Declare(collection, model)
+		}
+	}
+	return s, nil
+}
+
+// Declare adds indexes of a collection of the default database
+func (s *IndexSync) Declare(collection string, models ...mongo.IndexModel) *IndexSync {
+	if _, ok := s.declared[collection]; !ok {
+		s.collections = append(s.collections, collection)
+	}
+	s.declared[collection] = append(s.declared[collection], models...)
+	return s
+}
+
+// Plan compares the declared indexes with ListIndexes
+func (s *IndexSync) Plan(ctx context.Context) (*IndexPlan, error) {
+	plan := &IndexPlan{Changes: []IndexChange{}}
+	for _, collection := range s.collections {
+		declared := make([]indexSpec, 0, len(s.declared[collection]))
+		names := make(map[string]bool)
+		for _, model := range s.declared[collection] {
+			spec, err := newIndexSpec(model)
+			if err != nil {
+				return nil, fmt.Errorf("indexes of %s: %w", collection, err)
+			}
+			if names[spec.name] {
+				return nil, fmt.Errorf("indexes of %s: index %s is declared twice", collection, spec.name)
+			}
+			names[spec.name] = true
+			declared = append(declared, spec)
+		}
+
+		existing, err := s.existing(ctx, collection)
+		if err != nil {
+			return nil, fmt.Errorf("indexes of %s: %w", collection, err)
+		}
+		plan.Changes = append(plan.Changes, diffIndexes(collection, declared, existing)...)
+	}
+	return plan, nil
+}
+
+// Apply applies a plan in build-safe order: missing indexes are created first, then TTL and
+// hidden changes are applied in place, then changed indexes are rebuilt and finally extra
+// indexes are dropped when WithDropExtraIndexes is set.
+//
+// A rebuilt index that gets a new name is built before the old one is dropped, so queries
+// and unique constraints keep an index meanwhile. The old index is dropped first only when
+// the server rejects both side by side: when the name is kept, or when the keys are the same.
+//
+// Each index is built by its own createIndexes command, so at most one build runs at a time
+// and a failure leaves the indexes applied so far in place.
+func (s *IndexSync) Apply(ctx context.Context, plan *IndexPlan) error {
+	for _, change := range orderIndexChanges(plan.Changes) {
+		var err error
+		switch {
+		case change.Type == IndexMissing:
+			_, err = s.manager.CreateIndex(ctx, change.Collection, change.Model)
+		case change.Type == IndexChanged && change.rebuild:
+			err = s.rebuild(ctx, change)
+		case change.Type == IndexChanged:
+			err = s.modify(ctx, change)
+		case change.Type == IndexExtra && s.dropExtra:
+			_, err = s.manager.DropIndex(ctx, change.Collection, change.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("apply %s: %w", change, err)
+		}
+	}
+	return nil
+}
+
+// Sync plans the differences and applies them, returning the applied plan
+func (s *IndexSync) Sync(ctx context.Context) (*IndexPlan, error) {
+	plan, err := s.Plan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return plan, s.Apply(ctx, plan)
+}
+
+// existing returns the indexes of a collection, none when the collection does not exist
+func (s *IndexSync) existing(ctx context.Context, collection string) ([]indexSpec, error) {
+	cursor, err := s.manager.ListIndexes(ctx, collection)
+	var commandErr mongo.CommandError
+	if errors.As(err, &commandErr) && commandErr.Code == errCodeNamespaceNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var listed []bson.Raw
+	if err := cursor.All(ctx, &listed); err != nil {
+		return nil, err
+	}
+
+	specs := make([]indexSpec, 0, len(listed))
+	for _, raw := range listed {
+		spec := listedIndexSpec(raw)
+		if spec.name != "_id_" {
+			specs = append(specs, spec)
+		}
+	}
+	return specs, nil
+}
+
+// rebuild replaces an index, building the new one first when it is renamed
+func (s *IndexSync) rebuild(ctx context.Context, change IndexChange) error {
+	if change.Name != change.Existing {
+		_, err := s.manager.CreateIndex(ctx, change.Collection, change.Model)
+		if err == nil {
+			_, err = s.manager.DropIndex(ctx, change.Collection, change.Existing)
+			return err
+		}
+		if !isIndexConflict(err) {
+			return err
+		}
+	}
+	if _, err := s.manager.DropIndex(ctx, change.Collection, change.Existing); err != nil {
+		return err
+	}
+	_, err := s.manager.CreateIndex(ctx, change.Collection, change.Model)
+	return err
+}
+
+// isIndexConflict reports whether createIndexes failed because an existing index has the
+// same keys or name
+func isIndexConflict(err error) bool {
+	var commandErr mongo.CommandError
+	return errors.As(err, &commandErr) &&
+		(commandErr.Code == errCodeIndexOptionsConflict || commandErr.Code == errCodeIndexKeySpecsConflict)
+}
+
+// modify applies TTL and hidden changes with collMod
+func (s *IndexSync) modify(ctx context.Context, change IndexChange) error {
+	index := append(bson.D{{Key: "name", Value: change.Existing}}, change.modification...)
+	if err := s.manager.Connect(ctx); err != nil {
+		return err
+	}
+	return s.manager.Database().RunCommand(ctx, bson.D{
+		{Key: "collMod", Value: change.Collection},
+		{Key: "index", Value: index},
+	}).Err()
+}
+
+// Server error codes handled by IndexSync
+const (
+	// errCodeNamespaceNotFound is returned by listIndexes for a collection that does not exist
+	errCodeNamespaceNotFound = 26
+
+	// errCodeIndexOptionsConflict is returned by createIndexes when an index with the same keys exists
+	errCodeIndexOptionsConflict = 85
+
+	// errCodeIndexKeySpecsConflict is returned by createIndexes when an index with the same name exists
+	errCodeIndexKeySpecsConflict = 86
+)
+
+// orderIndexChanges sorts changes in the order Apply runs them, keeping the plan order otherwise
+func orderIndexChanges(changes []IndexChange) []IndexChange {
+	rank := func(c IndexChange) int {
+		switch {
+		case c.Type == IndexMissing:
+			return 0
+		case c.Type == IndexChanged && !c.rebuild:
+			return 1
+		case c.Type == IndexChanged:
+			return 2
+		}
+		return 3
+	}
+	ordered := append([]IndexChange(nil), changes...)
+	sort.SliceStable(ordered, func(i, j int) bool { return rank(ordered[i]) < rank(ordered[j]) })
+	return ordered
+}
+
+// indexSpec holds the properties of an index that are compared
+type indexSpec struct {
+	name    string
+	keys    bson.D
+	unique  bool
+	sparse  bool
+	hidden  bool
+	ttl     *int64
+	partial bson.D
+	weights bson.D // Text field weights, nil for other indexes
+	model   mongo.IndexModel
+}
+
+// newIndexSpec reads a declared index model, naming it after its keys when it has no name
+func newIndexSpec(model mongo.IndexModel) (indexSpec, error) {
+	spec := indexSpec{model: model}
+	data, err := bson.Marshal(model.Keys)
+	if err != nil {
+		return spec, fmt.Errorf("index keys: %w", err)
+	}
+	if err := bson.Unmarshal(data, &spec.keys); err != nil || len(spec.keys) == 0 {
+		return spec, fmt.Errorf("index keys must be a non-empty document")
+	}
+	var weights bson.D
+
+	if opts := model.Options; opts != nil {
+		if opts.Name != nil {
+			spec.name = *opts.Name
+		}
+		spec.unique = opts.Unique != nil && *opts.Unique
+		spec.sparse = opts.Sparse != nil && *opts.Sparse
+		spec.hidden = opts.Hidden != nil && *opts.Hidden
+		if opts.ExpireAfterSeconds != nil {
+			ttl := int64(*opts.ExpireAfterSeconds)
+			spec.ttl = &ttl
+		}
+		if opts.PartialFilterExpression != nil {
+			data, err := bson.Marshal(opts.PartialFilterExpression)
+			if err != nil {
+				return spec, fmt.Errorf("partial filter expression: %w", err)
+			}
+			if err := bson.Unmarshal(data, &spec.partial); err != nil {
+				return spec, fmt.Errorf("partial filter expression: %w", err)
+			}
+		}
+		if opts.Weights != nil {
+			data, err := bson.Marshal(opts.Weights)
+			if err != nil {
+				return spec, fmt.Errorf("weights: %w", err)
+			}
+			if err := bson.Unmarshal(data, &weights); err != nil {
+				return spec, fmt.Errorf("weights: %w", err)
+			}
+		}
+	}
+	if spec.name == "" {
+		spec.name = indexName(spec.keys)
+	}
+	spec.keys, spec.weights = normalizeTextIndex(spec.keys, weights)
+	return spec, nil
+}
+
+// listedIndexSpec reads an index returned by listIndexes
+func listedIndexSpec(raw bson.Raw) indexSpec {
+	var listed struct {
+		Name    string `bson:"name"`
+		Key     bson.D `bson:"key"`
+		Unique  bool   `bson:"unique"`
+		Sparse  bool   `bson:"sparse"`
+		Hidden  bool   `bson:"hidden"`
+		Partial bson.D `bson:"partialFilterExpression"`
+		Weights bson.D `bson:"weights"`
+	}
+	_ = bson.Unmarshal(raw, &listed)
+	spec := indexSpec{
+		name:    listed.Name,
+		unique:  listed.Unique,
+		sparse:  listed.Sparse,
+		hidden:  listed.Hidden,
+		partial: listed.Partial,
+	}
+	spec.keys, spec.weights = normalizeTextIndex(listed.Key, listed.Weights)
+	if ttl, ok := raw.Lookup("expireAfterSeconds").AsInt64OK(); ok {
+		spec.ttl = &ttl
+	}
+	return spec
+}
+
+// normalizeTextIndex gives the keys and weights of a text index one form, whether declared
+// or listed.
+//
+// listIndexes reports the text fields of {body: "text"} as {_fts: "text", _ftsx: 1} and
+// lists them in weights instead. Both forms become the text fields sorted by name, in
+// place of the first text key, with weights defaulting to 1. Other indexes are unchanged.
+func normalizeTextIndex(keys, weights bson.D) (bson.D, bson.D) {
+	normalized := make(bson.D, 0, len(keys))
+	var fields []string
+	at := -1
+	for _, key := range keys {
+		switch {
+		case key.Key == "_fts" || key.Value == "text":
+			if at < 0 {
+				at = len(normalized)
+			}
+			if key.Key != "_fts" {
+				fields = append(fields, key.Key)
+			}
+		case key.Key == "_ftsx":
+		default:
+			normalized = append(normalized, key)
+		}
+	}
+	if at < 0 {
+		return keys, nil
+	}
+
+	// Weighted fields are indexed even when they are not keys
+	for _, weight := range weights {
+		fields = append(fields, weight.Key)
+	}
+	sort.Strings(fields)
+	text := make(bson.D, 0, len(fields))
+	textWeights := make(bson.D, 0, len(fields))
+	for i, field := range fields {
+		if i > 0 && fields[i-1] == field {
+			continue
+		}
+		var weight interface{} = 1
+		for _, elem := range weights {
+			if elem.Key == field {
+				weight = elem.Value
+			}
+		}
+		text = append(text, bson.E{Key: field, Value: "text"})
+		textWeights = append(textWeights, bson.E{Key: field, Value: weight})
+	}
+
+	result := append(bson.D{}, normalized[:at]...)
+	result = append(result, text...)
+	return append(result, normalized[at:]...), textWeights
+}
+
+// indexName generates the name the server gives to an index, e.g. "status_1_created_at_-1"
+func indexName(keys bson.D) string {
+	parts := make([]string, 0, 2*len(keys))
+	for _, key := range keys {
+		value := fmt.Sprint(key.Value)
+		if number, ok := indexKeyNumber(key.Value); ok {
+			value = strconv.FormatFloat(number, 'f', -1, 64)
+		}
+		parts = append(parts, key.Key, value)
+	}
+	return strings.Join(parts, "_")
+}
+
+// indexKeyNumber converts a numeric key direction
+func indexKeyNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// diffIndexes compares the declared indexes of a collection with the existing ones
+func diffIndexes(collection string, declared, existing []indexSpec) []IndexChange {
+	byName := make(map[string]indexSpec, len(existing))
+	for _, spec := range existing {
+		byName[spec.name] = spec
+	}
+	matched := make(map[string]bool, len(existing))
+
+	var changes []IndexChange
+	for _, want := range declared {
+		have, ok := byName[want.name]
+		if !ok {
+			// An index with the same keys under another name is renamed rather than duplicated
+			for _, spec := range existing {
+				if !matched[spec.name] && !isDeclared(declared, spec.name) && sameIndexKeys(spec.keys, want.keys) {
+					have, ok = spec, true
+					break
+				}
+			}
+		}
+		if !ok {
+			changes = append(changes, IndexChange{Type: IndexMissing, Collection: collection, Name: want.name, Model: want.model})
+			continue
+		}
+		matched[have.name] = true
+
+		differences, rebuild, modification := compareIndexes(want, have)
+		if len(differences) > 0 {
+			changes = append(changes, IndexChange{
+				Type:         IndexChanged,
+				Collection:   collection,
+				Name:         want.name,
+				Existing:     have.name,
+				Differences:  differences,
+				Model:        want.model,
+				rebuild:      rebuild,
+				modification: modification,
+			})
+		}
+	}
+	for _, spec := range existing {
+		if !matched[spec.name] {
+			changes = append(changes, IndexChange{Type: IndexExtra, Collection: collection, Name: spec.name})
+		}
+	}
+	return changes
+}
+
+// isDeclared reports whether an index name is declared
+func isDeclared(declared []indexSpec, name string) bool {
+	for _, spec := range declared {
+		if spec.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// compareIndexes describes the differences of an existing index from its declaration,
+// whether they need a rebuild and otherwise the collMod options applying them
+func compareIndexes(want, have indexSpec) (differences []string, rebuild bool, modification bson.D) {
+	changed := func(property string, from, to interface{}, needsRebuild bool) {
+		differences = append(differences, fmt.Sprintf("%s: %v -> %v", property, from, to))
+		rebuild = rebuild || needsRebuild
+	}
+	if want.name != have.name {
+		changed("name", have.name, want.name, true)
+	}
+	if !sameIndexKeys(want.keys, have.keys) {
+		changed("keys", extJSON(have.keys), extJSON(want.keys), true)
+	}
+	if want.unique != have.unique {
+		changed("unique", have.unique, want.unique, true)
+	}
+	if want.sparse != have.sparse {
+		changed("sparse", have.sparse, want.sparse, true)
+	}
+	if !reflect.DeepEqual(canonicalBSON(want.partial), canonicalBSON(have.partial)) {
+		changed("partialFilterExpression", extJSON(have.partial), extJSON(want.partial), true)
+	}
+	if !reflect.DeepEqual(canonicalBSON(want.weights), canonicalBSON(have.weights)) {
+		changed("weights", extJSON(have.weights), extJSON(want.weights), true)
+	}
+	if ttlOf(want.ttl) != ttlOf(have.ttl) {
+		// Adding or removing the TTL needs a rebuild, changing its value does not
+		changed("expireAfterSeconds", ttlOf(have.ttl), ttlOf(want.ttl), want.ttl == nil || have.ttl == nil)
+		if want.ttl != nil {
+			modification = append(modification, bson.E{Key: "expireAfterSeconds", Value: *want.ttl})
+		}
+	}
+	if want.hidden != have.hidden {
+		changed("hidden", have.hidden, want.hidden, false)
+		modification = append(modification, bson.E{Key: "hidden", Value: want.hidden})
+	}
+	return differences, rebuild, modification
+}
+
+// ttlOf formats an optional TTL
+func ttlOf(ttl *int64) string {
+	if ttl == nil {
+		return "none"
+	}
+	return strconv.FormatInt(*ttl, 10)
+}
+
+// sameIndexKeys compares index keys in order, ignoring the numeric type of directions
+func sameIndexKeys(a, b bson.D) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Key != b[i].Key || !reflect.DeepEqual(canonicalBSON(a[i].Value), canonicalBSON(b[i].Value)) {
+			return false
+		}
+	}
+	return true
+}
+
+// canonicalBSON converts a value for comparison: documents become key sorted slices and
+// numbers become float64
+func canonicalBSON(value interface{}) interface{} {
+	switch v := value.(type) {
+	case bson.D:
+		if len(v) == 0 {
+			return nil
+		}
+		doc := make([][2]interface{}, len(v))
+		for i, elem := range v {
+			doc[i] = [2]interface{}{elem.Key, canonicalBSON(elem.Value)}
+		}
+		sort.Slice(doc, func(i, j int) bool { return doc[i][0].(string) < doc[j][0].(string) })
+		return doc
+	case bson.A:
+		values := make([]interface{}, len(v))
+		for i, elem := range v {
+			values[i] = canonicalBSON(elem)
+		}
+		return values
+	}
+	if number, ok := indexKeyNumber(value); ok {
+		return number
+	}
+	return value
+}
+
+// extJSON formats a document as relaxed extended JSON
+func extJSON(doc bson.D) string {
+	if len(doc) == 0 {
+		return "none"
+	}
+	data, err := bson.MarshalExtJSON(doc, false, false)
+	if err != nil {
+		return fmt.Sprint(doc)
+	}
+	return string(data)
+}
+
+// validateIndexes checks every declared index
+func validateIndexes(indexes map[string][]IndexConfig) error {
+	for collection, configs := range indexes {
+		for _, index := range configs {
+			if _, err := index.Model(); err != nil {
+				return fmt.Errorf("indexes.%s: %w", collection, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Model converts the declaration to an index model
+func (c IndexConfig) Model() (mongo.IndexModel, error) {
+	if len(c.Keys) == 0 {
+		return mongo.IndexModel{}, fmt.Errorf("index %q has no keys", c.Name)
+	}
+	keys := make(bson.D, 0, len(c.Keys))
+	for _, key := range c.Keys {
+		field, direction, hasDirection := strings.Cut(key, ":")
+		if field == "" {
+			return mongo.IndexModel{}, fmt.Errorf("index %q has an empty key", c.Name)
+		}
+		var value interface{}
+		switch {
+		case !hasDirection || direction == "1":
+			value = int32(1)
+		case direction == "-1":
+			value = int32(-1)
+		case direction == "text", direction == "hashed", direction == "2d", direction == "2dsphere":
+			value = direction
+		default:
+			return mongo.IndexModel{}, fmt.Errorf("index key %q must have direction 1, -1, text, hashed, 2d or 2dsphere", key)
+		}
+		keys = append(keys, bson.E{Key: field, Value: value})
+	}
+
+	opts := options.Index()
+	if c.Name != "" {
+		opts.SetName(c.Name)
+	}
+	if c.Unique {
+		opts.SetUnique(true)
+	}
+	if c.Sparse {
+		opts.SetSparse(true)
+	}
+	if c.Hidden {
+		opts.SetHidden(true)
+	}
+	if c.ExpireAfterSeconds != nil {
+		if *c.ExpireAfterSeconds < 0 {
+			return mongo.IndexModel{}, fmt.Errorf("index %q expire_after_seconds must not be negative", c.Name)
+		}
+		opts.SetExpireAfterSeconds(*c.ExpireAfterSeconds)
+	}
+	if len(c.PartialFilter) > 0 {
+		opts.SetPartialFilterExpression(bson.M(c.PartialFilter))
+	}
+	return mongo.IndexModel{Keys: keys, Options: opts}, nil
+}
@@ -0,0 +1,236 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// listedIndex returns an index document as returned by listIndexes
+func listedIndex(name string, keys bson.D, opts ...bson.E) bson.D {
+	return append(bson.D{{Key: "v", Value: 2}, {Key: "key", Value: keys}, {Key: "name", Value: name}}, opts...)
+}
+
+func TestIndexConfig_Model(t *testing.T) {
+	ttl := int32(3600)
+	model, err := IndexConfig{
+		Name:               "recent",
+		Keys:               []string{"status", "created_at:-1", "body:text"},
+		Unique:             true,
+		ExpireAfterSeconds: &ttl,
+		PartialFilter:      map[string]interface{}{"status": "active"},
+	}.Model()
+	assert.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "status", Value: int32(1)}, {Key: "created_at", Value: int32(-1)}, {Key: "body", Value: "text"}}, model.Keys)
+	assert.Equal(t, "recent", *model.Options.Name)
+	assert.True(t, *model.Options.Unique)
+	assert.Equal(t, ttl, *model.Options.ExpireAfterSeconds)
+	assert.Equal(t, bson.M{"status": "active"}, model.Options.PartialFilterExpression)
+	assert.Nil(t, model.Options.Sparse)
+
+	for _, keys := range [][]string{nil, {""}, {"email:"}, {"email:up"}} {
+		_, err := IndexConfig{Keys: keys}.Model()
+		assert.Error(t, err, keys)
+	}
+}
+
+func TestIndexName(t *testing.T) {
+	assert.Equal(t, "email_1", indexName(bson.D{{Key: "email", Value: 1}}))
+	assert.Equal(t, "status_1_created_at_-1", indexName(bson.D{{Key: "status", Value: int32(1)}, {Key: "created_at", Value: -1.0}}))
+	assert.Equal(t, "body_text", indexName(bson.D{{Key: "body", Value: "text"}}))
+}
+
+func TestDiffIndexes(t *testing.T) {
+	spec := func(model mongo.IndexModel) indexSpec {
+		s, err := newIndexSpec(model)
+		assert.NoError(t, err)
+		return s
+	}
+	raw := func(doc bson.D) indexSpec {
+		data, err := bson.Marshal(doc)
+		assert.NoError(t, err)
+		return listedIndexSpec(data)
+	}
+
+	declared := []indexSpec{
+		spec(mongo.IndexModel{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetUnique(true)}),
+		spec(mongo.IndexModel{Keys: bson.D{{Key: "seen_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(7200)}),
+		spec(mongo.IndexModel{Keys: bson.D{{Key: "status", Value: 1}}, Options: options.Index().SetPartialFilterExpression(bson.M{"a": 1, "b": bson.M{"$gt": 2}})}),
+		spec(mongo.IndexModel{Keys: bson.D{{Key: "org", Value: 1}}, Options: options.Index().SetName("by_org")}),
+		spec(mongo.IndexModel{Keys: bson.D{{Key: "missing", Value: -1}}}),
+	}
+	existing := []indexSpec{
+		raw(listedIndex("email_1", bson.D{{Key: "email", Value: int32(1)}})),
+		raw(listedIndex("seen_at_1", bson.D{{Key: "seen_at", Value: 1.0}}, bson.E{Key: "expireAfterSeconds", Value: int32(3600)})),
+		raw(listedIndex("status_1", bson.D{{Key: "status", Value: int32(1)}}, bson.E{Key: "partialFilterExpression", Value: bson.D{{Key: "b", Value: bson.D{{Key: "$gt", Value: int64(2)}}}, {Key: "a", Value: 1.0}}})),
+		raw(listedIndex("org_1", bson.D{{Key: "org", Value: int32(1)}})),
+		raw(listedIndex("legacy_1", bson.D{{Key: "legacy", Value: int32(1)}})),
+	}
+
+	changes := diffIndexes("users", declared, existing)
+	assert.Len(t, changes, 5)
+
+	assert.Equal(t, IndexChanged, changes[0].Type)
+	assert.Equal(t, []string{"unique: false -> true"}, changes[0].Differences)
+	assert.True(t, changes[0].Rebuild())
+
+	assert.Equal(t, IndexChanged, changes[1].Type)
+	assert.Equal(t, []string{"expireAfterSeconds: 3600 -> 7200"}, changes[1].Differences)
+	assert.False(t, changes[1].Rebuild())
+	assert.Equal(t, bson.D{{Key: "expireAfterSeconds", Value: int64(7200)}}, changes[1].modification)
+
+	// status_1 has the same partial filter in another order and numeric type
+	assert.Equal(t, IndexChanged, changes[2].Type)
+	assert.Equal(t, "by_org", changes[2].Name)
+	assert.Equal(t, "org_1", changes[2].Existing)
+	assert.True(t, changes[2].Rebuild())
+
+	assert.Equal(t, IndexChange{Type: IndexMissing, Collection: "users", Name: "missing_-1", Model: declared[4].model}, changes[3])
+	assert.Equal(t, IndexChange{Type: IndexExtra, Collection: "users", Name: "legacy_1"}, changes[4])
+	assert.Equal(t, "users.email_1: changed (unique: false -> true)", changes[0].String())
+}
+
+func TestDiffIndexes_Text(t *testing.T) {
+	spec := func(model mongo.IndexModel) indexSpec {
+		s, err := newIndexSpec(model)
+		assert.NoError(t, err)
+		return s
+	}
+	// As listed by MongoDB for createIndexes {key: {category: 1, title: "text", body: "text"}, weights: {title: 10}}
+	data, err := bson.Marshal(listedIndex("category_1_title_text_body_text",
+		bson.D{{Key: "category", Value: int32(1)}, {Key: "_fts", Value: "text"}, {Key: "_ftsx", Value: int32(1)}},
+		bson.E{Key: "weights", Value: bson.D{{Key: "body", Value: int32(1)}, {Key: "title", Value: int32(10)}}},
+		bson.E{Key: "default_language", Value: "english"},
+		bson.E{Key: "language_override", Value: "language"},
+		bson.E{Key: "textIndexVersion", Value: int32(3)},
+	))
+	assert.NoError(t, err)
+	existing := []indexSpec{listedIndexSpec(data)}
+	assert.Equal(t, bson.D{{Key: "category", Value: int32(1)}, {Key: "body", Value: "text"}, {Key: "title", Value: "text"}}, existing[0].keys)
+
+	keys := bson.D{{Key: "category", Value: 1}, {Key: "title", Value: "text"}, {Key: "body", Value: "text"}}
+	declared := spec(mongo.IndexModel{Keys: keys, Options: options.Index().SetWeights(bson.D{{Key: "title", Value: 10}})})
+	assert.Equal(t, "category_1_title_text_body_text", declared.name)
+	assert.Empty(t, diffIndexes("posts", []indexSpec{declared}, existing))
+
+	reweighted := spec(mongo.IndexModel{Keys: keys, Options: options.Index().SetWeights(bson.D{{Key: "title", Value: 5}})})
+	changes := diffIndexes("posts", []indexSpec{reweighted}, existing)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, []string{`weights: {"body":1,"title":10} -> {"body":1,"title":5}`}, changes[0].Differences)
+	assert.True(t, changes[0].Rebuild())
+}
+
+func TestIndexSync(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	ctx := context.Background()
+	cfg := Config{
+		URI:      "mongodb://localhost:27017",
+		Database: "testdb",
+		Indexes: map[string][]IndexConfig{
+			"users": {{Keys: []string{"email"}, Unique: true}, {Keys: []string{"name"}, Hidden: true}},
+		},
+	}
+
+	mt.Run("invalid configuration", func(mt *mtest.T) {
+		invalid := cfg
+		invalid.Indexes = map[string][]IndexConfig{"users": {{}}}
+		_, err := NewIndexSync(createTestManager(mt, invalid))
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+	})
+
+	mt.Run("plan and apply", func(mt *mtest.T) {
+		sync, err := NewIndexSync(createTestManager(mt, cfg), WithDropExtraIndexes())
+		assert.NoError(t, err)
+		sync.Declare("sessions", mongo.IndexModel{Keys: bson.D{{Key: "seen_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(60)})
+
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "testdb.users", mtest.FirstBatch,
+				listedIndex("_id_", bson.D{{Key: "_id", Value: int32(1)}}),
+				listedIndex("email_1", bson.D{{Key: "email", Value: int32(1)}}),
+				listedIndex("name_1", bson.D{{Key: "name", Value: int32(1)}}),
+				listedIndex("old_1", bson.D{{Key: "old", Value: int32(1)}}),
+			),
+			mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 26, Name: "NamespaceNotFound", Message: "ns does not exist"}),
+		)
+		plan, err := sync.Plan(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, plan.Of(IndexChanged), 2)
+		assert.Len(t, plan.Of(IndexExtra), 1)
+		assert.Equal(t, "sessions", plan.Of(IndexMissing)[0].Collection)
+
+		mt.ClearEvents()
+		for i := 0; i < 5; i++ {
+			mt.AddMockResponses(mtest.CreateSuccessResponse())
+		}
+		assert.NoError(t, sync.Apply(ctx, plan))
+
+		var commands []string
+		for _, evt := range mt.GetAllStartedEvents() {
+			commands = append(commands, evt.CommandName)
+		}
+		assert.Equal(t, []string{"createIndexes", "collMod", "dropIndexes", "createIndexes", "dropIndexes"}, commands)
+		collMod := mt.GetAllStartedEvents()[1].Command
+		assert.Equal(t, "name_1", collMod.Lookup("index", "name").StringValue())
+		assert.True(t, collMod.Lookup("index", "hidden").Boolean())
+	})
+
+	mt.Run("renamed index is built before the old one is dropped", func(mt *mtest.T) {
+		sync, err := NewIndexSync(createTestManager(mt, Config{URI: cfg.URI, Database: "testdb"}))
+		assert.NoError(t, err)
+		plan := &IndexPlan{Changes: []IndexChange{{
+			Type:       IndexChanged,
+			Collection: "users",
+			Name:       "email_1_tenant_1",
+			Existing:   "email_1",
+			Model:      mongo.IndexModel{Keys: bson.D{{Key: "email", Value: 1}, {Key: "tenant", Value: 1}}, Options: options.Index().SetUnique(true)},
+			rebuild:    true,
+		}}}
+
+		mt.AddMockResponses(mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse())
+		assert.NoError(t, sync.Apply(ctx, plan))
+		events := mt.GetAllStartedEvents()
+		assert.Len(t, events, 2)
+		assert.Equal(t, "createIndexes", events[0].CommandName)
+		assert.Equal(t, "dropIndexes", events[1].CommandName)
+		assert.Equal(t, "email_1", events[1].Command.Lookup("index").StringValue())
+	})
+
+	mt.Run("renamed index with the same keys falls back to drop first", func(mt *mtest.T) {
+		sync, err := NewIndexSync(createTestManager(mt, Config{URI: cfg.URI, Database: "testdb"}))
+		assert.NoError(t, err)
+		plan := &IndexPlan{Changes: []IndexChange{{
+			Type:       IndexChanged,
+			Collection: "users",
+			Name:       "by_email",
+			Existing:   "email_1",
+			Model:      mongo.IndexModel{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetName("by_email")},
+			rebuild:    true,
+		}}}
+
+		mt.AddMockResponses(
+			mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 85, Name: "IndexOptionsConflict", Message: "Index already exists with a different name: email_1"}),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(),
+		)
+		assert.NoError(t, sync.Apply(ctx, plan))
+		var commands []string
+		for _, evt := range mt.GetAllStartedEvents() {
+			commands = append(commands, evt.CommandName)
+		}
+		assert.Equal(t, []string{"createIndexes", "dropIndexes", "createIndexes"}, commands)
+	})
+
+	mt.Run("extra indexes are kept by default", func(mt *mtest.T) {
+		sync, err := NewIndexSync(createTestManager(mt, Config{URI: cfg.URI, Database: "testdb"}))
+		assert.NoError(t, err)
+		plan := &IndexPlan{Changes: []IndexChange{{Type: IndexExtra, Collection: "users", Name: "old_1"}}}
+
+		assert.NoError(t, sync.Apply(ctx, plan))
+		assert.Empty(t, mt.GetAllStartedEvents())
+	})
+}
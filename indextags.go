@@ -0,0 +1,243 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// IndexTag is the struct tag declaring the indexes of a field, see IndexModelsOf
+const IndexTag = "mongo"
+
+// tagIndex collects the fields and options of an index declared by tags
+type tagIndex struct {
+	group  string // Compound group name, empty for single field indexes
+	keys   bson.D
+	unique bool
+	sparse bool
+	ttl    *int32
+}
+
+// IndexModelsOf derives the indexes declared by the mongo tags of the document type T.
+//
+// A tag is a comma separated list of:
+//
+//	index           single field index
+//	unique          single field unique index
+//	ttl=<seconds>   single field TTL index
+//	sparse          makes the single field index sparse
+//	index=<group>   adds the field to the compound index named group, in field order
+//	unique=<group>  adds the field to the compound index named group and makes it unique
+//	desc            sorts the field descending in its indexes
+//	text, hashed, 2dsphere
+//	                index type of the field instead of ascending or descending, alone
+//	                it declares a single field index of that type
+//
+// A collection can have only one text index, so the text fields outside a group are
+// combined into one text index, and declaring more than one text index is an error.
+//
+// For example:
+//
+//	type Session struct {
+//		UserID   primitive.ObjectID `bson:"user_id" mongo:"index=user_recent"`
+//		LastSeen time.Time          `bson:"last_seen" mongo:"ttl=3600,index=user_recent,desc"`
+//		Token    string             `bson:"token" mongo:"unique"`
+//	}
+//
+// declares {token: 1} unique, {last_seen: 1} with a one hour TTL and user_recent on
+// {user_id: 1, last_seen: -1}. Field paths follow the bson tags, including nested and
+// inline structs. Indexes are returned in the order they are first declared; single field
+// indexes get the name generated by the server.
+func IndexModelsOf[T any]() ([]mongo.IndexModel, error) {
+	var indexes []*tagIndex
+	groups := make(map[string]*tagIndex)
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if err := collectIndexTags(t, "", &indexes, groups, make(map[reflect.Type]bool)); err != nil {
+		return nil, fmt.Errorf("indexes of %s: %w", t, err)
+	}
+	textIndexes := 0
+	for _, index := range indexes {
+		if slices.ContainsFunc(index.keys, func(key bson.E) bool { return key.Value == "text" }) {
+			textIndexes++
+		}
+	}
+	if textIndexes > 1 {
+		return nil, fmt.Errorf("indexes of %s: %d text indexes declared, a collection can have only one", t, textIndexes)
+	}
+
+	models := make([]mongo.IndexModel, 0, len(indexes))
+	for _, index := range indexes {
+		opts := options.Index()
+		if index.group != "" {
+			opts.SetName(index.group)
+		}
+		if index.unique {
+			opts.SetUnique(true)
+		}
+		if index.sparse {
+			opts.SetSparse(true)
+		}
+		if index.ttl != nil {
+			opts.SetExpireAfterSeconds(*index.ttl)
+		}
+		models = append(models, mongo.IndexModel{Keys: index.keys, Options: opts})
+	}
+	return models, nil
+}
+
+// EnsureIndexes creates the indexes declared by the mongo tags of T on a collection of the
+// default database, see IndexModelsOf
+func EnsureIndexes[T any](ctx context.Context, m Manager, collection string, opts ...*options.CreateIndexesOptions) ([]string, error) {
+	models, err := IndexModelsOf[T]()
+	if err != nil || len(models) == 0 {
+		return nil, err
+	}
+	return m.CreateIndexes(ctx, collection, models, opts...)
+}
+
+// EnsureIndexes creates the indexes declared by the mongo tags of T, see IndexModelsOf
+func (r *Repository[T]) EnsureIndexes(ctx context.Context, opts ...*options.CreateIndexesOptions) ([]string, error) {
+	return EnsureIndexes[T](ctx, r.manager, r.collection, opts...)
+}
+
+// collectIndexTags walks the fields of t below prefix and records their index declarations
+func collectIndexTags(t reflect.Type, prefix string, indexes *[]*tagIndex, groups map[string]*tagIndex, visiting map[reflect.Type]bool) error {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || visiting[t] || t.PkgPath() == "time" || t.PkgPath() == "go.mongodb.org/mongo-driver/bson/primitive" {
+		return nil
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, flags, _ := strings.Cut(field.Tag.Get("bson"), ",")
+		if name == "-" {
+			continue
+		}
+		path := prefix
+		if !hasTagFlag(flags, "inline") {
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			if path != "" {
+				path += "."
+			}
+			path += name
+		}
+
+		if tag, ok := field.Tag.Lookup(IndexTag); ok {
+			if err := addIndexTag(path, tag, indexes, groups); err != nil {
+				return fmt.Errorf("field %s: %w", field.Name, err)
+			}
+		}
+		if err := collectIndexTags(field.Type, path, indexes, groups, visiting); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sharedTextGroup is the groups key of the text index combining the text fields without a
+// group; tags cannot declare an empty group name
+const sharedTextGroup = ""
+
+// addIndexTag records the index declarations of the field at path
+func addIndexTag(path, tag string, indexes *[]*tagIndex, groups map[string]*tagIndex) error {
+	var value interface{} = int32(1)
+	var single *tagIndex
+	var joined []*tagIndex
+	singleIndex := func() *tagIndex {
+		if single == nil {
+			single = &tagIndex{}
+			*indexes = append(*indexes, single)
+		}
+		return single
+	}
+	groupIndex := func(group string) *tagIndex {
+		index, ok := groups[group]
+		if !ok {
+			index = &tagIndex{group: group}
+			groups[group] = index
+			*indexes = append(*indexes, index)
+		}
+		joined = append(joined, index)
+		return index
+	}
+
+	for _, option := range strings.Split(tag, ",") {
+		key, arg, hasArg := strings.Cut(strings.TrimSpace(option), "=")
+		switch {
+		case key == "index" && !hasArg:
+			singleIndex()
+		case key == "unique" && !hasArg:
+			singleIndex().unique = true
+		case key == "sparse" && !hasArg:
+			singleIndex().sparse = true
+		case key == "ttl" && hasArg:
+			seconds, err := strconv.ParseInt(arg, 10, 32)
+			if err != nil || seconds < 0 {
+				return fmt.Errorf("ttl %q must be a number of seconds", arg)
+			}
+			ttl := int32(seconds)
+			singleIndex().ttl = &ttl
+		case (key == "index" || key == "unique") && hasArg && arg != "":
+			index := groupIndex(arg)
+			index.unique = index.unique || key == "unique"
+		case key == "desc" && !hasArg:
+			value = int32(-1)
+		case (key == "text" || key == "hashed" || key == "2dsphere") && !hasArg:
+			value = key
+		case key == "":
+		default:
+			return fmt.Errorf("unknown index option %q in tag %q", option, tag)
+		}
+	}
+
+	if _, special := value.(string); special && single == nil && len(joined) == 0 {
+		singleIndex()
+	}
+	if single != nil && value == "text" {
+		if text, ok := groups[sharedTextGroup]; ok {
+			*indexes = slices.DeleteFunc(*indexes, func(index *tagIndex) bool { return index == single })
+			text.unique = text.unique || single.unique
+			text.sparse = text.sparse || single.sparse
+			single = text
+		} else {
+			groups[sharedTextGroup] = single
+		}
+		single.keys = append(single.keys, bson.E{Key: path, Value: value})
+	} else if single != nil {
+		single.keys = bson.D{{Key: path, Value: value}}
+	}
+	added := make(map[*tagIndex]bool, len(joined))
+	for _, index := range joined {
+		if !added[index] {
+			added[index] = true
+			index.keys = append(index.keys, bson.E{Key: path, Value: value})
+		}
+	}
+	return nil
+}
+
+// hasTagFlag reports whether a comma separated list of tag flags contains flag
+func hasTagFlag(flags, flag string) bool {
+	for _, f := range strings.Split(flags, ",") {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// taggedAddress is a nested document with an indexed field
+type taggedAddress struct {
+	City string `bson:"city" mongo:"index"`
+}
+
+// taggedBase is inlined into taggedSession
+type taggedBase struct {
+	TenantID string `bson:"tenant_id" mongo:"unique=tenant_token"`
+}
+
+// taggedSession declares indexes with struct tags
+type taggedSession struct {
+	Base     taggedBase         `bson:",inline"`
+	ID       primitive.ObjectID `bson:"_id,omitempty"`
+	UserID   primitive.ObjectID `bson:"user_id" mongo:"index=user_recent"`
+	LastSeen time.Time          `bson:"last_seen" mongo:"ttl=3600, index=user_recent, desc"`
+	Token    string             `bson:"token" mongo:"unique,sparse,unique=tenant_token"`
+	Body     string             `mongo:"text"`
+	Address  *taggedAddress     `bson:"address"`
+	Ignored  string             `bson:"-" mongo:"index"`
+}
+
+func TestIndexModelsOf(t *testing.T) {
+	models, err := IndexModelsOf[taggedSession]()
+	assert.NoError(t, err)
+	assert.Len(t, models, 6)
+
+	assert.Equal(t, bson.D{{Key: "tenant_id", Value: int32(1)}, {Key: "token", Value: int32(1)}}, models[0].Keys)
+	assert.Equal(t, "tenant_token", *models[0].Options.Name)
+	assert.True(t, *models[0].Options.Unique)
+
+	assert.Equal(t, bson.D{{Key: "user_id", Value: int32(1)}, {Key: "last_seen", Value: int32(-1)}}, models[1].Keys)
+	assert.Equal(t, "user_recent", *models[1].Options.Name)
+	assert.Nil(t, models[1].Options.Unique)
+
+	assert.Equal(t, bson.D{{Key: "last_seen", Value: int32(-1)}}, models[2].Keys)
+	assert.Equal(t, int32(3600), *models[2].Options.ExpireAfterSeconds)
+	assert.Nil(t, models[2].Options.Name)
+
+	assert.Equal(t, bson.D{{Key: "token", Value: int32(1)}}, models[3].Keys)
+	assert.True(t, *models[3].Options.Unique)
+	assert.True(t, *models[3].Options.Sparse)
+
+	assert.Equal(t, bson.D{{Key: "body", Value: "text"}}, models[4].Keys)
+	assert.Equal(t, bson.D{{Key: "address.city", Value: int32(1)}}, models[5].Keys)
+
+	models, err = IndexModelsOf[testUser]()
+	assert.NoError(t, err)
+	assert.Empty(t, models)
+}
+
+func TestIndexModelsOf_TextFields(t *testing.T) {
+	type article struct {
+		Title  string `bson:"title" mongo:"text"`
+		Author string `bson:"author" mongo:"index"`
+		Body   string `bson:"body" mongo:"text"`
+	}
+
+	models, err := IndexModelsOf[article]()
+	assert.NoError(t, err)
+	assert.Len(t, models, 2)
+	assert.Equal(t, bson.D{{Key: "title", Value: "text"}, {Key: "body", Value: "text"}}, models[0].Keys)
+	assert.Nil(t, models[0].Options.Name)
+	assert.Equal(t, bson.D{{Key: "author", Value: int32(1)}}, models[1].Keys)
+}
+
+func TestIndexModelsOf_InvalidTags(t *testing.T) {
+	type badTTL struct {
+		At time.Time `mongo:"ttl=soon"`
+	}
+	type unknownOption struct {
+		Name string `mongo:"indexed"`
+	}
+
+	type twoTextIndexes struct {
+		Title string `mongo:"text,index=search"`
+		Body  string `mongo:"text"`
+	}
+
+	_, err := IndexModelsOf[twoTextIndexes]()
+	assert.ErrorContains(t, err, "2 text indexes declared")
+	_, err = IndexModelsOf[badTTL]()
+	assert.ErrorContains(t, err, "ttl")
+	_, err = IndexModelsOf[unknownOption]()
+	assert.ErrorContains(t, err, `unknown index option "indexed"`)
+}
+
+func TestEnsureIndexes(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	cfg := Config{URI: "mongodb://localhost:27017", Database: "testdb"}
+
+	mt.Run("creates the tagged indexes", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		sessions := NewRepository[taggedSession](createTestManager(mt, cfg), "sessions")
+
+		names, err := sessions.EnsureIndexes(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, names, 6)
+
+		command := mt.GetStartedEvent().Command
+		assert.Equal(t, "sessions", command.Lookup("createIndexes").StringValue())
+		assert.Equal(t, "tenant_token", command.Lookup("indexes", "0", "name").StringValue())
+		assert.Equal(t, "last_seen_-1", command.Lookup("indexes", "2", "name").StringValue())
+	})
+
+	mt.Run("without tags", func(mt *mtest.T) {
+		names, err := EnsureIndexes[testUser](context.Background(), createTestManager(mt, cfg), "users")
+		assert.NoError(t, err)
+		assert.Empty(t, names)
+		assert.Empty(t, mt.GetAllStartedEvents())
+	})
+}
@@ -0,0 +1,464 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SchemaTag is the struct tag constraining the values of a field in JSONSchemaOf
+const SchemaTag = "schema"
+
+// Validation levels and actions accepted in ValidationConfig
+const (
+	ValidationLevelStrict   = "strict"   // Validate every insert and update
+	ValidationLevelModerate = "moderate" // Skip updates of documents that are already invalid
+	ValidationLevelOff      = "off"      // Do not validate
+
+	ValidationActionError = "error" // Reject invalid documents
+	ValidationActionWarn  = "warn"  // Log invalid documents and accept them
+)
+
+// bsonTypes of the types encoded as a specific BSON type rather than by kind
+var bsonTypes = map[reflect.Type]string{
+	reflect.TypeOf(primitive.ObjectID{}):   "objectId",
+	reflect.TypeOf(primitive.DateTime(0)):  "date",
+	reflect.TypeOf(primitive.Decimal128{}): "decimal",
+	reflect.TypeOf(primitive.Binary{}):     "binData",
+	reflect.TypeOf(primitive.Regex{}):      "regex",
+	reflect.TypeOf(primitive.Timestamp{}):  "timestamp",
+	reflect.TypeOf(bson.D{}):               "object",
+	reflect.TypeOf(bson.M{}):               "object",
+	reflect.TypeOf(bson.Raw{}):             "object",
+	reflect.TypeOf(bson.A{}):               "array",
+}
+
+// JSONSchemaOf generates the $jsonSchema of the documents of type T from their bson tags.
+//
+// Field types map to BSON types; pointers, slices and maps also accept null, which is how
+// the driver encodes their nil values. The schema tag adds constraints as a comma separated
+// list of:
+//
+//	required            the field must be present
+//	enum=a|b|c          the value must be one of the listed values
+//	min=<n>, max=<n>    bounds of numbers, of the length of strings or of the size of arrays
+//	pattern=<regex>     regular expression strings must match, it cannot contain commas
+//	description=<text>  description reported in validation errors
+//
+// For example:
+//
+//	type User struct {
+//		Email  string `bson:"email" schema:"required,pattern=^.+@.+$"`
+//		Status string `bson:"status" schema:"required,enum=active|suspended"`
+//		Age    int    `bson:"age,omitempty" schema:"min=13,max=130"`
+//	}
+func JSONSchemaOf[T any]() (bson.D, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	schema, err := jsonSchemaOf(t, make(map[reflect.Type]bool))
+	if err != nil {
+		return nil, fmt.Errorf("schema of %s: %w", t, err)
+	}
+	return schema, nil
+}
+
+// jsonSchemaOf generates the schema of a type
+func jsonSchemaOf(t reflect.Type, visiting map[reflect.Type]bool) (bson.D, error) {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+	withType := func(bsonType string, rest ...bson.E) bson.D {
+		var value interface{} = bsonType
+		if nullable {
+			value = bson.A{bsonType, "null"}
+		}
+		return append(bson.D{{Key: "bsonType", Value: value}}, rest...)
+	}
+
+	if bsonType, ok := bsonTypes[t]; ok {
+		return withType(bsonType), nil
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return withType("date"), nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return withType("string"), nil
+	case reflect.Bool:
+		return withType("bool"), nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return withType("int"), nil
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return withTypes(nullable, "int", "long"), nil
+	case reflect.Float32, reflect.Float64:
+		return withType("double"), nil
+	case reflect.Interface:
+		return bson.D{}, nil
+	case reflect.Map:
+		nullable = true
+		return withType("object"), nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return withType("binData"), nil
+		}
+		nullable = nullable || t.Kind() == reflect.Slice
+		items, err := jsonSchemaOf(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		if len(items) == 0 {
+			return withType("array"), nil
+		}
+		return withType("array", bson.E{Key: "items", Value: items}), nil
+	case reflect.Struct:
+		if visiting[t] {
+			// Recursive types are not expanded again
+			return withType("object"), nil
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		properties := bson.D{}
+		var required bson.A
+		if err := structSchema(t, visiting, &properties, &required); err != nil {
+			return nil, err
+		}
+		schema := withType("object")
+		if len(required) > 0 {
+			schema = append(schema, bson.E{Key: "required", Value: required})
+		}
+		return append(schema, bson.E{Key: "properties", Value: properties}), nil
+	}
+	return nil, fmt.Errorf("type %s has no BSON representation", t)
+}
+
+// withTypes returns a schema accepting several BSON types
+func withTypes(nullable bool, bsonTypes ...string) bson.D {
+	types := make(bson.A, 0, len(bsonTypes)+1)
+	for _, bsonType := range bsonTypes {
+		types = append(types, bsonType)
+	}
+	if nullable {
+		types = append(types, "null")
+	}
+	return bson.D{{Key: "bsonType", Value: types}}
+}
+
+// structSchema adds the properties and required fields of a struct, inline fields included
+func structSchema(t reflect.Type, visiting map[reflect.Type]bool, properties *bson.D, required *bson.A) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, flags, _ := strings.Cut(field.Tag.Get("bson"), ",")
+		if name == "-" {
+			continue
+		}
+		if hasTagFlag(flags, "inline") {
+			inline := field.Type
+			for inline.Kind() == reflect.Pointer {
+				inline = inline.Elem()
+			}
+			if inline.Kind() == reflect.Struct {
+				if err := structSchema(inline, visiting, properties, required); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		schema, err := jsonSchemaOf(field.Type, visiting)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		isRequired, err := applySchemaTag(&schema, field.Type, field.Tag.Get(SchemaTag))
+		if err != nil {
+			return fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		if isRequired {
+			*required = append(*required, name)
+		}
+		*properties = append(*properties, bson.E{Key: name, Value: schema})
+	}
+	return nil
+}
+
+// applySchemaTag adds the constraints of a schema tag and reports whether the field is required
+func applySchemaTag(schema *bson.D, t reflect.Type, tag string) (bool, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	required := false
+	for _, option := range strings.Split(tag, ",") {
+		key, arg, _ := strings.Cut(option, "=")
+		switch key {
+		case "":
+		case "required":
+			required = true
+		case "enum":
+			values := bson.A{}
+			for _, value := range strings.Split(arg, "|") {
+				parsed, err := parseSchemaValue(t, value)
+				if err != nil {
+					return false, fmt.Errorf("enum: %w", err)
+				}
+				values = append(values, parsed)
+			}
+			*schema = append(*schema, bson.E{Key: "enum", Value: values})
+		case "min", "max":
+			keyword, err := boundKeyword(t, key)
+			if err != nil {
+				return false, err
+			}
+			var bound interface{}
+			if keyword == "minimum" || keyword == "maximum" {
+				bound, err = parseSchemaValue(t, arg)
+			} else {
+				bound, err = strconv.ParseInt(arg, 10, 64)
+			}
+			if err != nil {
+				return false, fmt.Errorf("%s: %w", key, err)
+			}
+			*schema = append(*schema, bson.E{Key: keyword, Value: bound})
+		case "pattern":
+			if t.Kind() != reflect.String {
+				return false, fmt.Errorf("pattern needs a string field")
+			}
+			*schema = append(*schema, bson.E{Key: "pattern", Value: arg})
+		case "description":
+			*schema = append(*schema, bson.E{Key: "description", Value: arg})
+		default:
+			return false, fmt.Errorf("unknown schema option %q in tag %q", option, tag)
+		}
+	}
+	return required, nil
+}
+
+// boundKeyword returns the JSON Schema keyword of a min or max bound for a type
+func boundKeyword(t reflect.Type, bound string) (string, error) {
+	keywords := map[string][2]string{
+		"min": {"minimum", "minLength"},
+		"max": {"maximum", "maxLength"},
+	}[bound]
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return keywords[0], nil
+	case reflect.String:
+		return keywords[1], nil
+	case reflect.Slice, reflect.Array:
+		return bound + "Items", nil
+	}
+	return "", fmt.Errorf("%s needs a number, string or array field", bound)
+}
+
+// parseSchemaValue converts a tag value to the BSON value of a field type
+func parseSchemaValue(t reflect.Type, value string) (interface{}, error) {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.ParseInt(value, 10, 64)
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(value, 64)
+	case reflect.Bool:
+		return strconv.ParseBool(value)
+	case reflect.String:
+		return value, nil
+	}
+	return nil, fmt.Errorf("values of type %s cannot be given in a tag", t)
+}
+
+// validate checks the validation level and action
+func (c ValidationConfig) validate() error {
+	switch c.Level {
+	case "", ValidationLevelStrict, ValidationLevelModerate, ValidationLevelOff:
+	default:
+		return fmt.Errorf("validation: level %q must be one of %s, %s, %s", c.Level, ValidationLevelStrict, ValidationLevelModerate, ValidationLevelOff)
+	}
+	switch c.Action {
+	case "", ValidationActionError, ValidationActionWarn:
+	default:
+		return fmt.Errorf("validation: action %q must be one of %s, %s", c.Action, ValidationActionError, ValidationActionWarn)
+	}
+	return nil
+}
+
+// ValidatorDiff reports how the validator of a collection differs from a schema
+type ValidatorDiff struct {
+	Collection string
+	Exists     bool     // The collection exists, otherwise applying creates it
+	Changes    []string // Changed settings and schema paths, e.g. "$jsonSchema.properties.age.minimum: 0 -> 13"
+	Current    bson.D   // Current validator, nil without one
+	Desired    bson.D   // Validator applied, {$jsonSchema: schema}
+}
+
+// Empty reports whether the collection exists with the desired validator and settings
+func (d *ValidatorDiff) Empty() bool {
+	return d.Exists && len(d.Changes) == 0
+}
+
+// DiffValidator compares the validator of a collection of the default database with the
+// $jsonSchema of T and the configured validation level and action, without changing it
+func DiffValidator[T any](ctx context.Context, m Manager, collection string) (*ValidatorDiff, error) {
+	schema, err := JSONSchemaOf[T]()
+	if err != nil {
+		return nil, err
+	}
+	return diffValidator(ctx, m, collection, schema)
+}
+
+// ApplyValidator sets the $jsonSchema of T and the configured validation level and action
+// on a collection of the default database, with createCollection when it does not exist
+// and collMod when it differs. It returns the applied differences.
+func ApplyValidator[T any](ctx context.Context, m Manager, collection string) (*ValidatorDiff, error) {
+	schema, err := JSONSchemaOf[T]()
+	if err != nil {
+		return nil, err
+	}
+	diff, err := diffValidator(ctx, m, collection, schema)
+	if err != nil || diff.Empty() {
+		return diff, err
+	}
+
+	validation := m.Config().Validation.withDefaults()
+	database := m.Database()
+	if !diff.Exists {
+		err = database.CreateCollection(ctx, collection, options.CreateCollection().
+			SetValidator(diff.Desired).
+			SetValidationLevel(validation.Level).
+			SetValidationAction(validation.Action))
+	} else {
+		err = database.RunCommand(ctx, bson.D{
+			{Key: "collMod", Value: collection},
+			{Key: "validator", Value: diff.Desired},
+			{Key: "validationLevel", Value: validation.Level},
+			{Key: "validationAction", Value: validation.Action},
+		}).Err()
+	}
+	if err != nil {
+		return diff, fmt.Errorf("apply validator of %s: %w", collection, err)
+	}
+	return diff, nil
+}
+
+// withDefaults fills the server defaults of the level and action
+func (c ValidationConfig) withDefaults() ValidationConfig {
+	if c.Level == "" {
+		c.Level = ValidationLevelStrict
+	}
+	if c.Action == "" {
+		c.Action = ValidationActionError
+	}
+	return c
+}
+
+// diffValidator compares the validator of a collection with a schema
+func diffValidator(ctx context.Context, m Manager, collection string, schema bson.D) (*ValidatorDiff, error) {
+	if err := m.Connect(ctx); err != nil {
+		return nil, err
+	}
+	specs, err := m.Database().ListCollectionSpecifications(ctx, bson.D{{Key: "name", Value: collection}})
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &ValidatorDiff{Collection: collection, Desired: bson.D{{Key: "$jsonSchema", Value: schema}}}
+	validation := m.Config().Validation.withDefaults()
+	if len(specs) == 0 {
+		diff.Changes = []string{"collection: created"}
+		return diff, nil
+	}
+	diff.Exists = true
+
+	var current struct {
+		Validator bson.D `bson:"validator"`
+		Level     string `bson:"validationLevel"`
+		Action    string `bson:"validationAction"`
+	}
+	if specs[0].Options != nil {
+		if err := bson.Unmarshal(specs[0].Options, &current); err != nil {
+			return nil, err
+		}
+	}
+	diff.Current = current.Validator
+	if level := (ValidationConfig{Level: current.Level}).withDefaults().Level; level != validation.Level {
+		diff.Changes = append(diff.Changes, fmt.Sprintf("validationLevel: %s -> %s", level, validation.Level))
+	}
+	if action := (ValidationConfig{Action: current.Action}).withDefaults().Action; action != validation.Action {
+		diff.Changes = append(diff.Changes, fmt.Sprintf("validationAction: %s -> %s", action, validation.Action))
+	}
+	diff.Changes = append(diff.Changes, diffDocuments(current.Validator, diff.Desired)...)
+	return diff, nil
+}
+
+// diffDocuments describes the added, removed and changed leaf paths between two documents
+func diffDocuments(from, to bson.D) []string {
+	before, after := make(map[string]interface{}), make(map[string]interface{})
+	flattenDocument("", from, before)
+	flattenDocument("", to, after)
+
+	paths := make([]string, 0, len(before)+len(after))
+	for path := range before {
+		paths = append(paths, path)
+	}
+	for path := range after {
+		if _, ok := before[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var changes []string
+	for _, path := range paths {
+		old, hadOld := before[path]
+		value, hasValue := after[path]
+		switch {
+		case !hadOld:
+			changes = append(changes, fmt.Sprintf("%s: added %s", path, formatBSONValue(value)))
+		case !hasValue:
+			changes = append(changes, fmt.Sprintf("%s: removed", path))
+		case !reflect.DeepEqual(canonicalBSON(old), canonicalBSON(value)):
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", path, formatBSONValue(old), formatBSONValue(value)))
+		}
+	}
+	return changes
+}
+
+// flattenDocument records the leaf values of a document by dotted path, arrays are leaves
+func flattenDocument(prefix string, doc bson.D, leaves map[string]interface{}) {
+	for _, elem := range doc {
+		path := elem.Key
+		if prefix != "" {
+			path = prefix + "." + elem.Key
+		}
+		if nested, ok := elem.Value.(bson.D); ok && len(nested) > 0 {
+			flattenDocument(path, nested, leaves)
+			continue
+		}
+		leaves[path] = elem.Value
+	}
+}
+
+// formatBSONValue formats a value as relaxed extended JSON
+func formatBSONValue(value interface{}) string {
+	data, err := bson.MarshalExtJSON(bson.D{{Key: "v", Value: value}}, false, false)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(string(data), `{"v":`), "}")
+}
@@ -0,0 +1,188 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// schemaProfile is a nested document
+type schemaProfile struct {
+	Bio string `bson:"bio" schema:"max=280"`
+}
+
+// schemaMeta is inlined into schemaUser
+type schemaMeta struct {
+	CreatedAt time.Time `bson:"created_at" schema:"required"`
+}
+
+// schemaUser exercises the schema tag options
+type schemaUser struct {
+	ID      primitive.ObjectID     `bson:"_id,omitempty"`
+	Email   string                 `bson:"email" schema:"required,pattern=^.+@.+$"`
+	Status  string                 `bson:"status" schema:"required,enum=active|suspended,description=Account state"`
+	Age     int                    `bson:"age,omitempty" schema:"min=13,max=130"`
+	Score   float64                `bson:"score"`
+	Tags    []string               `bson:"tags" schema:"max=10"`
+	Profile *schemaProfile         `bson:"profile"`
+	Extra   map[string]interface{} `bson:"extra"`
+	Any     interface{}            `bson:"any"`
+	Meta    schemaMeta             `bson:",inline"`
+	Secret  string                 `bson:"-"`
+}
+
+func TestJSONSchemaOf(t *testing.T) {
+	schema, err := JSONSchemaOf[schemaUser]()
+	assert.NoError(t, err)
+
+	assert.Equal(t, bson.D{
+		{Key: "bsonType", Value: "object"},
+		{Key: "required", Value: bson.A{"email", "status", "created_at"}},
+		{Key: "properties", Value: bson.D{
+			{Key: "_id", Value: bson.D{{Key: "bsonType", Value: "objectId"}}},
+			{Key: "email", Value: bson.D{{Key: "bsonType", Value: "string"}, {Key: "pattern", Value: "^.+@.+$"}}},
+			{Key: "status", Value: bson.D{
+				{Key: "bsonType", Value: "string"},
+				{Key: "enum", Value: bson.A{"active", "suspended"}},
+				{Key: "description", Value: "Account state"},
+			}},
+			{Key: "age", Value: bson.D{
+				{Key: "bsonType", Value: bson.A{"int", "long"}},
+				{Key: "minimum", Value: int64(13)},
+				{Key: "maximum", Value: int64(130)},
+			}},
+			{Key: "score", Value: bson.D{{Key: "bsonType", Value: "double"}}},
+			{Key: "tags", Value: bson.D{
+				{Key: "bsonType", Value: bson.A{"array", "null"}},
+				{Key: "items", Value: bson.D{{Key: "bsonType", Value: "string"}}},
+				{Key: "maxItems", Value: int64(10)},
+			}},
+			{Key: "profile", Value: bson.D{
+				{Key: "bsonType", Value: bson.A{"object", "null"}},
+				{Key: "properties", Value: bson.D{
+					{Key: "bio", Value: bson.D{{Key: "bsonType", Value: "string"}, {Key: "maxLength", Value: int64(280)}}},
+				}},
+			}},
+			{Key: "extra", Value: bson.D{{Key: "bsonType", Value: bson.A{"object", "null"}}}},
+			{Key: "any", Value: bson.D{}},
+			{Key: "created_at", Value: bson.D{{Key: "bsonType", Value: "date"}}},
+		}},
+	}, schema)
+}
+
+func TestJSONSchemaOf_InvalidTags(t *testing.T) {
+	type badEnum struct {
+		Level int `schema:"enum=low|high"`
+	}
+	type badBound struct {
+		Active bool `schema:"min=1"`
+	}
+	type unknownOption struct {
+		Name string `schema:"optional"`
+	}
+	type unsupported struct {
+		Callback func()
+	}
+
+	_, err := JSONSchemaOf[badEnum]()
+	assert.ErrorContains(t, err, "enum")
+	_, err = JSONSchemaOf[badBound]()
+	assert.ErrorContains(t, err, "min needs")
+	_, err = JSONSchemaOf[unknownOption]()
+	assert.ErrorContains(t, err, `unknown schema option "optional"`)
+	_, err = JSONSchemaOf[unsupported]()
+	assert.ErrorContains(t, err, "no BSON representation")
+}
+
+func TestDiffDocuments(t *testing.T) {
+	from := bson.D{{Key: "$jsonSchema", Value: bson.D{
+		{Key: "required", Value: bson.A{"email"}},
+		{Key: "properties", Value: bson.D{
+			{Key: "age", Value: bson.D{{Key: "minimum", Value: int32(0)}}},
+			{Key: "nickname", Value: bson.D{{Key: "bsonType", Value: "string"}}},
+		}},
+	}}}
+	to := bson.D{{Key: "$jsonSchema", Value: bson.D{
+		{Key: "required", Value: bson.A{"email"}},
+		{Key: "properties", Value: bson.D{
+			{Key: "age", Value: bson.D{{Key: "minimum", Value: int64(13)}}},
+			{Key: "status", Value: bson.D{{Key: "enum", Value: bson.A{"active"}}}},
+		}},
+	}}}
+
+	assert.Equal(t, []string{
+		"$jsonSchema.properties.age.minimum: 0 -> 13",
+		"$jsonSchema.properties.nickname.bsonType: removed",
+		`$jsonSchema.properties.status.enum: added ["active"]`,
+	}, diffDocuments(from, to))
+	assert.Empty(t, diffDocuments(to, to))
+}
+
+func TestApplyValidator(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	ctx := context.Background()
+	cfg := Config{URI: "mongodb://localhost:27017", Database: "testdb", Validation: ValidationConfig{Level: ValidationLevelModerate}}
+
+	mt.Run("creates a missing collection", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "testdb.$cmd.listCollections", mtest.FirstBatch),
+			mtest.CreateSuccessResponse(),
+		)
+
+		diff, err := ApplyValidator[schemaProfile](ctx, createTestManager(mt, cfg), "profiles")
+		assert.NoError(t, err)
+		assert.False(t, diff.Exists)
+
+		command := mt.GetAllStartedEvents()[1].Command
+		assert.Equal(t, "profiles", command.Lookup("create").StringValue())
+		assert.Equal(t, "moderate", command.Lookup("validationLevel").StringValue())
+		assert.Equal(t, "error", command.Lookup("validationAction").StringValue())
+		assert.Equal(t, "object", command.Lookup("validator", "$jsonSchema", "bsonType").StringValue())
+	})
+
+	mt.Run("modifies a different validator", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "testdb.$cmd.listCollections", mtest.FirstBatch, bson.D{
+				{Key: "name", Value: "profiles"},
+				{Key: "type", Value: "collection"},
+				{Key: "options", Value: bson.D{{Key: "validator", Value: bson.D{{Key: "$jsonSchema", Value: bson.D{
+					{Key: "bsonType", Value: "object"},
+					{Key: "properties", Value: bson.D{{Key: "bio", Value: bson.D{{Key: "bsonType", Value: "string"}, {Key: "maxLength", Value: int64(140)}}}}},
+				}}}}}},
+			}),
+			mtest.CreateSuccessResponse(),
+		)
+
+		diff, err := ApplyValidator[schemaProfile](ctx, createTestManager(mt, cfg), "profiles")
+		assert.NoError(t, err)
+		assert.True(t, diff.Exists)
+		assert.Equal(t, []string{
+			"validationLevel: strict -> moderate",
+			"$jsonSchema.properties.bio.maxLength: 140 -> 280",
+		}, diff.Changes)
+		assert.Equal(t, "collMod", mt.GetAllStartedEvents()[1].CommandName)
+	})
+
+	mt.Run("diff does not change the collection", func(mt *mtest.T) {
+		schema, err := JSONSchemaOf[schemaProfile]()
+		assert.NoError(t, err)
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "testdb.$cmd.listCollections", mtest.FirstBatch, bson.D{
+			{Key: "name", Value: "profiles"},
+			{Key: "type", Value: "collection"},
+			{Key: "options", Value: bson.D{
+				{Key: "validator", Value: bson.D{{Key: "$jsonSchema", Value: schema}}},
+				{Key: "validationLevel", Value: "moderate"},
+			}},
+		}))
+
+		diff, err := DiffValidator[schemaProfile](ctx, createTestManager(mt, cfg), "profiles")
+		assert.NoError(t, err)
+		assert.True(t, diff.Empty())
+		assert.Len(t, mt.GetAllStartedEvents(), 1)
+	})
+}
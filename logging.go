@@ -0,0 +1,181 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// parseLogLevel parses a level name accepted by LoggingConfig
+func parseLogLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q: must be one of debug, info, warn, error", name)
+	}
+	return level, nil
+}
+
+// validate checks the configured levels and body length
+func (c LoggingConfig) validate() error {
+	if c.Level != "" {
+		if _, err := parseLogLevel(c.Level); err != nil {
+			return fmt.Errorf("logging: %w", err)
+		}
+	}
+	for command, name := range c.CommandLevels {
+		if strings.EqualFold(name, LogLevelOff) {
+			continue
+		}
+		if _, err := parseLogLevel(name); err != nil {
+			return fmt.Errorf("logging: command %q: %w", command, err)
+		}
+	}
+	if c.MaxBodyLength < 0 {
+		return fmt.Errorf("logging: max_body_length must not be negative")
+	}
+	return nil
+}
+
+// commandLogger logs command monitoring events through slog
+type commandLogger struct {
+	logger        *slog.Logger
+	level         slog.Level
+	levels        map[string]slog.Level
+	off           map[string]bool
+	maxBodyLength int
+
+	// collections remembers the collection of started commands, keyed by request ID,
+	// because finished events do not carry the command
+	collections sync.Map
+}
+
+// newCommandLogger creates a command logger from a validated logging configuration
+func newCommandLogger(logger *slog.Logger, cfg LoggingConfig) *commandLogger {
+	l := &commandLogger{
+		logger:        logger,
+		level:         slog.LevelDebug,
+		levels:        make(map[string]slog.Level),
+		off:           make(map[string]bool),
+		maxBodyLength: cfg.MaxBodyLength,
+	}
+	if level, err := parseLogLevel(cfg.Level); err == nil {
+		l.level = level
+	}
+	for command, name := range cfg.CommandLevels {
+		if strings.EqualFold(name, LogLevelOff) {
+			l.off[command] = true
+			continue
+		}
+		if level, err := parseLogLevel(name); err == nil {
+			l.levels[command] = level
+		}
+	}
+	return l
+}
+
+// levelFor returns the level of started and succeeded events of a command and whether it is logged at all
+func (l *commandLogger) levelFor(command string) (slog.Level, bool) {
+	if l.off[command] {
+		return 0, false
+	}
+	if level, ok := l.levels[command]; ok {
+		return level, true
+	}
+	return l.level, true
+}
+
+// monitor returns a command monitor writing to the logger
+func (l *commandLogger) monitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started:   l.started,
+		Succeeded: l.succeeded,
+		Failed:    l.failed,
+	}
+}
+
+// started logs a command started event
+func (l *commandLogger) started(ctx context.Context, evt *event.CommandStartedEvent) {
+	level, ok := l.levelFor(evt.CommandName)
+	if !ok {
+		return
+	}
+	collection := commandCollection(evt.Command)
+	l.collections.Store(evt.RequestID, collection)
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("command", evt.CommandName),
+		slog.String("database", evt.DatabaseName),
+		slog.String("collection", collection),
+		slog.Int64("request_id", evt.RequestID),
+		slog.String("connection_id", evt.ConnectionID),
+	}
+	if l.maxBodyLength > 0 {
+		attrs = append(attrs, slog.String("body", l.body(evt.Command)))
+	}
+	l.logger.LogAttrs(ctx, level, "MongoDB command started", attrs...)
+}
+
+// succeeded logs a command succeeded event
+func (l *commandLogger) succeeded(ctx context.Context, evt *event.CommandSucceededEvent) {
+	collection := l.finish(evt.RequestID)
+	level, ok := l.levelFor(evt.CommandName)
+	if !ok || !l.logger.Enabled(ctx, level) {
+		return
+	}
+	l.logger.LogAttrs(ctx, level, "MongoDB command succeeded", l.finishedAttrs(&evt.CommandFinishedEvent, collection)...)
+}
+
+// failed logs a command failed event
+func (l *commandLogger) failed(ctx context.Context, evt *event.CommandFailedEvent) {
+	collection := l.finish(evt.RequestID)
+	if _, ok := l.levelFor(evt.CommandName); !ok || !l.logger.Enabled(ctx, slog.LevelError) {
+		return
+	}
+	attrs := append(l.finishedAttrs(&evt.CommandFinishedEvent, collection), slog.String("error", evt.Failure))
+	l.logger.LogAttrs(ctx, slog.LevelError, "MongoDB command failed", attrs...)
+}
+
+// finish forgets a started command and returns its collection
+func (l *commandLogger) finish(requestID int64) string {
+	collection, _ := l.collections.LoadAndDelete(requestID)
+	name, _ := collection.(string)
+	return name
+}
+
+// finishedAttrs returns the attributes shared by succeeded and failed events
+func (l *commandLogger) finishedAttrs(evt *event.CommandFinishedEvent, collection string) []slog.Attr {
+	return []slog.Attr{
+		slog.String("command", evt.CommandName),
+		slog.String("database", evt.DatabaseName),
+		slog.String("collection", collection),
+		slog.Int64("request_id", evt.RequestID),
+		slog.String("connection_id", evt.ConnectionID),
+		slog.Duration("duration", evt.Duration),
+	}
+}
+
+// body returns the redacted command as extended JSON, truncated to the configured length
+func (l *commandLogger) body(cmd bson.Raw) string {
+	data, err := bson.MarshalExtJSON(commandShape(cmd), false, false)
+	if err != nil {
+		return ""
+	}
+	if len(data) > l.maxBodyLength {
+		// Cut at a rune boundary so a multi-byte character is not split
+		n := l.maxBodyLength
+		for n > 0 && !utf8.RuneStart(data[n]) {
+			n--
+		}
+		return string(data[:n]) + "..."
+	}
+	return string(data)
+}
@@ -0,0 +1,160 @@
+package mongodb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// logRecords decodes the JSON lines written by a slog JSON handler
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+// findCommand returns a marshalled find command on testdb.users
+func findCommand(t *testing.T) bson.Raw {
+	t.Helper()
+	cmd, err := bson.Marshal(bson.D{
+		{Key: "find", Value: "users"},
+		{Key: "filter", Value: bson.D{{Key: "email", Value: "jane@example.com"}}},
+		{Key: "lsid", Value: bson.D{{Key: "id", Value: "session"}}},
+	})
+	assert.NoError(t, err)
+	return cmd
+}
+
+func TestCommandLogger(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("logs started, succeeded and failed events", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		monitor := newCommandLogger(logger, LoggingConfig{Level: "info", MaxBodyLength: 1000}).monitor()
+
+		monitor.Started(ctx, &event.CommandStartedEvent{Command: findCommand(t), CommandName: "find", DatabaseName: "testdb", RequestID: 7, ConnectionID: "conn-1"})
+		monitor.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: event.CommandFinishedEvent{
+			CommandName: "find", DatabaseName: "testdb", RequestID: 7, ConnectionID: "conn-1", Duration: 3 * time.Millisecond,
+		}})
+		monitor.Started(ctx, &event.CommandStartedEvent{Command: findCommand(t), CommandName: "find", DatabaseName: "testdb", RequestID: 8})
+		monitor.Failed(ctx, &event.CommandFailedEvent{CommandFinishedEvent: event.CommandFinishedEvent{
+			CommandName: "find", DatabaseName: "testdb", RequestID: 8,
+		}, Failure: "boom"})
+
+		records := logRecords(t, &buf)
+		assert.Len(t, records, 4)
+
+		started := records[0]
+		assert.Equal(t, "INFO", started["level"])
+		assert.Equal(t, "MongoDB command started", started["msg"])
+		assert.Equal(t, "users", started["collection"])
+		assert.Equal(t, float64(7), started["request_id"])
+		assert.Equal(t, `{"find":"users","filter":{"email":"?"}}`, started["body"])
+		assert.NotContains(t, started["body"], "jane@example.com")
+
+		succeeded := records[1]
+		assert.Equal(t, "MongoDB command succeeded", succeeded["msg"])
+		assert.Equal(t, "users", succeeded["collection"])
+		assert.Equal(t, float64(3*time.Millisecond), succeeded["duration"])
+
+		failed := records[3]
+		assert.Equal(t, "ERROR", failed["level"])
+		assert.Equal(t, "MongoDB command failed", failed["msg"])
+		assert.Equal(t, "boom", failed["error"])
+	})
+
+	t.Run("command level overrides", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+		monitor := newCommandLogger(logger, LoggingConfig{
+			Level:         "info",
+			CommandLevels: map[string]string{"ping": "debug", "hello": "off", "insert": "warn"},
+		}).monitor()
+
+		for _, name := range []string{"ping", "hello", "insert"} {
+			monitor.Started(ctx, &event.CommandStartedEvent{Command: bson.Raw(bsonDoc(t, name)), CommandName: name})
+			monitor.Failed(ctx, &event.CommandFailedEvent{CommandFinishedEvent: event.CommandFinishedEvent{CommandName: name}})
+		}
+
+		records := logRecords(t, &buf)
+		var messages []string
+		for _, record := range records {
+			messages = append(messages, record["command"].(string)+" "+record["level"].(string))
+		}
+		// ping is below the handler level except for its failure, hello is silenced
+		assert.Equal(t, []string{"ping ERROR", "insert WARN", "insert ERROR"}, messages)
+		assert.NotContains(t, records[1], "body", "bodies are not logged when max_body_length is 0")
+	})
+
+	t.Run("truncates long bodies", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		monitor := newCommandLogger(logger, LoggingConfig{MaxBodyLength: 10}).monitor()
+
+		monitor.Started(ctx, &event.CommandStartedEvent{Command: findCommand(t), CommandName: "find"})
+
+		records := logRecords(t, &buf)
+		assert.Equal(t, "DEBUG", records[0]["level"])
+		assert.Equal(t, `{"find":"u...`, records[0]["body"])
+	})
+
+	t.Run("truncates at a rune boundary", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		monitor := newCommandLogger(logger, LoggingConfig{MaxBodyLength: 12}).monitor()
+
+		command, err := bson.Marshal(bson.D{{Key: "find", Value: "ngày"}})
+		assert.NoError(t, err)
+		monitor.Started(ctx, &event.CommandStartedEvent{Command: command, CommandName: "find"})
+
+		records := logRecords(t, &buf)
+		assert.Equal(t, `{"find":"ng...`, records[0]["body"])
+	})
+}
+
+// bsonDoc returns a command document with the given name
+func bsonDoc(t *testing.T, name string) []byte {
+	t.Helper()
+	doc, err := bson.Marshal(bson.D{{Key: name, Value: 1}})
+	assert.NoError(t, err)
+	return doc
+}
+
+func TestLoggingConfig_Validate(t *testing.T) {
+	assert.NoError(t, LoggingConfig{}.validate())
+	assert.NoError(t, LoggingConfig{Level: "WARN", CommandLevels: map[string]string{"ping": "off", "find": "debug"}}.validate())
+	assert.ErrorContains(t, LoggingConfig{Level: "verbose"}.validate(), `invalid log level "verbose"`)
+	assert.ErrorContains(t, LoggingConfig{CommandLevels: map[string]string{"find": "loud"}}.validate(), `command "find"`)
+	assert.Error(t, LoggingConfig{MaxBodyLength: -1}.validate())
+}
+
+func TestWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	mgr, err := newManager(Config{URI: "mongodb://localhost:27017"}, WithLogger(logger))
+	assert.NoError(t, err)
+	assert.True(t, mgr.config.Logging.Enabled)
+	assert.Same(t, logger, mgr.log())
+
+	monitor := mgr.commandMonitor()
+	monitor.Failed(context.Background(), &event.CommandFailedEvent{CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "find"}, Failure: "boom"})
+	assert.Contains(t, buf.String(), "MongoDB command failed")
+}
@@ -2,11 +2,14 @@ package mongodb
 
 import (
 	"context"
-	"crypto/tls"
+	"errors"
 	"fmt"
-	"strings"
+	"log/slog"
+	"sync"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readconcern"
@@ -31,15 +34,33 @@ type Manager interface {
 	// CollectionWithDatabase returns a collection from the specified database
 	CollectionWithDatabase(dbName, collectionName string) *mongo.Collection
 
+	// ReadPreference returns the read preference of a named profile (empty name for the default)
+	ReadPreference(profile string) (*readpref.ReadPref, error)
+
+	// DatabaseWithReadPreference returns the default database using a named read preference profile
+	DatabaseWithReadPreference(profile string) (*mongo.Database, error)
+
+	// CollectionWithReadPreference returns a collection from the default database using a named read preference profile
+	CollectionWithReadPreference(name, profile string) (*mongo.Collection, error)
+
+	// ClientEncryption returns a client for explicit field encryption and data key management
+	ClientEncryption() (*mongo.ClientEncryption, error)
+
 	// Config returns the MongoDB configuration
 	Config() *Config
 
+	// Connect establishes the connection if it does not exist yet, honoring ctx cancellation
+	Connect(ctx context.Context) error
+
 	// Ping pings the MongoDB server
 	Ping(ctx context.Context) error
 
 	// Disconnect disconnects from MongoDB
 	Disconnect(ctx context.Context) error
 
+	// Shutdown closes tracked change streams and sessions, waits for in-flight operations until ctx is done, then disconnects for good
+	Shutdown(ctx context.Context) error
+
 	// StartSession starts a new session
 	StartSession(opts ...*options.SessionOptions) (mongo.Session, error)
 
@@ -52,9 +73,21 @@ type Manager interface {
 	// HealthCheck performs a health check on the MongoDB connection
 	HealthCheck(ctx context.Context) error
 
+	// HealthReport runs the given checks, or the configured health checks when none are given
+	HealthReport(ctx context.Context, checks ...string) HealthReport
+
 	// Stats returns database statistics
 	Stats(ctx context.Context) (map[string]interface{}, error)
 
+	// PoolStats returns the connection pool statistics of every server the client is connected to
+	PoolStats() []PoolStats
+
+	// Topology returns the deployment state gathered from server monitoring events
+	Topology() Topology
+
+	// SubscribeTopology returns a channel notified of primary elections and server state changes, and a function ending the subscription
+	SubscribeTopology(buffer int) (<-chan TopologyEvent, func())
+
 	// ListCollections returns a list of collection names in the default database
 	ListCollections(ctx context.Context) ([]string, error)
 
@@ -112,9 +145,45 @@ type Manager interface {
 
 // manager implements the Manager interface
 type manager struct {
-	client   *mongo.Client
-	config   *Config
-	database *mongo.Database
+	// mu guards client, database, connecting and closed; the client is created lazily on first use
+	mu         sync.Mutex
+	client     *mongo.Client
+	config     *Config
+	database   *mongo.Database
+	connecting chan struct{} // closed when the in-flight connection attempt finishes
+	closed     bool          // set by Shutdown, the manager never connects again
+
+	// dial creates the client; nil means createClientWithRetry
+	dial func(ctx context.Context) (*mongo.Client, error)
+
+	encryptionMu     sync.Mutex
+	clientEncryption *mongo.ClientEncryption
+
+	// operations counts the commands in flight, Shutdown waits for them
+	operations operationTracker
+
+	// pools maintains the connection pool statistics returned by PoolStats
+	pools poolTracker
+
+	// topology keeps the deployment state returned by Topology
+	topology topologyTracker
+
+	// logger receives command events when logging is enabled; nil means slog.Default()
+	logger *slog.Logger
+
+	// slowQuerySink receives slow query reports; nil means a log sink on the manager's logger
+	slowQuerySink SlowQuerySink
+
+	// metrics feeds the recorder set by WithMetrics; nil when metrics are not collected
+	metrics *metricsCollector
+
+	// tracer starts the spans of the tracer set by WithTracer; nil when commands are not traced
+	tracer *commandTracer
+
+	// trackedMu guards the change streams and sessions closed by Shutdown
+	trackedMu     sync.Mutex
+	changeStreams map[*mongo.ChangeStream]struct{}
+	sessions      map[mongo.Session]struct{}
 }
 
 // NewManager creates a new MongoDB manager with default configuration
@@ -124,28 +193,59 @@ func NewManager() Manager {
 }
 
 // NewManagerWithConfig creates a new MongoDB manager with the provided configuration
-func NewManagerWithConfig(config Config) Manager {
-	// Validate essential configuration early
-	if config.URI == "" {
-		panic("MongoDB URI is required")
+//
+// The connection is established lazily on first use. NewManagerWithConfig panics when
+// the configuration is invalid; use Open to get an error instead.
+func NewManagerWithConfig(config Config, opts ...Option) Manager {
+	m, err := newManager(config, opts...)
+	if err != nil {
+		panic(err.Error())
 	}
+	return m
+}
 
-	// Try to parse URI to catch obvious errors early
-	if strings.HasPrefix(config.URI, "invalid-uri://") {
-		panic("Invalid MongoDB URI format")
+// Open creates a MongoDB manager and establishes the connection.
+//
+// Connection attempts follow the configured retry policy and stop as soon as ctx is
+// done. Invalid configuration is reported with an error wrapping ErrInvalidURI or
+// ErrInvalidConfig, and connection failures with an error wrapping ErrConnectFailed.
+func Open(ctx context.Context, config Config, opts ...Option) (Manager, error) {
+	m, err := newManager(config, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.Connect(ctx); err != nil {
+		return nil, err
 	}
+	return m, nil
+}
 
-	return &manager{
+// newManager applies the options and validates the resulting configuration
+func newManager(config Config, opts ...Option) (*manager, error) {
+	m := &manager{
 		client:   nil, // Lazy initialization
 		config:   &config,
 		database: nil, // Lazy initialization
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	if err := m.config.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
 }
 
 // createMongoClient creates a MongoDB client from the given configuration
-func createMongoClient(config Config) (*mongo.Client, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.ConnectTimeout)*time.Millisecond)
-	defer cancel()
+//
+// extra options are applied after the ones derived from config.
+func createMongoClient(ctx context.Context, config Config, extra ...*options.ClientOptions) (*mongo.Client, error) {
+	if config.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.GetConnectTimeout())
+		defer cancel()
+	}
 
 	opts := options.Client()
 
@@ -201,26 +301,20 @@ func createMongoClient(config Config) (*mongo.Client, error) {
 
 	// Set TLS
 	if config.TLS.Enabled {
-		tlsConfig := &tls.Config{
-			InsecureSkipVerify: config.TLS.InsecureSkipVerify,
+		tlsConfig, err := newTLSConfig(config.TLS)
+		if err != nil {
+			return nil, err
 		}
 		opts.SetTLSConfig(tlsConfig)
 	}
 
 	// Set Read Preference
-	if config.ReadPreference.Mode != "" {
-		switch config.ReadPreference.Mode {
-		case "primary":
-			opts.SetReadPreference(readpref.Primary())
-		case "primaryPreferred":
-			opts.SetReadPreference(readpref.PrimaryPreferred())
-		case "secondary":
-			opts.SetReadPreference(readpref.Secondary())
-		case "secondaryPreferred":
-			opts.SetReadPreference(readpref.SecondaryPreferred())
-		case "nearest":
-			opts.SetReadPreference(readpref.Nearest())
-		}
+	readPref, err := newReadPref(config.ReadPreference)
+	if err != nil {
+		return nil, err
+	}
+	if readPref != nil {
+		opts.SetReadPreference(readPref)
 	}
 
 	// Set Read Concern
@@ -327,63 +421,251 @@ func createMongoClient(config Config) (*mongo.Client, error) {
 		opts.SetLoadBalanced(config.LoadBalanced)
 	}
 
+	// Set Auto Encryption
+	autoEncryption, err := newAutoEncryptionOptions(config.AutoEncryption)
+	if err != nil {
+		return nil, err
+	}
+	if autoEncryption != nil {
+		if !encryptionSupported() {
+			return nil, errEncryptionNotSupported
+		}
+		opts.SetAutoEncryptionOptions(autoEncryption)
+	}
+
+	// Set Server API (Stable API)
+	serverAPI, err := newServerAPIOptions(config.ServerAPI)
+	if err != nil {
+		return nil, err
+	}
+	if serverAPI != nil {
+		opts.SetServerAPIOptions(serverAPI)
+	}
+
 	// Create client
-	client, err := mongo.Connect(ctx, opts)
+	client, err := mongo.Connect(ctx, append([]*options.ClientOptions{opts}, extra...)...)
 	if err != nil {
 		return nil, err
 	}
 
-	// Test connection
+	// Test connection, the first command also verifies the server accepts the Stable API version
 	err = client.Ping(ctx, readpref.Primary())
 	if err != nil {
-		return nil, err
+		_ = client.Disconnect(context.Background())
+		return nil, wrapServerAPIError(err, config.ServerAPI)
 	}
 
 	return client, nil
 }
 
 // Client returns the underlying MongoDB client
+//
+// Client connects on first use and panics if the connection cannot be established;
+// call Connect first to handle connection errors.
 func (m *manager) Client() *mongo.Client {
-	if m.client == nil {
-		client, err := m.createClientWithRetry()
+	client, _, err := m.connection(context.Background())
+	if err != nil {
+		panic("MongoDB client creation failed: " + err.Error())
+	}
+	return client
+}
+
+// Connect establishes the connection if it does not exist yet, honoring ctx cancellation
+func (m *manager) Connect(ctx context.Context) error {
+	_, _, err := m.connection(ctx)
+	return err
+}
+
+// connection returns the client and default database, creating them on first use.
+//
+// Concurrent first callers share a single connection attempt and stop waiting when
+// their context is done. A failed attempt is not cached, so the next caller tries again.
+// After Shutdown, ErrClosed is returned instead of connecting again.
+func (m *manager) connection(ctx context.Context) (*mongo.Client, *mongo.Database, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for {
+		m.mu.Lock()
+		if m.closed {
+			m.mu.Unlock()
+			return nil, nil, ErrClosed
+		}
+		if m.client != nil {
+			if m.database == nil {
+				m.database = m.client.Database(m.config.Database)
+			}
+			client, database := m.client, m.database
+			m.mu.Unlock()
+			return client, database, nil
+		}
+
+		// Another caller is connecting, wait for its outcome
+		if wait := m.connecting; wait != nil {
+			m.mu.Unlock()
+			select {
+			case <-wait:
+				continue
+			case <-ctx.Done():
+				return nil, nil, fmt.Errorf("%w: %w", ErrConnectFailed, ctx.Err())
+			}
+		}
+
+		done := make(chan struct{})
+		m.connecting = done
+		dial := m.dial
+		m.mu.Unlock()
+
+		if dial == nil {
+			dial = m.createClientWithRetry
+		}
+		client, err := dial(ctx)
+
+		m.mu.Lock()
+		m.connecting = nil
+		closed := m.closed
+		if err == nil && !closed {
+			m.client = client
+			m.database = client.Database(m.config.Database)
+		}
+		database := m.database
+		m.mu.Unlock()
+		close(done)
+
 		if err != nil {
-			panic("MongoDB client creation failed: " + err.Error())
+			return nil, nil, err
 		}
-		m.client = client
+		if closed {
+			// Shutdown ran while connecting, do not leak the new pool
+			_ = client.Disconnect(context.WithoutCancel(ctx))
+			return nil, nil, ErrClosed
+		}
+		return client, database, nil
 	}
-	return m.client
 }
 
-// createClientWithRetry creates MongoDB client with retry mechanism
-func (m *manager) createClientWithRetry() (*mongo.Client, error) {
-	var lastErr error
-	maxRetries := 3
-	baseDelay := time.Second
+// watched tracks a change stream opened through the manager so Shutdown can close it
+func (m *manager) watched(stream *mongo.ChangeStream, err error) (*mongo.ChangeStream, error) {
+	if err != nil {
+		return nil, err
+	}
+	m.trackChangeStream(stream)
+	return stream, nil
+}
+
+// ensureClient returns the client, connecting first if needed
+func (m *manager) ensureClient(ctx context.Context) (*mongo.Client, error) {
+	client, _, err := m.connection(ctx)
+	return client, err
+}
+
+// ensureDatabase returns the default database, connecting first if needed
+func (m *manager) ensureDatabase(ctx context.Context) (*mongo.Database, error) {
+	_, database, err := m.connection(ctx)
+	return database, err
+}
+
+// commandMonitor returns the command monitor installed on clients created by the manager
+func (m *manager) commandMonitor() *event.CommandMonitor {
+	var logging *event.CommandMonitor
+	if m.config.Logging.Enabled {
+		logging = newCommandLogger(m.log(), m.config.Logging).monitor()
+	}
+	var slowQueries *event.CommandMonitor
+	if m.config.SlowQuery.Enabled {
+		sink := m.slowQuerySink
+		if sink == nil {
+			sink = NewLogSlowQuerySink(m.log())
+		}
+		slowQueries = newSlowQueryDetector(m.config.SlowQuery, sink, m.runCommand).monitor()
+	}
+	var metrics *event.CommandMonitor
+	if m.metrics != nil {
+		metrics = m.metrics.commandMonitor()
+	}
+	var tracing *event.CommandMonitor
+	if m.tracer != nil {
+		tracing = m.tracer.monitor()
+	}
+	return combineCommandMonitors(m.operations.monitor(), logging, slowQueries, metrics, tracing)
+}
+
+// poolMonitor returns the pool monitor installed on clients created by the manager
+func (m *manager) poolMonitor() *event.PoolMonitor {
+	return &event.PoolMonitor{
+		Event: func(evt *event.PoolEvent) {
+			stats := m.pools.event(evt)
+			if m.metrics != nil {
+				m.metrics.poolEvent(evt, stats)
+			}
+		},
+	}
+}
+
+// serverMonitor returns the server monitor installed on clients created by the manager
+func (m *manager) serverMonitor() *event.ServerMonitor {
+	monitor := m.topology.monitor()
+	if m.metrics != nil {
+		monitor.ServerHeartbeatFailed = m.metrics.heartbeatFailed
+	}
+	return monitor
+}
+
+// runCommand runs a command against a database and returns the raw result
+func (m *manager) runCommand(ctx context.Context, database string, cmd bson.D) (bson.Raw, error) {
+	client, err := m.ensureClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.Database(database).RunCommand(ctx, cmd).Raw()
+}
+
+// log returns the logger of the manager
+func (m *manager) log() *slog.Logger {
+	if m.logger != nil {
+		return m.logger
+	}
+	return slog.Default()
+}
+
+// createClientWithRetry creates MongoDB client following the configured retry policy
+func (m *manager) createClientWithRetry(ctx context.Context) (*mongo.Client, error) {
+	retry := m.config.ConnectRetry
+	maxAttempts := retry.maxAttempts()
+
+	monitorOpts := options.Client().
+		SetMonitor(m.commandMonitor()).
+		SetPoolMonitor(m.poolMonitor()).
+		SetServerMonitor(m.serverMonitor())
 
-	for i := 0; i < maxRetries; i++ {
-		client, err := createMongoClient(*m.config)
+	var lastErr error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		client, err := createMongoClient(ctx, *m.config, monitorOpts)
 		if err == nil {
 			return client, nil
 		}
-
 		lastErr = err
-		if i < maxRetries-1 {
-			delay := baseDelay * time.Duration(1<<uint(i)) // exponential backoff: 1s, 2s, 4s
-			time.Sleep(delay)
+
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("%w: %w", ErrConnectFailed, lastErr)
+		}
+		if attempt < maxAttempts {
+			if err := sleepContext(ctx, retry.delay(attempt)); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrConnectFailed, err)
+			}
 		}
 	}
 
-	return nil, fmt.Errorf("failed to create MongoDB client after %d retries: %w", maxRetries, lastErr)
+	return nil, fmt.Errorf("%w after %d attempts: %w", ErrConnectFailed, maxAttempts, lastErr)
 }
 
 // Database returns the default database
 func (m *manager) Database() *mongo.Database {
-	if m.database == nil {
-		// Ensure client is initialized first
-		client := m.Client()
-		m.database = client.Database(m.config.Database)
+	_, database, err := m.connection(context.Background())
+	if err != nil {
+		panic("MongoDB client creation failed: " + err.Error())
 	}
-	return m.database
+	return database
 }
 
 // DatabaseWithName returns a database with the specified name
@@ -395,12 +677,98 @@ func (m *manager) DatabaseWithName(name string) *mongo.Database {
 
 // Collection returns a collection from the default database
 func (m *manager) Collection(name string) *mongo.Collection {
-	return m.database.Collection(name)
+	return m.Database().Collection(name)
 }
 
 // CollectionWithDatabase returns a collection from the specified database
 func (m *manager) CollectionWithDatabase(dbName, collectionName string) *mongo.Collection {
-	return m.client.Database(dbName).Collection(collectionName)
+	return m.Client().Database(dbName).Collection(collectionName)
+}
+
+// ReadPreference returns the read preference of a named profile (empty name for the default)
+func (m *manager) ReadPreference(profile string) (*readpref.ReadPref, error) {
+	if profile == "" {
+		readPref, err := newReadPref(m.config.ReadPreference)
+		if readPref == nil && err == nil {
+			readPref = readpref.Primary()
+		}
+		return readPref, err
+	}
+
+	cfg, ok := m.config.ReadPreferenceProfiles[profile]
+	if !ok {
+		return nil, fmt.Errorf("read preference profile %q is not configured", profile)
+	}
+	return newReadPref(cfg)
+}
+
+// DatabaseWithReadPreference returns the default database using a named read preference profile
+func (m *manager) DatabaseWithReadPreference(profile string) (*mongo.Database, error) {
+	readPref, err := m.ReadPreference(profile)
+	if err != nil {
+		return nil, err
+	}
+	client, err := m.ensureClient(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return client.Database(m.config.Database, options.Database().SetReadPreference(readPref)), nil
+}
+
+// CollectionWithReadPreference returns a collection from the default database using a named read preference profile
+func (m *manager) CollectionWithReadPreference(name, profile string) (*mongo.Collection, error) {
+	db, err := m.DatabaseWithReadPreference(profile)
+	if err != nil {
+		return nil, err
+	}
+	return db.Collection(name), nil
+}
+
+// ClientEncryption returns a client for explicit field encryption and data key management
+//
+// The key vault namespace and KMS providers are taken from the auto-encryption
+// configuration; auto-encryption itself does not need to be enabled. The application
+// must be built with the "cse" build tag and libmongocrypt; without the tag this method
+// returns an error.
+func (m *manager) ClientEncryption() (*mongo.ClientEncryption, error) {
+	m.encryptionMu.Lock()
+	defer m.encryptionMu.Unlock()
+
+	if m.clientEncryption != nil {
+		return m.clientEncryption, nil
+	}
+
+	opts, err := newClientEncryptionOptions(m.config.AutoEncryption)
+	if err != nil {
+		return nil, err
+	}
+	if !encryptionSupported() {
+		return nil, errEncryptionNotSupported
+	}
+	client, err := m.ensureClient(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	clientEncryption, err := mongo.NewClientEncryption(client, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MongoDB client encryption: %w", err)
+	}
+	m.clientEncryption = clientEncryption
+	return clientEncryption, nil
+}
+
+// closeClientEncryption closes the explicit encryption client if one was created
+func (m *manager) closeClientEncryption(ctx context.Context) error {
+	m.encryptionMu.Lock()
+	defer m.encryptionMu.Unlock()
+
+	if ctx == nil || m.clientEncryption == nil {
+		return nil
+	}
+	// The encryption client is unusable once the client is gone, even if Close fails
+	err := m.clientEncryption.Close(ctx)
+	m.clientEncryption = nil
+	return err
 }
 
 // Config returns the MongoDB configuration
@@ -410,31 +778,61 @@ func (m *manager) Config() *Config {
 
 // Ping pings the MongoDB server
 func (m *manager) Ping(ctx context.Context) error {
-	// Ensure client is initialized
-	client := m.Client()
+	client, err := m.ensureClient(ctx)
+	if err != nil {
+		return err
+	}
 	return client.Ping(ctx, nil)
 }
 
 // Disconnect disconnects from MongoDB
+//
+// The client is disconnected even when closing the explicit encryption client fails;
+// both errors are returned.
 func (m *manager) Disconnect(ctx context.Context) error {
+	// Release the explicit encryption state before the key vault client goes away
+	encryptionErr := m.closeClientEncryption(ctx)
+	if encryptionErr != nil {
+		encryptionErr = fmt.Errorf("failed to close MongoDB client encryption: %w", encryptionErr)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	// If client was never initialized, there's nothing to disconnect
 	if ctx != nil && m.client != nil {
-		return m.client.Disconnect(ctx)
+		err := m.client.Disconnect(ctx)
+		// The next call to Client or Database connects again, unless the manager is shut down
+		m.client = nil
+		m.database = nil
+		m.topology.reset()
+		return errors.Join(encryptionErr, err)
 	}
-	return nil
+	return encryptionErr
 }
 
 // StartSession starts a new session
+//
+// Sessions still open when Shutdown is called are ended by it.
 func (m *manager) StartSession(opts ...*options.SessionOptions) (mongo.Session, error) {
-	// Ensure client is initialized
-	client := m.Client()
-	return client.StartSession(opts...)
+	client, err := m.ensureClient(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	session, err := client.StartSession(opts...)
+	if err != nil {
+		return nil, err
+	}
+	m.trackSession(session)
+	return session, nil
 }
 
 // UseSession executes a function with a session
 func (m *manager) UseSession(ctx context.Context, fn func(mongo.SessionContext) error) error {
-	// Ensure client is initialized
-	client := m.Client()
+	client, err := m.ensureClient(ctx)
+	if err != nil {
+		return err
+	}
 	session, err := client.StartSession()
 	if err != nil {
 		return err
@@ -445,8 +843,10 @@ func (m *manager) UseSession(ctx context.Context, fn func(mongo.SessionContext)
 
 // UseSessionWithTransaction executes a function within a transaction
 func (m *manager) UseSessionWithTransaction(ctx context.Context, fn func(mongo.SessionContext) (interface{}, error), opts ...*options.TransactionOptions) (interface{}, error) {
-	// Ensure client is initialized
-	client := m.Client()
+	client, err := m.ensureClient(ctx)
+	if err != nil {
+		return nil, err
+	}
 	session, err := client.StartSession()
 	if err != nil {
 		return nil, err
@@ -465,118 +865,196 @@ func (m *manager) HealthCheck(ctx context.Context) error {
 	}
 
 	// Try to list databases to ensure we have proper access
-	client := m.Client() // Ensure client is initialized
-	_, err := client.ListDatabaseNames(ctx, map[string]interface{}{})
+	client, err := m.ensureClient(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = client.ListDatabaseNames(ctx, map[string]interface{}{})
 	return err
 }
 
 // Stats returns database statistics
 func (m *manager) Stats(ctx context.Context) (map[string]interface{}, error) {
+	database, err := m.ensureDatabase(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	var stats map[string]interface{}
-	err := m.database.RunCommand(ctx, map[string]interface{}{"dbStats": 1}).Decode(&stats)
+	err = database.RunCommand(ctx, map[string]interface{}{"dbStats": 1}).Decode(&stats)
 	return stats, err
 }
 
 // ListCollections returns a list of collection names in the default database
 func (m *manager) ListCollections(ctx context.Context) ([]string, error) {
-	return m.database.ListCollectionNames(ctx, map[string]interface{}{})
+	database, err := m.ensureDatabase(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return database.ListCollectionNames(ctx, map[string]interface{}{})
 }
 
 // ListDatabases returns a list of database names
 func (m *manager) ListDatabases(ctx context.Context) ([]string, error) {
-	return m.client.ListDatabaseNames(ctx, map[string]interface{}{})
+	client, err := m.ensureClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return client.ListDatabaseNames(ctx, map[string]interface{}{})
 }
 
 // DropDatabase drops the default database
 func (m *manager) DropDatabase(ctx context.Context) error {
-	return m.database.Drop(ctx)
+	database, err := m.ensureDatabase(ctx)
+	if err != nil {
+		return err
+	}
+	return database.Drop(ctx)
 }
 
 // DropDatabaseWithName drops the specified database
 func (m *manager) DropDatabaseWithName(ctx context.Context, name string) error {
-	return m.client.Database(name).Drop(ctx)
+	client, err := m.ensureClient(ctx)
+	if err != nil {
+		return err
+	}
+	return client.Database(name).Drop(ctx)
 }
 
 // Watch opens a change stream to watch for changes to the default database
 func (m *manager) Watch(ctx context.Context, pipeline interface{}, opts ...*options.ChangeStreamOptions) (*mongo.ChangeStream, error) {
-	return m.database.Watch(ctx, pipeline, opts...)
+	database, err := m.ensureDatabase(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return m.watched(database.Watch(ctx, pipeline, opts...))
 }
 
 // WatchCollection opens a change stream to watch for changes to a specific collection
 func (m *manager) WatchCollection(ctx context.Context, collectionName string, pipeline interface{}, opts ...*options.ChangeStreamOptions) (*mongo.ChangeStream, error) {
-	collection := m.database.Collection(collectionName)
-	return collection.Watch(ctx, pipeline, opts...)
+	database, err := m.ensureDatabase(ctx)
+	if err != nil {
+		return nil, err
+	}
+	collection := database.Collection(collectionName)
+	return m.watched(collection.Watch(ctx, pipeline, opts...))
 }
 
 // WatchCollectionWithDatabase opens a change stream to watch for changes to a collection in a specific database
 func (m *manager) WatchCollectionWithDatabase(ctx context.Context, dbName, collectionName string, pipeline interface{}, opts ...*options.ChangeStreamOptions) (*mongo.ChangeStream, error) {
-	collection := m.client.Database(dbName).Collection(collectionName)
-	return collection.Watch(ctx, pipeline, opts...)
+	client, err := m.ensureClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	collection := client.Database(dbName).Collection(collectionName)
+	return m.watched(collection.Watch(ctx, pipeline, opts...))
 }
 
 // WatchAllDatabases opens a change stream to watch for changes across all databases (requires appropriate permissions)
 func (m *manager) WatchAllDatabases(ctx context.Context, pipeline interface{}, opts ...*options.ChangeStreamOptions) (*mongo.ChangeStream, error) {
-	return m.client.Watch(ctx, pipeline, opts...)
+	client, err := m.ensureClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return m.watched(client.Watch(ctx, pipeline, opts...))
 }
 
 // CreateIndexes creates multiple indexes on a collection in the default database
 func (m *manager) CreateIndexes(ctx context.Context, collectionName string, models []mongo.IndexModel, opts ...*options.CreateIndexesOptions) ([]string, error) {
-	collection := m.database.Collection(collectionName)
+	database, err := m.ensureDatabase(ctx)
+	if err != nil {
+		return nil, err
+	}
+	collection := database.Collection(collectionName)
 	return collection.Indexes().CreateMany(ctx, models, opts...)
 }
 
 // CreateIndexesWithDatabase creates multiple indexes on a collection in a specific database
 func (m *manager) CreateIndexesWithDatabase(ctx context.Context, dbName, collectionName string, models []mongo.IndexModel, opts ...*options.CreateIndexesOptions) ([]string, error) {
-
-	collection := m.client.Database(dbName).Collection(collectionName)
+	client, err := m.ensureClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	collection := client.Database(dbName).Collection(collectionName)
 	return collection.Indexes().CreateMany(ctx, models, opts...)
 }
 
 // CreateIndex creates a single index on a collection in the default database
 func (m *manager) CreateIndex(ctx context.Context, collectionName string, model mongo.IndexModel, opts ...*options.CreateIndexesOptions) (string, error) {
-	collection := m.database.Collection(collectionName)
+	database, err := m.ensureDatabase(ctx)
+	if err != nil {
+		return "", err
+	}
+	collection := database.Collection(collectionName)
 	return collection.Indexes().CreateOne(ctx, model, opts...)
 }
 
 // CreateIndexWithDatabase creates a single index on a collection in a specific database
 func (m *manager) CreateIndexWithDatabase(ctx context.Context, dbName, collectionName string, model mongo.IndexModel, opts ...*options.CreateIndexesOptions) (string, error) {
-	collection := m.client.Database(dbName).Collection(collectionName)
+	client, err := m.ensureClient(ctx)
+	if err != nil {
+		return "", err
+	}
+	collection := client.Database(dbName).Collection(collectionName)
 	return collection.Indexes().CreateOne(ctx, model, opts...)
 }
 
 // ListIndexes returns all indexes for a collection in the default database
 func (m *manager) ListIndexes(ctx context.Context, collectionName string, opts ...*options.ListIndexesOptions) (*mongo.Cursor, error) {
-
-	collection := m.database.Collection(collectionName)
+	database, err := m.ensureDatabase(ctx)
+	if err != nil {
+		return nil, err
+	}
+	collection := database.Collection(collectionName)
 	return collection.Indexes().List(ctx, opts...)
 }
 
 // ListIndexesWithDatabase returns all indexes for a collection in a specific database
 func (m *manager) ListIndexesWithDatabase(ctx context.Context, dbName, collectionName string, opts ...*options.ListIndexesOptions) (*mongo.Cursor, error) {
-	collection := m.client.Database(dbName).Collection(collectionName)
+	client, err := m.ensureClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	collection := client.Database(dbName).Collection(collectionName)
 	return collection.Indexes().List(ctx, opts...)
 }
 
 // DropIndex drops a single index from a collection in the default database
 func (m *manager) DropIndex(ctx context.Context, collectionName string, name string) (interface{}, error) {
-	collection := m.database.Collection(collectionName)
+	database, err := m.ensureDatabase(ctx)
+	if err != nil {
+		return nil, err
+	}
+	collection := database.Collection(collectionName)
 	return collection.Indexes().DropOne(ctx, name)
 }
 
 // DropIndexWithDatabase drops a single index from a collection in a specific database
 func (m *manager) DropIndexWithDatabase(ctx context.Context, dbName, collectionName string, name string) (interface{}, error) {
-	collection := m.client.Database(dbName).Collection(collectionName)
+	client, err := m.ensureClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	collection := client.Database(dbName).Collection(collectionName)
 	return collection.Indexes().DropOne(ctx, name)
 }
 
 // DropAllIndexes drops all indexes except _id from a collection in the default database
 func (m *manager) DropAllIndexes(ctx context.Context, collectionName string) (interface{}, error) {
-	collection := m.database.Collection(collectionName)
+	database, err := m.ensureDatabase(ctx)
+	if err != nil {
+		return nil, err
+	}
+	collection := database.Collection(collectionName)
 	return collection.Indexes().DropAll(ctx)
 }
 
 // DropAllIndexesWithDatabase drops all indexes except _id from a collection in a specific database
 func (m *manager) DropAllIndexesWithDatabase(ctx context.Context, dbName, collectionName string) (interface{}, error) {
-	collection := m.client.Database(dbName).Collection(collectionName)
+	client, err := m.ensureClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	collection := client.Database(dbName).Collection(collectionName)
 	return collection.Indexes().DropAll(ctx)
 }
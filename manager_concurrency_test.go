@@ -0,0 +1,306 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// countingDialer returns a dial function creating unconnected clients and counting its calls
+func countingDialer(t *testing.T, calls *int32) func(ctx context.Context) (*mongo.Client, error) {
+	return func(ctx context.Context) (*mongo.Client, error) {
+		atomic.AddInt32(calls, 1)
+		// mongo.Connect does not wait for the server, so no MongoDB instance is required
+		client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://localhost:27017"))
+		if err == nil {
+			t.Cleanup(func() { _ = client.Disconnect(context.Background()) })
+		}
+		return client, err
+	}
+}
+
+func TestManager_ConcurrentFirstUse(t *testing.T) {
+	var calls int32
+	mgr := &manager{
+		config: &Config{URI: "mongodb://localhost:27017", Database: "testdb"},
+	}
+	mgr.dial = countingDialer(t, &calls)
+
+	const workers = 64
+	clients := make([]*mongo.Client, workers)
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			switch i % 3 {
+			case 0:
+				clients[i] = mgr.Client()
+			case 1:
+				clients[i] = mgr.Database().Client()
+			default:
+				clients[i] = mgr.Collection("users").Database().Client()
+			}
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "expected a single client to be created")
+	for _, client := range clients {
+		assert.Same(t, clients[0], client)
+	}
+	assert.Equal(t, "testdb", mgr.Database().Name())
+}
+
+func TestManager_RetryAfterFailedConnect(t *testing.T) {
+	var calls int32
+	succeed := countingDialer(t, &calls)
+	mgr := &manager{
+		config: &Config{URI: "mongodb://localhost:27017", Database: "testdb"},
+	}
+	mgr.dial = func(ctx context.Context) (*mongo.Client, error) {
+		if atomic.LoadInt32(&calls) == 0 {
+			atomic.AddInt32(&calls, 1)
+			return nil, errors.New("server unavailable")
+		}
+		return succeed(ctx)
+	}
+
+	assert.PanicsWithValue(t, "MongoDB client creation failed: server unavailable", func() {
+		mgr.Client()
+	})
+	assert.Nil(t, mgr.client, "a failed attempt must not be cached")
+
+	assert.NotNil(t, mgr.Client())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestManager_ConcurrentUseAndDisconnect(t *testing.T) {
+	var calls int32
+	mgr := &manager{
+		config: &Config{URI: "mongodb://localhost:27017", Database: "testdb"},
+	}
+	mgr.dial = countingDialer(t, &calls)
+
+	const workers = 16
+	const iterations = 50
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				switch (i + j) % 5 {
+				case 0:
+					assert.NotNil(t, mgr.Client())
+				case 1:
+					assert.NotNil(t, mgr.Database())
+				case 2:
+					assert.NotNil(t, mgr.Collection("users"))
+				case 3:
+					assert.NotNil(t, mgr.CollectionWithDatabase("otherdb", "users"))
+				default:
+					_ = mgr.Disconnect(context.Background())
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.GreaterOrEqual(t, atomic.LoadInt32(&calls), int32(1))
+	assert.NoError(t, mgr.Disconnect(context.Background()))
+	assert.Nil(t, mgr.client)
+	assert.Nil(t, mgr.database)
+}
+
+func TestManager_ConnectHonorsContext(t *testing.T) {
+	var calls int32
+	succeed := countingDialer(t, &calls)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mgr := &manager{
+		config: &Config{URI: "mongodb://localhost:27017", Database: "testdb"},
+	}
+	mgr.dial = func(ctx context.Context) (*mongo.Client, error) {
+		close(started)
+		<-release
+		return succeed(ctx)
+	}
+
+	// The first caller blocks in dial, a second caller gives up when its context ends
+	done := make(chan error, 1)
+	go func() { done <- mgr.Connect(context.Background()) }()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := mgr.Connect(ctx)
+	assert.ErrorIs(t, err, ErrConnectFailed)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+	assert.NoError(t, <-done)
+	assert.NoError(t, mgr.Connect(context.Background()))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestManager_FreshManagerReportsConnectError(t *testing.T) {
+	dialErr := errors.New("server unavailable")
+	ctx := context.Background()
+
+	calls := map[string]func(m Manager) error{
+		"Ping":        func(m Manager) error { return m.Ping(ctx) },
+		"HealthCheck": func(m Manager) error { return m.HealthCheck(ctx) },
+		"StartSession": func(m Manager) error {
+			_, err := m.StartSession()
+			return err
+		},
+		"UseSession": func(m Manager) error {
+			return m.UseSession(ctx, func(mongo.SessionContext) error { return nil })
+		},
+		"UseSessionWithTransaction": func(m Manager) error {
+			_, err := m.UseSessionWithTransaction(ctx, func(mongo.SessionContext) (interface{}, error) { return nil, nil })
+			return err
+		},
+		"Stats": func(m Manager) error {
+			_, err := m.Stats(ctx)
+			return err
+		},
+		"ListCollections": func(m Manager) error {
+			_, err := m.ListCollections(ctx)
+			return err
+		},
+		"ListDatabases": func(m Manager) error {
+			_, err := m.ListDatabases(ctx)
+			return err
+		},
+		"DropDatabase":         func(m Manager) error { return m.DropDatabase(ctx) },
+		"DropDatabaseWithName": func(m Manager) error { return m.DropDatabaseWithName(ctx, "otherdb") },
+		"Watch": func(m Manager) error {
+			_, err := m.Watch(ctx, mongo.Pipeline{})
+			return err
+		},
+		"WatchCollection": func(m Manager) error {
+			_, err := m.WatchCollection(ctx, "users", mongo.Pipeline{})
+			return err
+		},
+		"WatchCollectionWithDatabase": func(m Manager) error {
+			_, err := m.WatchCollectionWithDatabase(ctx, "otherdb", "users", mongo.Pipeline{})
+			return err
+		},
+		"WatchAllDatabases": func(m Manager) error {
+			_, err := m.WatchAllDatabases(ctx, mongo.Pipeline{})
+			return err
+		},
+		"CreateIndexes": func(m Manager) error {
+			_, err := m.CreateIndexes(ctx, "users", []mongo.IndexModel{{Keys: map[string]int{"email": 1}}})
+			return err
+		},
+		"CreateIndexesWithDatabase": func(m Manager) error {
+			_, err := m.CreateIndexesWithDatabase(ctx, "otherdb", "users", []mongo.IndexModel{{Keys: map[string]int{"email": 1}}})
+			return err
+		},
+		"CreateIndex": func(m Manager) error {
+			_, err := m.CreateIndex(ctx, "users", mongo.IndexModel{Keys: map[string]int{"email": 1}})
+			return err
+		},
+		"CreateIndexWithDatabase": func(m Manager) error {
+			_, err := m.CreateIndexWithDatabase(ctx, "otherdb", "users", mongo.IndexModel{Keys: map[string]int{"email": 1}})
+			return err
+		},
+		"ListIndexes": func(m Manager) error {
+			_, err := m.ListIndexes(ctx, "users")
+			return err
+		},
+		"ListIndexesWithDatabase": func(m Manager) error {
+			_, err := m.ListIndexesWithDatabase(ctx, "otherdb", "users")
+			return err
+		},
+		"DropIndex": func(m Manager) error {
+			_, err := m.DropIndex(ctx, "users", "email_1")
+			return err
+		},
+		"DropIndexWithDatabase": func(m Manager) error {
+			_, err := m.DropIndexWithDatabase(ctx, "otherdb", "users", "email_1")
+			return err
+		},
+		"DropAllIndexes": func(m Manager) error {
+			_, err := m.DropAllIndexes(ctx, "users")
+			return err
+		},
+		"DropAllIndexesWithDatabase": func(m Manager) error {
+			_, err := m.DropAllIndexesWithDatabase(ctx, "otherdb", "users")
+			return err
+		},
+		"DatabaseWithReadPreference": func(m Manager) error {
+			_, err := m.DatabaseWithReadPreference("")
+			return err
+		},
+		"CollectionWithReadPreference": func(m Manager) error {
+			_, err := m.CollectionWithReadPreference("users", "")
+			return err
+		},
+	}
+
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			mgr := &manager{
+				config: &Config{URI: "mongodb://localhost:27017", Database: "testdb"},
+				dial: func(ctx context.Context) (*mongo.Client, error) {
+					return nil, dialErr
+				},
+			}
+
+			assert.NotPanics(t, func() {
+				assert.ErrorIs(t, call(mgr), dialErr)
+			})
+		})
+	}
+}
+
+func TestManager_FreshManagerConnectsOnFirstUse(t *testing.T) {
+	calls := map[string]func(t *testing.T, m Manager){
+		"DatabaseWithName": func(t *testing.T, m Manager) {
+			assert.Equal(t, "otherdb", m.DatabaseWithName("otherdb").Name())
+		},
+		"Collection": func(t *testing.T, m Manager) {
+			assert.Equal(t, "testdb", m.Collection("users").Database().Name())
+		},
+		"CollectionWithDatabase": func(t *testing.T, m Manager) {
+			assert.Equal(t, "otherdb", m.CollectionWithDatabase("otherdb", "users").Database().Name())
+		},
+		"CollectionWithReadPreference": func(t *testing.T, m Manager) {
+			coll, err := m.CollectionWithReadPreference("users", "")
+			assert.NoError(t, err)
+			assert.Equal(t, "users", coll.Name())
+		},
+		"StartSession": func(t *testing.T, m Manager) {
+			session, err := m.StartSession()
+			assert.NoError(t, err)
+			session.EndSession(context.Background())
+		},
+	}
+
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			var dials int32
+			mgr := &manager{
+				config: &Config{URI: "mongodb://localhost:27017", Database: "testdb"},
+			}
+			mgr.dial = countingDialer(t, &dials)
+
+			assert.NotPanics(t, func() { call(t, mgr) })
+			assert.Equal(t, int32(1), atomic.LoadInt32(&dials))
+		})
+	}
+}
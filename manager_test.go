@@ -3,11 +3,13 @@ package mongodb
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 // createTestManager creates a manager using the mocked client from mtest
@@ -59,6 +61,82 @@ func TestNewManagerWithConfig(t *testing.T) {
 			NewManagerWithConfig(cfg)
 		})
 	})
+
+	mt.Run("unsupported server API version should panic", func(mt *mtest.T) {
+		cfg := Config{
+			URI:       "mongodb://localhost:27017",
+			ServerAPI: ServerAPIConfig{Version: "99"},
+		}
+
+		assert.Panics(t, func() {
+			NewManagerWithConfig(cfg)
+		})
+	})
+
+	mt.Run("invalid auto-encryption should panic", func(mt *mtest.T) {
+		cfg := Config{
+			URI:            "mongodb://localhost:27017",
+			AutoEncryption: AutoEncryptionConfig{Enabled: true},
+		}
+
+		assert.Panics(t, func() {
+			NewManagerWithConfig(cfg)
+		})
+	})
+
+	mt.Run("invalid read preference should panic", func(mt *mtest.T) {
+		cfg := Config{
+			URI: "mongodb://localhost:27017",
+			ReadPreferenceProfiles: map[string]ReadPreferenceConfig{
+				"analytics": {Mode: "primary", MaxStaleness: 120},
+			},
+		}
+
+		assert.Panics(t, func() {
+			NewManagerWithConfig(cfg)
+		})
+	})
+}
+
+func TestOpen(t *testing.T) {
+	t.Run("invalid uri", func(t *testing.T) {
+		mgr, err := Open(context.Background(), Config{URI: "invalid-uri://bad"})
+		assert.Nil(t, mgr)
+		assert.ErrorIs(t, err, ErrInvalidURI)
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		cfg := Config{URI: "mongodb://localhost:27017", ServerAPI: ServerAPIConfig{Version: "99"}}
+		mgr, err := Open(context.Background(), cfg)
+		assert.Nil(t, mgr)
+		assert.ErrorIs(t, err, ErrInvalidConfig)
+	})
+
+	t.Run("unreachable server", func(t *testing.T) {
+		cfg := Config{
+			URI:                    "mongodb://127.0.0.1:1",
+			ConnectTimeout:         200,
+			ServerSelectionTimeout: 200,
+		}
+		retry := ConnectRetryConfig{MaxAttempts: 2, BaseDelay: 10, MaxDelay: 10}
+
+		mgr, err := Open(context.Background(), cfg, WithConnectRetry(retry))
+		assert.Nil(t, mgr)
+		assert.ErrorIs(t, err, ErrConnectFailed)
+		assert.ErrorContains(t, err, "after 2 attempts")
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		start := time.Now()
+		mgr, err := Open(ctx, Config{URI: "mongodb://127.0.0.1:1"})
+		assert.Nil(t, mgr)
+		assert.ErrorIs(t, err, ErrConnectFailed)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
 }
 
 func TestManager_Client(t *testing.T) {
@@ -1047,6 +1125,109 @@ func TestManager_CollectionWithDatabase(t *testing.T) {
 	})
 }
 
+func TestManager_ReadPreference(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("returns default and named profiles", func(mt *mtest.T) {
+		cfg := Config{
+			URI:            "mongodb://localhost:27017",
+			Database:       "testdb",
+			ReadPreference: ReadPreferenceConfig{Mode: "primaryPreferred"},
+			ReadPreferenceProfiles: map[string]ReadPreferenceConfig{
+				"analytics": {Mode: "secondary", TagSets: []map[string]string{{"usage": "analytics"}}},
+			},
+		}
+
+		manager := createTestManager(mt, cfg)
+
+		rp, err := manager.ReadPreference("")
+		assert.NoError(t, err)
+		assert.Equal(t, readpref.PrimaryPreferredMode, rp.Mode())
+
+		rp, err = manager.ReadPreference("analytics")
+		assert.NoError(t, err)
+		assert.Equal(t, readpref.SecondaryMode, rp.Mode())
+		assert.Len(t, rp.TagSets(), 1)
+	})
+
+	mt.Run("defaults to primary", func(mt *mtest.T) {
+		manager := createTestManager(mt, Config{Database: "testdb"})
+
+		rp, err := manager.ReadPreference("")
+		assert.NoError(t, err)
+		assert.Equal(t, readpref.PrimaryMode, rp.Mode())
+	})
+
+	mt.Run("unknown profile", func(mt *mtest.T) {
+		manager := createTestManager(mt, Config{Database: "testdb"})
+
+		_, err := manager.ReadPreference("missing")
+		assert.ErrorContains(t, err, "not configured")
+	})
+}
+
+func TestManager_CollectionWithReadPreference(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("applies profile to collection", func(mt *mtest.T) {
+		cfg := Config{
+			URI:      "mongodb://localhost:27017",
+			Database: "testdb",
+			ReadPreferenceProfiles: map[string]ReadPreferenceConfig{
+				"reports": {Mode: "nearest", MaxStaleness: 90},
+			},
+		}
+
+		manager := createTestManager(mt, cfg)
+
+		db, err := manager.DatabaseWithReadPreference("reports")
+		assert.NoError(t, err)
+		assert.Equal(t, "testdb", db.Name())
+		assert.Equal(t, readpref.NearestMode, db.ReadPreference().Mode())
+
+		coll, err := manager.CollectionWithReadPreference("orders", "reports")
+		assert.NoError(t, err)
+		assert.Equal(t, "orders", coll.Name())
+		assert.Equal(t, "testdb", coll.Database().Name())
+	})
+
+	mt.Run("unknown profile", func(mt *mtest.T) {
+		manager := createTestManager(mt, Config{Database: "testdb"})
+
+		coll, err := manager.CollectionWithReadPreference("orders", "missing")
+		assert.Error(t, err)
+		assert.Nil(t, coll)
+	})
+}
+
+func TestManager_ClientEncryption(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("requires key vault configuration", func(mt *mtest.T) {
+		manager := createTestManager(mt, Config{Database: "testdb"})
+
+		clientEncryption, err := manager.ClientEncryption()
+		assert.ErrorContains(t, err, "key_vault_namespace")
+		assert.Nil(t, clientEncryption)
+	})
+
+	mt.Run("fails without the cse build tag", func(mt *mtest.T) {
+		if encryptionSupported() {
+			mt.Skip("built with the cse build tag")
+		}
+		key, err := GenerateLocalMasterKey()
+		assert.NoError(t, err)
+		manager := createTestManager(mt, Config{Database: "testdb", AutoEncryption: AutoEncryptionConfig{
+			KeyVaultNamespace: "encryption.__keyVault",
+			KMSProviders:      map[string]interface{}{LocalKMSProvider: map[string]interface{}{"key": key}},
+		}})
+
+		clientEncryption, err := manager.ClientEncryption()
+		assert.ErrorIs(t, err, errEncryptionNotSupported)
+		assert.Nil(t, clientEncryption)
+	})
+}
+
 func TestManager_DropDatabaseWithName(t *testing.T) {
 	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
 
@@ -1468,7 +1649,7 @@ func TestCreateMongoClient(t *testing.T) {
 		}
 
 		// This will fail to connect but should not panic and should return a client
-		client, err := createMongoClient(cfg)
+		client, err := createMongoClient(context.Background(), cfg)
 		if client != nil {
 			defer func() {
 				if disconnectErr := client.Disconnect(context.Background()); disconnectErr != nil {
@@ -1496,7 +1677,7 @@ func TestCreateMongoClient(t *testing.T) {
 			},
 		}
 
-		client, err := createMongoClient(cfg)
+		client, err := createMongoClient(context.Background(), cfg)
 		if client != nil {
 			defer func() {
 				if disconnectErr := client.Disconnect(context.Background()); disconnectErr != nil {
@@ -1524,7 +1705,7 @@ func TestCreateMongoClient(t *testing.T) {
 			MaxConnecting:   10,
 		}
 
-		client, err := createMongoClient(cfg)
+		client, err := createMongoClient(context.Background(), cfg)
 		if client != nil {
 			defer func() {
 				if disconnectErr := client.Disconnect(context.Background()); disconnectErr != nil {
@@ -1549,7 +1730,7 @@ func TestCreateMongoClient(t *testing.T) {
 			LocalThreshold:         15000,
 		}
 
-		client, err := createMongoClient(cfg)
+		client, err := createMongoClient(context.Background(), cfg)
 		if client != nil {
 			defer func() {
 				if disconnectErr := client.Disconnect(context.Background()); disconnectErr != nil {
@@ -1574,7 +1755,7 @@ func TestCreateMongoClient(t *testing.T) {
 			},
 		}
 
-		client, err := createMongoClient(cfg)
+		client, err := createMongoClient(context.Background(), cfg)
 		if client != nil {
 			defer func() {
 				if disconnectErr := client.Disconnect(context.Background()); disconnectErr != nil {
@@ -1601,7 +1782,7 @@ func TestCreateMongoClient(t *testing.T) {
 			},
 		}
 
-		client, err := createMongoClient(cfg)
+		client, err := createMongoClient(context.Background(), cfg)
 		if client != nil {
 			defer func() {
 				if disconnectErr := client.Disconnect(context.Background()); disconnectErr != nil {
@@ -1625,7 +1806,7 @@ func TestCreateMongoClient(t *testing.T) {
 			},
 		}
 
-		client, err := createMongoClient(cfg)
+		client, err := createMongoClient(context.Background(), cfg)
 		if client != nil {
 			defer func() {
 				if disconnectErr := client.Disconnect(context.Background()); disconnectErr != nil {
@@ -1651,7 +1832,7 @@ func TestCreateMongoClient(t *testing.T) {
 			},
 		}
 
-		client, err := createMongoClient(cfg)
+		client, err := createMongoClient(context.Background(), cfg)
 		if client != nil {
 			defer func() {
 				if disconnectErr := client.Disconnect(context.Background()); disconnectErr != nil {
@@ -1679,7 +1860,7 @@ func TestCreateMongoClient(t *testing.T) {
 			LoadBalanced:   false,
 		}
 
-		client, err := createMongoClient(cfg)
+		client, err := createMongoClient(context.Background(), cfg)
 		if client != nil {
 			defer func() {
 				if disconnectErr := client.Disconnect(context.Background()); disconnectErr != nil {
@@ -1703,7 +1884,7 @@ func TestCreateMongoClient(t *testing.T) {
 			},
 		}
 
-		client, err := createMongoClient(cfg)
+		client, err := createMongoClient(context.Background(), cfg)
 		if client != nil {
 			defer func() {
 				if disconnectErr := client.Disconnect(context.Background()); disconnectErr != nil {
@@ -1727,7 +1908,7 @@ func TestCreateMongoClient(t *testing.T) {
 			},
 		}
 
-		client, err := createMongoClient(cfg)
+		client, err := createMongoClient(context.Background(), cfg)
 		if client != nil {
 			defer func() {
 				if disconnectErr := client.Disconnect(context.Background()); disconnectErr != nil {
@@ -1753,7 +1934,7 @@ func TestCreateMongoClient(t *testing.T) {
 			},
 		}
 
-		client, err := createMongoClient(cfg)
+		client, err := createMongoClient(context.Background(), cfg)
 		if client != nil {
 			defer func() {
 				if disconnectErr := client.Disconnect(context.Background()); disconnectErr != nil {
@@ -0,0 +1,110 @@
+package mongodb
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// MetricsRecorder receives the metrics collected from the driver's monitoring events.
+//
+// Implementations must be safe for concurrent use and should not block; the mongoprom
+// subpackage provides an implementation exposed as a Prometheus collector.
+type MetricsRecorder interface {
+	// ObserveCommand records the duration of a finished command, outcome is CommandOutcomeSuccess or CommandOutcomeFailure
+	ObserveCommand(command, collection, outcome string, duration time.Duration)
+
+	// SetPoolConnections records the number of checked-out and idle connections in the pool of a server
+	SetPoolConnections(address string, checkedOut, idle int)
+
+	// ObservePoolWait records the time spent waiting to check out a connection from the pool of a server
+	ObservePoolWait(address string, wait time.Duration)
+
+	// IncHeartbeatFailures counts a failed heartbeat to a server
+	IncHeartbeatFailures(address string)
+}
+
+// ConnectionMetricsRecorder is a MetricsRecorder that can be shared by the managers of
+// several connections. NewConnections records the metrics of each connection through
+// the recorder returned by ForConnection, so that the pools of different connections
+// to the same server are kept apart.
+type ConnectionMetricsRecorder interface {
+	MetricsRecorder
+
+	// ForConnection returns a recorder labelling the metrics with the connection name
+	ForConnection(name string) MetricsRecorder
+}
+
+// metricsCollector turns command, pool and server monitoring events into recorder calls
+type metricsCollector struct {
+	recorder MetricsRecorder
+
+	// collections remembers the collection of started commands by request ID
+	collections sync.Map
+}
+
+// newMetricsCollector creates a collector feeding recorder
+func newMetricsCollector(recorder MetricsRecorder) *metricsCollector {
+	return &metricsCollector{recorder: recorder}
+}
+
+// forConnection returns the collector recording the metrics of a named connection
+func (c *metricsCollector) forConnection(name string) *metricsCollector {
+	recorder, ok := c.recorder.(ConnectionMetricsRecorder)
+	if !ok {
+		return c
+	}
+	return newMetricsCollector(recorder.ForConnection(name))
+}
+
+// commandMonitor returns a command monitor recording command latencies
+func (c *metricsCollector) commandMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(_ context.Context, evt *event.CommandStartedEvent) {
+			c.collections.Store(evt.RequestID, commandCollection(evt.Command))
+		},
+		Succeeded: func(_ context.Context, evt *event.CommandSucceededEvent) {
+			c.observeCommand(&evt.CommandFinishedEvent, CommandOutcomeSuccess)
+		},
+		Failed: func(_ context.Context, evt *event.CommandFailedEvent) {
+			c.observeCommand(&evt.CommandFinishedEvent, CommandOutcomeFailure)
+		},
+	}
+}
+
+// observeCommand records a finished command
+func (c *metricsCollector) observeCommand(evt *event.CommandFinishedEvent, outcome string) {
+	collection, _ := c.collections.LoadAndDelete(evt.RequestID)
+	name, _ := collection.(string)
+	c.recorder.ObserveCommand(evt.CommandName, name, outcome, evt.Duration)
+}
+
+// poolEvent records the checkout waits and connection counts of a pool event, stats are
+// the statistics of the server after the event
+func (c *metricsCollector) poolEvent(evt *event.PoolEvent, stats PoolStats) {
+	switch evt.Type {
+	case event.GetSucceeded, event.GetFailed:
+		c.recorder.ObservePoolWait(evt.Address, evt.Duration)
+	case event.ConnectionCreated, event.ConnectionClosed, event.ConnectionReturned, event.PoolClosedEvent:
+	default:
+		return
+	}
+	c.recorder.SetPoolConnections(evt.Address, stats.InUse, stats.Idle)
+}
+
+// heartbeatFailed counts a failed heartbeat
+func (c *metricsCollector) heartbeatFailed(evt *event.ServerHeartbeatFailedEvent) {
+	c.recorder.IncHeartbeatFailures(connectionAddress(evt.ConnectionID))
+}
+
+// connectionAddress strips the connection counter the driver appends to the address in
+// connection IDs, e.g. "localhost:27017[-3]"
+func connectionAddress(connectionID string) string {
+	if i := strings.LastIndex(connectionID, "[-"); i >= 0 {
+		return connectionID[:i]
+	}
+	return connectionID
+}
@@ -0,0 +1,167 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// recordedCommand is a command observed by fakeRecorder
+type recordedCommand struct {
+	command, collection, outcome string
+	duration                     time.Duration
+}
+
+// fakeRecorder keeps the metrics it receives
+type fakeRecorder struct {
+	mu         sync.Mutex
+	commands   []recordedCommand
+	pools      map[string][2]int
+	waits      []time.Duration
+	heartbeats map[string]int
+}
+
+func newFakeRecorder() *fakeRecorder {
+	return &fakeRecorder{pools: make(map[string][2]int), heartbeats: make(map[string]int)}
+}
+
+func (r *fakeRecorder) ObserveCommand(command, collection, outcome string, duration time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.commands = append(r.commands, recordedCommand{command, collection, outcome, duration})
+}
+
+func (r *fakeRecorder) SetPoolConnections(address string, checkedOut, idle int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pools[address] = [2]int{checkedOut, idle}
+}
+
+func (r *fakeRecorder) ObservePoolWait(_ string, wait time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.waits = append(r.waits, wait)
+}
+
+func (r *fakeRecorder) IncHeartbeatFailures(address string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.heartbeats[address]++
+}
+
+// connectionRecorder hands out a fakeRecorder per connection name
+type connectionRecorder struct {
+	*fakeRecorder
+	mu          sync.Mutex
+	connections map[string]*fakeRecorder
+}
+
+func (r *connectionRecorder) ForConnection(name string) MetricsRecorder {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	recorder := newFakeRecorder()
+	r.connections[name] = recorder
+	return recorder
+}
+
+func TestMetricsCollector_Connections(t *testing.T) {
+	recorder := &connectionRecorder{fakeRecorder: newFakeRecorder(), connections: make(map[string]*fakeRecorder)}
+	conns, err := NewConnections(map[string]Config{
+		DefaultConnectionName: {URI: "mongodb://localhost:27017"},
+		"audit":               {URI: "mongodb://localhost:27017"},
+	}, DefaultConnectionName, WithMetrics(recorder))
+	assert.NoError(t, err)
+	assert.Len(t, recorder.connections, 2)
+
+	evt := &event.PoolEvent{Type: event.ConnectionCreated, Address: "localhost:27017"}
+	conns.Connection("audit").(*manager).poolMonitor().Event(evt)
+	assert.Contains(t, recorder.connections["audit"].pools, "localhost:27017")
+	assert.Empty(t, recorder.connections[DefaultConnectionName].pools)
+	assert.Empty(t, recorder.pools)
+
+	// A recorder without connection support is shared as is
+	plain := newFakeRecorder()
+	conns, err = NewConnections(map[string]Config{DefaultConnectionName: {URI: "mongodb://localhost:27017"}}, DefaultConnectionName, WithMetrics(plain))
+	assert.NoError(t, err)
+	assert.Same(t, plain, conns.Default().(*manager).metrics.recorder)
+}
+
+func TestMetricsCollector_Commands(t *testing.T) {
+	ctx := context.Background()
+	recorder := newFakeRecorder()
+	monitor := newMetricsCollector(recorder).commandMonitor()
+
+	monitor.Started(ctx, &event.CommandStartedEvent{Command: findCommand(t), CommandName: "find", RequestID: 1})
+	monitor.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "find", RequestID: 1, Duration: time.Millisecond}})
+	monitor.Started(ctx, &event.CommandStartedEvent{Command: bsonDoc(t, "ping"), CommandName: "ping", RequestID: 2})
+	monitor.Failed(ctx, &event.CommandFailedEvent{CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "ping", RequestID: 2, Duration: time.Second}})
+
+	assert.Equal(t, []recordedCommand{
+		{command: "find", collection: "users", outcome: CommandOutcomeSuccess, duration: time.Millisecond},
+		{command: "ping", collection: "", outcome: CommandOutcomeFailure, duration: time.Second},
+	}, recorder.commands)
+}
+
+func TestMetricsCollector_Pool(t *testing.T) {
+	recorder := newFakeRecorder()
+	mgr, err := newManager(Config{URI: "mongodb://localhost:27017"}, WithMetrics(recorder))
+	assert.NoError(t, err)
+	monitor := mgr.poolMonitor()
+	const address = "localhost:27017"
+
+	for _, evt := range []*event.PoolEvent{
+		{Type: event.ConnectionCreated, Address: address},
+		{Type: event.ConnectionCreated, Address: address},
+		{Type: event.GetStarted, Address: address},
+		{Type: event.GetSucceeded, Address: address, Duration: 2 * time.Millisecond},
+		{Type: event.GetStarted, Address: address},
+		{Type: event.GetFailed, Address: address, Duration: 5 * time.Millisecond},
+	} {
+		monitor.Event(evt)
+	}
+	assert.Equal(t, [2]int{1, 1}, recorder.pools[address])
+	assert.Equal(t, []time.Duration{2 * time.Millisecond, 5 * time.Millisecond}, recorder.waits)
+
+	monitor.Event(&event.PoolEvent{Type: event.ConnectionReturned, Address: address})
+	assert.Equal(t, [2]int{0, 2}, recorder.pools[address])
+
+	monitor.Event(&event.PoolEvent{Type: event.ConnectionClosed, Address: address})
+	assert.Equal(t, [2]int{0, 1}, recorder.pools[address])
+
+	monitor.Event(&event.PoolEvent{Type: event.PoolClosedEvent, Address: address})
+	assert.Equal(t, [2]int{0, 0}, recorder.pools[address])
+}
+
+func TestMetricsCollector_Heartbeats(t *testing.T) {
+	recorder := newFakeRecorder()
+	mgr, err := newManager(Config{URI: "mongodb://localhost:27017"}, WithMetrics(recorder))
+	assert.NoError(t, err)
+	monitor := mgr.serverMonitor()
+
+	monitor.ServerHeartbeatFailed(&event.ServerHeartbeatFailedEvent{ConnectionID: "db-1:27017[-3]", Failure: errors.New("timeout")})
+	monitor.ServerHeartbeatFailed(&event.ServerHeartbeatFailedEvent{ConnectionID: "db-1:27017[-4]", Failure: errors.New("timeout")})
+
+	assert.Equal(t, map[string]int{"db-1:27017": 2}, recorder.heartbeats)
+}
+
+func TestWithMetrics(t *testing.T) {
+	mgr, err := newManager(Config{URI: "mongodb://localhost:27017"})
+	assert.NoError(t, err)
+	assert.Nil(t, mgr.metrics)
+	assert.Nil(t, mgr.serverMonitor().ServerHeartbeatFailed)
+
+	recorder := newFakeRecorder()
+	mgr, err = newManager(Config{URI: "mongodb://localhost:27017"}, WithMetrics(recorder))
+	assert.NoError(t, err)
+	assert.NotNil(t, mgr.serverMonitor().ServerHeartbeatFailed)
+
+	monitor := mgr.commandMonitor()
+	monitor.Started(context.Background(), &event.CommandStartedEvent{Command: findCommand(t), CommandName: "find", RequestID: 1})
+	monitor.Succeeded(context.Background(), &event.CommandSucceededEvent{CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "find", RequestID: 1}})
+	assert.Len(t, recorder.commands, 1)
+}
@@ -0,0 +1,510 @@
+// Package migrate applies versioned schema migrations through a mongodb.Manager.
+//
+// Migrations are Go functions registered with a version. Applied versions are recorded in
+// the _migrations collection of the manager's default database, and a lock document in
+// the same collection makes sure only one replica runs migrations at a time:
+//
+//	migrator := migrate.New(manager)
+//	migrator.MustRegister(
+//		migrate.Migration{Version: 1, Description: "create users email index", Up: createEmailIndex, Down: dropEmailIndex},
+//		migrate.Migration{Version: 2, Description: "backfill users status", Up: backfillStatus},
+//	)
+//	result, err := migrator.Up(ctx)
+//
+// Migrations run in version order and are not wrapped in transactions; a failing
+// migration stops the run and is not recorded, so it is retried by the next run.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"go.fork.vn/mongodb"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// DefaultCollection stores the applied versions and the lock
+	DefaultCollection = "_migrations"
+
+	// DefaultLockTTL is how long the lock is held without being extended before another replica may take it
+	DefaultLockTTL = 10 * time.Minute
+
+	// lockID is the _id of the lock document
+	lockID = "lock"
+)
+
+var (
+	// ErrLocked is returned when another owner holds the migration lock
+	ErrLocked = errors.New("migrations are locked")
+
+	// ErrInvalidMigration is returned by Register for migrations without version or Up function,
+	// or with a version that is already registered
+	ErrInvalidMigration = errors.New("invalid migration")
+
+	// ErrUnknownVersion is returned when a target version or an applied version is not registered
+	ErrUnknownVersion = errors.New("unknown migration version")
+
+	// ErrIrreversible is returned when a rollback reaches a migration without Down function
+	ErrIrreversible = errors.New("migration cannot be rolled back")
+)
+
+// Func applies or reverts a migration on the manager's default database
+type Func func(ctx context.Context, db *mongo.Database) error
+
+// Migration is a versioned change of the database
+type Migration struct {
+	Version     int64  // Unique positive version, e.g. 1, 2, 3 or a timestamp such as 202406011200
+	Description string // Human readable summary recorded with the version
+	Up          Func   // Applies the migration
+	Down        Func   // Reverts the migration, nil when it cannot be rolled back
+}
+
+// Direction tells whether a step applies or reverts a migration
+type Direction string
+
+// Step directions
+const (
+	DirectionUp   Direction = "up"
+	DirectionDown Direction = "down"
+)
+
+// Step is a migration applied or reverted by a run
+type Step struct {
+	Version     int64
+	Description string
+	Direction   Direction
+	Duration    time.Duration // Time the migration took, 0 in dry runs
+}
+
+// Result lists the steps of a run in execution order
+type Result struct {
+	Steps  []Step
+	DryRun bool // Steps were planned but not run
+}
+
+// Status is the state of a registered or applied migration
+type Status struct {
+	Version     int64
+	Description string
+	Registered  bool      // Migration is registered with the migrator
+	Applied     bool      // Migration is recorded as applied
+	AppliedAt   time.Time // When the migration was applied, zero when it was not
+}
+
+// record is the document stored for an applied migration
+type record struct {
+	Version     int64     `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+	DurationMS  int64     `bson:"duration_ms"`
+}
+
+// Option configures a Migrator
+type Option func(*Migrator)
+
+// WithCollection stores applied versions and the lock in collection instead of DefaultCollection
+func WithCollection(collection string) Option {
+	return func(m *Migrator) {
+		m.collection = collection
+	}
+}
+
+// WithLockTTL sets how long the lock is held without being extended, see DefaultLockTTL.
+// The lock is extended every third of it while a migration runs.
+func WithLockTTL(ttl time.Duration) Option {
+	return func(m *Migrator) {
+		m.lockTTL = ttl
+	}
+}
+
+// WithOwner identifies the lock holder, the host name and process ID by default
+func WithOwner(owner string) Option {
+	return func(m *Migrator) {
+		m.owner = owner
+	}
+}
+
+// WithDryRun plans the steps of every run without running them or taking the lock
+func WithDryRun(dryRun bool) Option {
+	return func(m *Migrator) {
+		m.dryRun = dryRun
+	}
+}
+
+// Migrator runs registered migrations against the manager's default database
+type Migrator struct {
+	manager    mongodb.Manager
+	migrations map[int64]Migration
+	collection string
+	lockTTL    time.Duration
+	owner      string
+	dryRun     bool
+}
+
+// New creates a migrator without migrations
+func New(manager mongodb.Manager, opts ...Option) *Migrator {
+	m := &Migrator{
+		manager:    manager,
+		migrations: make(map[int64]Migration),
+		collection: DefaultCollection,
+		lockTTL:    DefaultLockTTL,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	if m.owner == "" {
+		host, _ := os.Hostname()
+		m.owner = fmt.Sprintf("%s:%d", host, os.Getpid())
+	}
+	return m
+}
+
+// Register adds migrations, rejecting invalid and duplicate versions with ErrInvalidMigration
+func (m *Migrator) Register(migrations ...Migration) error {
+	for _, migration := range migrations {
+		if migration.Version <= 0 {
+			return fmt.Errorf("%w: version %d must be positive", ErrInvalidMigration, migration.Version)
+		}
+		if migration.Up == nil {
+			return fmt.Errorf("%w: version %d has no Up function", ErrInvalidMigration, migration.Version)
+		}
+		if _, ok := m.migrations[migration.Version]; ok {
+			return fmt.Errorf("%w: version %d is registered twice", ErrInvalidMigration, migration.Version)
+		}
+		m.migrations[migration.Version] = migration
+	}
+	return nil
+}
+
+// MustRegister is like Register but panics on invalid migrations
+func (m *Migrator) MustRegister(migrations ...Migration) {
+	if err := m.Register(migrations...); err != nil {
+		panic(err)
+	}
+}
+
+// Status returns the registered and applied migrations sorted by version
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	collection, err := m.collectionOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for version, migration := range m.migrations {
+		status := Status{Version: version, Description: migration.Description, Registered: true}
+		if rec, ok := applied[version]; ok {
+			status.Applied = true
+			status.AppliedAt = rec.AppliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	for version, rec := range applied {
+		if _, ok := m.migrations[version]; !ok {
+			statuses = append(statuses, Status{Version: version, Description: rec.Description, Applied: true, AppliedAt: rec.AppliedAt})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Version returns the highest applied version, 0 when none is applied
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	collection, err := m.collectionOf(ctx)
+	if err != nil {
+		return 0, err
+	}
+	applied, err := m.applied(ctx, collection)
+	if err != nil {
+		return 0, err
+	}
+	var version int64
+	for v := range applied {
+		version = max(version, v)
+	}
+	return version, nil
+}
+
+// Up applies every pending migration in version order
+func (m *Migrator) Up(ctx context.Context) (Result, error) {
+	return m.run(ctx, func(applied map[int64]record) ([]Step, error) {
+		return m.upSteps(applied, m.latest()), nil
+	})
+}
+
+// To migrates to version: pending migrations up to it are applied and applied migrations
+// above it are rolled back, newest first. Version 0 rolls back every migration.
+func (m *Migrator) To(ctx context.Context, version int64) (Result, error) {
+	if _, ok := m.migrations[version]; version != 0 && !ok {
+		return Result{}, fmt.Errorf("%w: %d", ErrUnknownVersion, version)
+	}
+	return m.run(ctx, func(applied map[int64]record) ([]Step, error) {
+		down, err := m.downSteps(applied, func(v int64) bool { return v > version })
+		if err != nil {
+			return nil, err
+		}
+		return append(down, m.upSteps(applied, version)...), nil
+	})
+}
+
+// Rollback reverts the last n applied migrations, newest first
+func (m *Migrator) Rollback(ctx context.Context, n int) (Result, error) {
+	if n < 0 {
+		return Result{DryRun: m.dryRun}, fmt.Errorf("rollback count %d must not be negative", n)
+	}
+	return m.run(ctx, func(applied map[int64]record) ([]Step, error) {
+		versions := sortedVersions(applied)
+		if n < len(versions) {
+			versions = versions[len(versions)-n:]
+		}
+		last := make(map[int64]bool, len(versions))
+		for _, v := range versions {
+			last[v] = true
+		}
+		return m.downSteps(applied, func(v int64) bool { return last[v] })
+	})
+}
+
+// latest returns the highest registered version
+func (m *Migrator) latest() int64 {
+	var version int64
+	for v := range m.migrations {
+		version = max(version, v)
+	}
+	return version
+}
+
+// upSteps plans the pending migrations up to target in ascending order
+func (m *Migrator) upSteps(applied map[int64]record, target int64) []Step {
+	var steps []Step
+	for v, migration := range m.migrations {
+		if _, ok := applied[v]; !ok && v <= target {
+			steps = append(steps, Step{Version: v, Description: migration.Description, Direction: DirectionUp})
+		}
+	}
+	sort.Slice(steps, func(i, j int) bool { return steps[i].Version < steps[j].Version })
+	return steps
+}
+
+// downSteps plans the rollback of the selected applied migrations in descending order
+func (m *Migrator) downSteps(applied map[int64]record, selected func(int64) bool) ([]Step, error) {
+	var steps []Step
+	versions := sortedVersions(applied)
+	for i := len(versions) - 1; i >= 0; i-- {
+		v := versions[i]
+		if !selected(v) {
+			continue
+		}
+		migration, ok := m.migrations[v]
+		if !ok {
+			return nil, fmt.Errorf("%w: applied version %d is not registered", ErrUnknownVersion, v)
+		}
+		if migration.Down == nil {
+			return nil, fmt.Errorf("%w: version %d has no Down function", ErrIrreversible, v)
+		}
+		steps = append(steps, Step{Version: v, Description: migration.Description, Direction: DirectionDown})
+	}
+	return steps, nil
+}
+
+// run plans the steps from the applied state and runs them while holding the lock
+func (m *Migrator) run(ctx context.Context, plan func(map[int64]record) ([]Step, error)) (Result, error) {
+	result := Result{DryRun: m.dryRun}
+	collection, err := m.collectionOf(ctx)
+	if err != nil {
+		return result, err
+	}
+	applied, err := m.applied(ctx, collection)
+	if err != nil {
+		return result, err
+	}
+	steps, err := plan(applied)
+	if err != nil || m.dryRun || len(steps) == 0 {
+		result.Steps = steps
+		return result, err
+	}
+
+	if err := m.lock(ctx, collection); err != nil {
+		return result, err
+	}
+	defer m.unlock(context.WithoutCancel(ctx), collection)
+
+	// Another replica may have migrated before the lock was taken
+	if applied, err = m.applied(ctx, collection); err != nil {
+		return result, err
+	}
+	if steps, err = plan(applied); err != nil {
+		return result, err
+	}
+
+	db := collection.Database()
+	for _, step := range steps {
+		migration := m.migrations[step.Version]
+		start := time.Now()
+		stepCtx, release := m.holdLock(ctx, collection)
+		if step.Direction == DirectionUp {
+			err = migration.Up(stepCtx, db)
+		} else {
+			err = migration.Down(stepCtx, db)
+		}
+		lockErr := release()
+		if err != nil {
+			return result, fmt.Errorf("migrate %s %d: %w", step.Direction, step.Version, errors.Join(err, lockErr))
+		}
+		step.Duration = time.Since(start)
+
+		// A migration that completed is recorded even if the lock was lost meanwhile
+		if err := m.save(ctx, collection, step); err != nil {
+			return result, err
+		}
+		result.Steps = append(result.Steps, step)
+		if lockErr != nil {
+			return result, lockErr
+		}
+
+		// Extend the lock so that long runs keep it
+		if err := m.lock(ctx, collection); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// save records an applied migration or removes a reverted one
+func (m *Migrator) save(ctx context.Context, collection *mongo.Collection, step Step) error {
+	if step.Direction == DirectionDown {
+		_, err := collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: step.Version}})
+		return err
+	}
+	_, err := collection.InsertOne(ctx, record{
+		Version:     step.Version,
+		Description: step.Description,
+		AppliedAt:   time.Now().UTC(),
+		DurationMS:  step.Duration.Milliseconds(),
+	})
+	return err
+}
+
+// collectionOf connects the manager and returns the migrations collection
+func (m *Migrator) collectionOf(ctx context.Context) (*mongo.Collection, error) {
+	if err := m.manager.Connect(ctx); err != nil {
+		return nil, err
+	}
+	return m.manager.Database().Collection(m.collection), nil
+}
+
+// applied returns the applied migrations by version
+func (m *Migrator) applied(ctx context.Context, collection *mongo.Collection) (map[int64]record, error) {
+	cursor, err := collection.Find(ctx, bson.D{{Key: "_id", Value: bson.D{{Key: "$type", Value: "number"}}}})
+	if err != nil {
+		return nil, err
+	}
+	var records []record
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]record, len(records))
+	for _, rec := range records {
+		applied[rec.Version] = rec
+	}
+	return applied, nil
+}
+
+// lock takes or extends the lock, it fails with ErrLocked while another owner holds it
+func (m *Migrator) lock(ctx context.Context, collection *mongo.Collection) error {
+	now := time.Now().UTC()
+	_, err := collection.UpdateOne(ctx,
+		bson.D{
+			{Key: "_id", Value: lockID},
+			{Key: "$or", Value: bson.A{
+				bson.D{{Key: "owner", Value: m.owner}},
+				bson.D{{Key: "expires_at", Value: bson.D{{Key: "$lte", Value: now}}}},
+			}},
+		},
+		bson.D{{Key: "$set", Value: bson.D{
+			{Key: "owner", Value: m.owner},
+			{Key: "locked_at", Value: now},
+			{Key: "expires_at", Value: now.Add(m.lockTTL)},
+		}}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		// The lock exists and is held by another owner, so the upsert collided with it
+		return fmt.Errorf("%w: held by another owner than %s", ErrLocked, m.owner)
+	}
+	return err
+}
+
+// holdLock extends the lock every third of its TTL while a migration runs, until release is called.
+//
+// Failed extensions are retried on the next tick. When another owner took the lock, the
+// context of the migration is canceled and release returns the error.
+func (m *Migrator) holdLock(ctx context.Context, collection *mongo.Collection) (context.Context, func() error) {
+	stepCtx, cancel := context.WithCancelCause(ctx)
+	interval := m.lockTTL / 3
+	if interval <= 0 {
+		return stepCtx, func() error {
+			cancel(nil)
+			return nil
+		}
+	}
+
+	var (
+		wg   sync.WaitGroup
+		lost error
+	)
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-stepCtx.Done():
+				return
+			case <-ticker.C:
+				if err := m.lock(stepCtx, collection); errors.Is(err, ErrLocked) {
+					lost = fmt.Errorf("migration lock lost: %w", err)
+					cancel(lost)
+					return
+				}
+			}
+		}
+	}()
+	return stepCtx, func() error {
+		close(done)
+		wg.Wait()
+		cancel(nil)
+		return lost
+	}
+}
+
+// unlock releases the lock if it is still held by the migrator
+func (m *Migrator) unlock(ctx context.Context, collection *mongo.Collection) {
+	_, _ = collection.DeleteOne(ctx, bson.D{{Key: "_id", Value: lockID}, {Key: "owner", Value: m.owner}})
+}
+
+// sortedVersions returns the applied versions in ascending order
+func sortedVersions(applied map[int64]record) []int64 {
+	versions := make([]int64, 0, len(applied))
+	for v := range applied {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	return versions
+}
@@ -0,0 +1,251 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	mongodb_mocks "go.fork.vn/mongodb/mocks"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// newTestMigrator returns a migrator on the mock deployment of mt and the log of run migrations
+func newTestMigrator(mt *mtest.T, opts ...Option) (*Migrator, *[]string) {
+	manager := mongodb_mocks.NewMockManager(mt)
+	manager.EXPECT().Connect(mock.Anything).Return(nil)
+	manager.EXPECT().Database().Return(mt.DB)
+
+	var log []string
+	step := func(name string) Func {
+		return func(context.Context, *mongo.Database) error {
+			log = append(log, name)
+			return nil
+		}
+	}
+	m := New(manager, append([]Option{WithOwner("test")}, opts...)...)
+	m.MustRegister(
+		Migration{Version: 1, Description: "one", Up: step("up 1"), Down: step("down 1")},
+		Migration{Version: 2, Description: "two", Up: step("up 2"), Down: step("down 2")},
+		Migration{Version: 3, Description: "three", Up: step("up 3")},
+	)
+	return m, &log
+}
+
+// appliedResponse returns the find response listing applied versions
+func appliedResponse(ns string, versions ...int64) bson.D {
+	docs := make([]bson.D, len(versions))
+	for i, v := range versions {
+		docs[i] = bson.D{{Key: "_id", Value: v}, {Key: "description", Value: "applied"}}
+	}
+	return mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, docs...)
+}
+
+func TestMigrator_Register(t *testing.T) {
+	m := New(nil)
+	up := func(context.Context, *mongo.Database) error { return nil }
+
+	assert.NoError(t, m.Register(Migration{Version: 1, Up: up}))
+	assert.ErrorIs(t, m.Register(Migration{Version: 1, Up: up}), ErrInvalidMigration)
+	assert.ErrorIs(t, m.Register(Migration{Version: 0, Up: up}), ErrInvalidMigration)
+	assert.ErrorIs(t, m.Register(Migration{Version: 2}), ErrInvalidMigration)
+	assert.Panics(t, func() { m.MustRegister(Migration{Version: -1, Up: up}) })
+	assert.NotEmpty(t, m.owner)
+}
+
+func TestMigrator(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	ctx := context.Background()
+
+	mt.Run("up applies pending migrations", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + DefaultCollection
+		m, log := newTestMigrator(mt)
+		mt.AddMockResponses(
+			appliedResponse(ns, 1),
+			mtest.CreateSuccessResponse(), // lock
+			appliedResponse(ns, 1),
+			mtest.CreateSuccessResponse(), // record 2
+			mtest.CreateSuccessResponse(), // extend lock
+			mtest.CreateSuccessResponse(), // record 3
+			mtest.CreateSuccessResponse(), // extend lock
+			mtest.CreateSuccessResponse(), // unlock
+		)
+
+		result, err := m.Up(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"up 2", "up 3"}, *log)
+		assert.Len(t, result.Steps, 2)
+		assert.Equal(t, Step{Version: 3, Description: "three", Direction: DirectionUp, Duration: result.Steps[1].Duration}, result.Steps[1])
+
+		started := mt.GetAllStartedEvents()
+		assert.Equal(t, "update", started[1].CommandName)
+		assert.Equal(t, "test", started[1].Command.Lookup("updates", "0", "u", "$set", "owner").StringValue())
+		assert.Equal(t, int64(2), started[3].Command.Lookup("documents", "0", "_id").Int64())
+		assert.Equal(t, "delete", started[len(started)-1].CommandName)
+	})
+
+	mt.Run("dry run plans without locking", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + DefaultCollection
+		m, log := newTestMigrator(mt, WithDryRun(true))
+		mt.AddMockResponses(appliedResponse(ns, 1, 2))
+
+		result, err := m.Up(ctx)
+		assert.NoError(t, err)
+		assert.True(t, result.DryRun)
+		assert.Equal(t, []Step{{Version: 3, Description: "three", Direction: DirectionUp}}, result.Steps)
+		assert.Empty(t, *log)
+		assert.Len(t, mt.GetAllStartedEvents(), 1)
+	})
+
+	mt.Run("to rolls back newer versions", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + DefaultCollection
+		m, log := newTestMigrator(mt)
+		mt.AddMockResponses(
+			appliedResponse(ns, 1, 2),
+			mtest.CreateSuccessResponse(),
+			appliedResponse(ns, 1, 2),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(),
+			mtest.CreateSuccessResponse(),
+		)
+
+		result, err := m.To(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"down 2"}, *log)
+		assert.Equal(t, DirectionDown, result.Steps[0].Direction)
+		assert.Equal(t, int64(2), mt.GetAllStartedEvents()[3].Command.Lookup("deletes", "0", "q", "_id").Int64())
+	})
+
+	mt.Run("rollback of an irreversible migration", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + DefaultCollection
+		m, log := newTestMigrator(mt)
+		mt.AddMockResponses(appliedResponse(ns, 1, 2, 3))
+
+		_, err := m.Rollback(ctx, 2)
+		assert.ErrorIs(t, err, ErrIrreversible)
+		assert.Empty(t, *log)
+	})
+
+	mt.Run("rollback of a negative count", func(mt *mtest.T) {
+		_, err := New(nil).Rollback(ctx, -1)
+		assert.ErrorContains(t, err, "rollback count -1 must not be negative")
+	})
+
+	mt.Run("lock is extended while a migration runs", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + DefaultCollection
+		m, _ := newTestMigrator(mt, WithLockTTL(30*time.Millisecond))
+		m.MustRegister(Migration{Version: 4, Up: func(ctx context.Context, _ *mongo.Database) error {
+			select {
+			case <-time.After(60 * time.Millisecond):
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}})
+		mt.AddMockResponses(appliedResponse(ns, 1, 2, 3), mtest.CreateSuccessResponse(), appliedResponse(ns, 1, 2, 3))
+		for i := 0; i < 20; i++ {
+			mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}))
+		}
+
+		result, err := m.Up(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, result.Steps, 1)
+
+		// The lock is taken, extended while version 4 runs, then extended after recording it
+		locks := 0
+		for _, evt := range mt.GetAllStartedEvents() {
+			if evt.CommandName == "update" {
+				locks++
+			}
+		}
+		assert.GreaterOrEqual(t, locks, 3)
+	})
+
+	mt.Run("lost lock cancels the migration", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + DefaultCollection
+		m, _ := newTestMigrator(mt, WithLockTTL(30*time.Millisecond))
+		m.MustRegister(Migration{Version: 4, Up: func(ctx context.Context, _ *mongo.Database) error {
+			select {
+			case <-time.After(time.Second):
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}})
+		mt.AddMockResponses(
+			appliedResponse(ns, 1, 2, 3),
+			mtest.CreateSuccessResponse(),
+			appliedResponse(ns, 1, 2, 3),
+			mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "duplicate key"}),
+			mtest.CreateSuccessResponse(), // unlock
+		)
+
+		result, err := m.Up(ctx)
+		assert.ErrorIs(t, err, ErrLocked)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Empty(t, result.Steps)
+	})
+
+	mt.Run("locked by another owner", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + DefaultCollection
+		m, log := newTestMigrator(mt)
+		mt.AddMockResponses(
+			appliedResponse(ns),
+			mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "duplicate key"}),
+		)
+
+		_, err := m.Up(ctx)
+		assert.ErrorIs(t, err, ErrLocked)
+		assert.Empty(t, *log)
+	})
+
+	mt.Run("failed migration stops the run", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + DefaultCollection
+		m, _ := newTestMigrator(mt)
+		failure := errors.New("backfill failed")
+		m.MustRegister(Migration{Version: 4, Up: func(context.Context, *mongo.Database) error { return failure }})
+		mt.AddMockResponses(
+			appliedResponse(ns, 1, 2, 3),
+			mtest.CreateSuccessResponse(),
+			appliedResponse(ns, 1, 2, 3),
+			mtest.CreateSuccessResponse(),
+		)
+
+		result, err := m.Up(ctx)
+		assert.ErrorIs(t, err, failure)
+		assert.Empty(t, result.Steps)
+	})
+
+	mt.Run("unknown target", func(mt *mtest.T) {
+		m := New(nil)
+		_, err := m.To(ctx, 7)
+		assert.ErrorIs(t, err, ErrUnknownVersion)
+	})
+
+	mt.Run("status", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + DefaultCollection
+		m, _ := newTestMigrator(mt)
+		mt.AddMockResponses(appliedResponse(ns, 1, 5))
+
+		statuses, err := m.Status(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, statuses, 4)
+		assert.True(t, statuses[0].Applied && statuses[0].Registered)
+		assert.False(t, statuses[1].Applied)
+		assert.Equal(t, Status{Version: 5, Description: "applied", Applied: true}, statuses[3])
+	})
+
+	mt.Run("version", func(mt *mtest.T) {
+		ns := mt.DB.Name() + "." + DefaultCollection
+		m, _ := newTestMigrator(mt)
+		mt.AddMockResponses(appliedResponse(ns, 2, 1))
+
+		version, err := m.Version(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), version)
+	})
+}
@@ -0,0 +1,316 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package mongodb_mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+	mongodb "go.fork.vn/mongodb"
+)
+
+// MockConnections is an autogenerated mock type for the Connections type
+type MockConnections struct {
+	mock.Mock
+}
+
+type MockConnections_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockConnections) EXPECT() *MockConnections_Expecter {
+	return &MockConnections_Expecter{mock: &_m.Mock}
+}
+
+// Connection provides a mock function with given fields: name
+func (_m *MockConnections) Connection(name string) mongodb.Manager {
+	ret := _m.Called(name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Connection")
+	}
+
+	var r0 mongodb.Manager
+	if rf, ok := ret.Get(0).(func(string) mongodb.Manager); ok {
+		r0 = rf(name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(mongodb.Manager)
+		}
+	}
+
+	return r0
+}
+
+// MockConnections_Connection_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Connection'
+type MockConnections_Connection_Call struct {
+	*mock.Call
+}
+
+// Connection is a helper method to define mock.On call
+//   - name string
+func (_e *MockConnections_Expecter) Connection(name interface{}) *MockConnections_Connection_Call {
+	return &MockConnections_Connection_Call{Call: _e.mock.On("Connection", name)}
+}
+
+func (_c *MockConnections_Connection_Call) Run(run func(name string)) *MockConnections_Connection_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockConnections_Connection_Call) Return(_a0 mongodb.Manager) *MockConnections_Connection_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConnections_Connection_Call) RunAndReturn(run func(string) mongodb.Manager) *MockConnections_Connection_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Default provides a mock function with no fields
+func (_m *MockConnections) Default() mongodb.Manager {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Default")
+	}
+
+	var r0 mongodb.Manager
+	if rf, ok := ret.Get(0).(func() mongodb.Manager); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(mongodb.Manager)
+		}
+	}
+
+	return r0
+}
+
+// MockConnections_Default_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Default'
+type MockConnections_Default_Call struct {
+	*mock.Call
+}
+
+// Default is a helper method to define mock.On call
+func (_e *MockConnections_Expecter) Default() *MockConnections_Default_Call {
+	return &MockConnections_Default_Call{Call: _e.mock.On("Default")}
+}
+
+func (_c *MockConnections_Default_Call) Run(run func()) *MockConnections_Default_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockConnections_Default_Call) Return(_a0 mongodb.Manager) *MockConnections_Default_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConnections_Default_Call) RunAndReturn(run func() mongodb.Manager) *MockConnections_Default_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DefaultName provides a mock function with no fields
+func (_m *MockConnections) DefaultName() string {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for DefaultName")
+	}
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+// MockConnections_DefaultName_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DefaultName'
+type MockConnections_DefaultName_Call struct {
+	*mock.Call
+}
+
+// DefaultName is a helper method to define mock.On call
+func (_e *MockConnections_Expecter) DefaultName() *MockConnections_DefaultName_Call {
+	return &MockConnections_DefaultName_Call{Call: _e.mock.On("DefaultName")}
+}
+
+func (_c *MockConnections_DefaultName_Call) Run(run func()) *MockConnections_DefaultName_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockConnections_DefaultName_Call) Return(_a0 string) *MockConnections_DefaultName_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConnections_DefaultName_Call) RunAndReturn(run func() string) *MockConnections_DefaultName_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Has provides a mock function with given fields: name
+func (_m *MockConnections) Has(name string) bool {
+	ret := _m.Called(name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Has")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(string) bool); ok {
+		r0 = rf(name)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// MockConnections_Has_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Has'
+type MockConnections_Has_Call struct {
+	*mock.Call
+}
+
+// Has is a helper method to define mock.On call
+//   - name string
+func (_e *MockConnections_Expecter) Has(name interface{}) *MockConnections_Has_Call {
+	return &MockConnections_Has_Call{Call: _e.mock.On("Has", name)}
+}
+
+func (_c *MockConnections_Has_Call) Run(run func(name string)) *MockConnections_Has_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockConnections_Has_Call) Return(_a0 bool) *MockConnections_Has_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConnections_Has_Call) RunAndReturn(run func(string) bool) *MockConnections_Has_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Names provides a mock function with no fields
+func (_m *MockConnections) Names() []string {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Names")
+	}
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func() []string); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	return r0
+}
+
+// MockConnections_Names_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Names'
+type MockConnections_Names_Call struct {
+	*mock.Call
+}
+
+// Names is a helper method to define mock.On call
+func (_e *MockConnections_Expecter) Names() *MockConnections_Names_Call {
+	return &MockConnections_Names_Call{Call: _e.mock.On("Names")}
+}
+
+func (_c *MockConnections_Names_Call) Run(run func()) *MockConnections_Names_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockConnections_Names_Call) Return(_a0 []string) *MockConnections_Names_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConnections_Names_Call) RunAndReturn(run func() []string) *MockConnections_Names_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Shutdown provides a mock function with given fields: ctx
+func (_m *MockConnections) Shutdown(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Shutdown")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockConnections_Shutdown_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Shutdown'
+type MockConnections_Shutdown_Call struct {
+	*mock.Call
+}
+
+// Shutdown is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockConnections_Expecter) Shutdown(ctx interface{}) *MockConnections_Shutdown_Call {
+	return &MockConnections_Shutdown_Call{Call: _e.mock.On("Shutdown", ctx)}
+}
+
+func (_c *MockConnections_Shutdown_Call) Run(run func(ctx context.Context)) *MockConnections_Shutdown_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockConnections_Shutdown_Call) Return(_a0 error) *MockConnections_Shutdown_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockConnections_Shutdown_Call) RunAndReturn(run func(context.Context) error) *MockConnections_Shutdown_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockConnections creates a new instance of MockConnections. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockConnections(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockConnections {
+	mock := &MockConnections{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
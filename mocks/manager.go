@@ -11,6 +11,8 @@ import (
 	mongodb "go.fork.vn/mongodb"
 
 	options "go.mongodb.org/mongo-driver/mongo/options"
+
+	readpref "go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
 // MockManager is an autogenerated mock type for the Manager type
@@ -73,6 +75,63 @@ func (_c *MockManager_Client_Call) RunAndReturn(run func() *mongo.Client) *MockM
 	return _c
 }
 
+// ClientEncryption provides a mock function with no fields
+func (_m *MockManager) ClientEncryption() (*mongo.ClientEncryption, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClientEncryption")
+	}
+
+	var r0 *mongo.ClientEncryption
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (*mongo.ClientEncryption, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() *mongo.ClientEncryption); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*mongo.ClientEncryption)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockManager_ClientEncryption_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ClientEncryption'
+type MockManager_ClientEncryption_Call struct {
+	*mock.Call
+}
+
+// ClientEncryption is a helper method to define mock.On call
+func (_e *MockManager_Expecter) ClientEncryption() *MockManager_ClientEncryption_Call {
+	return &MockManager_ClientEncryption_Call{Call: _e.mock.On("ClientEncryption")}
+}
+
+func (_c *MockManager_ClientEncryption_Call) Run(run func()) *MockManager_ClientEncryption_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockManager_ClientEncryption_Call) Return(_a0 *mongo.ClientEncryption, _a1 error) *MockManager_ClientEncryption_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockManager_ClientEncryption_Call) RunAndReturn(run func() (*mongo.ClientEncryption, error)) *MockManager_ClientEncryption_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Collection provides a mock function with given fields: name
 func (_m *MockManager) Collection(name string) *mongo.Collection {
 	ret := _m.Called(name)
@@ -170,6 +229,65 @@ func (_c *MockManager_CollectionWithDatabase_Call) RunAndReturn(run func(string,
 	return _c
 }
 
+// CollectionWithReadPreference provides a mock function with given fields: name, profile
+func (_m *MockManager) CollectionWithReadPreference(name string, profile string) (*mongo.Collection, error) {
+	ret := _m.Called(name, profile)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CollectionWithReadPreference")
+	}
+
+	var r0 *mongo.Collection
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (*mongo.Collection, error)); ok {
+		return rf(name, profile)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) *mongo.Collection); ok {
+		r0 = rf(name, profile)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*mongo.Collection)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(name, profile)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockManager_CollectionWithReadPreference_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CollectionWithReadPreference'
+type MockManager_CollectionWithReadPreference_Call struct {
+	*mock.Call
+}
+
+// CollectionWithReadPreference is a helper method to define mock.On call
+//   - name string
+//   - profile string
+func (_e *MockManager_Expecter) CollectionWithReadPreference(name interface{}, profile interface{}) *MockManager_CollectionWithReadPreference_Call {
+	return &MockManager_CollectionWithReadPreference_Call{Call: _e.mock.On("CollectionWithReadPreference", name, profile)}
+}
+
+func (_c *MockManager_CollectionWithReadPreference_Call) Run(run func(name string, profile string)) *MockManager_CollectionWithReadPreference_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockManager_CollectionWithReadPreference_Call) Return(_a0 *mongo.Collection, _a1 error) *MockManager_CollectionWithReadPreference_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockManager_CollectionWithReadPreference_Call) RunAndReturn(run func(string, string) (*mongo.Collection, error)) *MockManager_CollectionWithReadPreference_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Config provides a mock function with no fields
 func (_m *MockManager) Config() *mongodb.Config {
 	ret := _m.Called()
@@ -217,6 +335,52 @@ func (_c *MockManager_Config_Call) RunAndReturn(run func() *mongodb.Config) *Moc
 	return _c
 }
 
+// Connect provides a mock function with given fields: ctx
+func (_m *MockManager) Connect(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Connect")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockManager_Connect_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Connect'
+type MockManager_Connect_Call struct {
+	*mock.Call
+}
+
+// Connect is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockManager_Expecter) Connect(ctx interface{}) *MockManager_Connect_Call {
+	return &MockManager_Connect_Call{Call: _e.mock.On("Connect", ctx)}
+}
+
+func (_c *MockManager_Connect_Call) Run(run func(ctx context.Context)) *MockManager_Connect_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockManager_Connect_Call) Return(_a0 error) *MockManager_Connect_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockManager_Connect_Call) RunAndReturn(run func(context.Context) error) *MockManager_Connect_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // CreateIndex provides a mock function with given fields: ctx, collectionName, model, opts
 func (_m *MockManager) CreateIndex(ctx context.Context, collectionName string, model mongo.IndexModel, opts ...*options.CreateIndexesOptions) (string, error) {
 	_va := make([]interface{}, len(opts))
@@ -610,6 +774,64 @@ func (_c *MockManager_DatabaseWithName_Call) RunAndReturn(run func(string) *mong
 	return _c
 }
 
+// DatabaseWithReadPreference provides a mock function with given fields: profile
+func (_m *MockManager) DatabaseWithReadPreference(profile string) (*mongo.Database, error) {
+	ret := _m.Called(profile)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DatabaseWithReadPreference")
+	}
+
+	var r0 *mongo.Database
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*mongo.Database, error)); ok {
+		return rf(profile)
+	}
+	if rf, ok := ret.Get(0).(func(string) *mongo.Database); ok {
+		r0 = rf(profile)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*mongo.Database)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(profile)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockManager_DatabaseWithReadPreference_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DatabaseWithReadPreference'
+type MockManager_DatabaseWithReadPreference_Call struct {
+	*mock.Call
+}
+
+// DatabaseWithReadPreference is a helper method to define mock.On call
+//   - profile string
+func (_e *MockManager_Expecter) DatabaseWithReadPreference(profile interface{}) *MockManager_DatabaseWithReadPreference_Call {
+	return &MockManager_DatabaseWithReadPreference_Call{Call: _e.mock.On("DatabaseWithReadPreference", profile)}
+}
+
+func (_c *MockManager_DatabaseWithReadPreference_Call) Run(run func(profile string)) *MockManager_DatabaseWithReadPreference_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockManager_DatabaseWithReadPreference_Call) Return(_a0 *mongo.Database, _a1 error) *MockManager_DatabaseWithReadPreference_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockManager_DatabaseWithReadPreference_Call) RunAndReturn(run func(string) (*mongo.Database, error)) *MockManager_DatabaseWithReadPreference_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Disconnect provides a mock function with given fields: ctx
 func (_m *MockManager) Disconnect(ctx context.Context) error {
 	ret := _m.Called(ctx)
@@ -1035,6 +1257,67 @@ func (_c *MockManager_HealthCheck_Call) RunAndReturn(run func(context.Context) e
 	return _c
 }
 
+// HealthReport provides a mock function with given fields: ctx, checks
+func (_m *MockManager) HealthReport(ctx context.Context, checks ...string) mongodb.HealthReport {
+	_va := make([]interface{}, len(checks))
+	for _i := range checks {
+		_va[_i] = checks[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for HealthReport")
+	}
+
+	var r0 mongodb.HealthReport
+	if rf, ok := ret.Get(0).(func(context.Context, ...string) mongodb.HealthReport); ok {
+		r0 = rf(ctx, checks...)
+	} else {
+		r0 = ret.Get(0).(mongodb.HealthReport)
+	}
+
+	return r0
+}
+
+// MockManager_HealthReport_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'HealthReport'
+type MockManager_HealthReport_Call struct {
+	*mock.Call
+}
+
+// HealthReport is a helper method to define mock.On call
+//   - ctx context.Context
+//   - checks ...string
+func (_e *MockManager_Expecter) HealthReport(ctx interface{}, checks ...interface{}) *MockManager_HealthReport_Call {
+	return &MockManager_HealthReport_Call{Call: _e.mock.On("HealthReport",
+		append([]interface{}{ctx}, checks...)...)}
+}
+
+func (_c *MockManager_HealthReport_Call) Run(run func(ctx context.Context, checks ...string)) *MockManager_HealthReport_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]string, len(args)-1)
+		for i, a := range args[1:] {
+			if a != nil {
+				variadicArgs[i] = a.(string)
+			}
+		}
+		run(args[0].(context.Context), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockManager_HealthReport_Call) Return(_a0 mongodb.HealthReport) *MockManager_HealthReport_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockManager_HealthReport_Call) RunAndReturn(run func(context.Context, ...string) mongodb.HealthReport) *MockManager_HealthReport_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // ListCollections provides a mock function with given fields: ctx
 func (_m *MockManager) ListCollections(ctx context.Context) ([]string, error) {
 	ret := _m.Called(ctx)
@@ -1346,6 +1629,157 @@ func (_c *MockManager_Ping_Call) RunAndReturn(run func(context.Context) error) *
 	return _c
 }
 
+// PoolStats provides a mock function with no fields
+func (_m *MockManager) PoolStats() []mongodb.PoolStats {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for PoolStats")
+	}
+
+	var r0 []mongodb.PoolStats
+	if rf, ok := ret.Get(0).(func() []mongodb.PoolStats); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]mongodb.PoolStats)
+		}
+	}
+
+	return r0
+}
+
+// MockManager_PoolStats_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PoolStats'
+type MockManager_PoolStats_Call struct {
+	*mock.Call
+}
+
+// PoolStats is a helper method to define mock.On call
+func (_e *MockManager_Expecter) PoolStats() *MockManager_PoolStats_Call {
+	return &MockManager_PoolStats_Call{Call: _e.mock.On("PoolStats")}
+}
+
+func (_c *MockManager_PoolStats_Call) Run(run func()) *MockManager_PoolStats_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockManager_PoolStats_Call) Return(_a0 []mongodb.PoolStats) *MockManager_PoolStats_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockManager_PoolStats_Call) RunAndReturn(run func() []mongodb.PoolStats) *MockManager_PoolStats_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ReadPreference provides a mock function with given fields: profile
+func (_m *MockManager) ReadPreference(profile string) (*readpref.ReadPref, error) {
+	ret := _m.Called(profile)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ReadPreference")
+	}
+
+	var r0 *readpref.ReadPref
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*readpref.ReadPref, error)); ok {
+		return rf(profile)
+	}
+	if rf, ok := ret.Get(0).(func(string) *readpref.ReadPref); ok {
+		r0 = rf(profile)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*readpref.ReadPref)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(profile)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockManager_ReadPreference_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ReadPreference'
+type MockManager_ReadPreference_Call struct {
+	*mock.Call
+}
+
+// ReadPreference is a helper method to define mock.On call
+//   - profile string
+func (_e *MockManager_Expecter) ReadPreference(profile interface{}) *MockManager_ReadPreference_Call {
+	return &MockManager_ReadPreference_Call{Call: _e.mock.On("ReadPreference", profile)}
+}
+
+func (_c *MockManager_ReadPreference_Call) Run(run func(profile string)) *MockManager_ReadPreference_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(string))
+	})
+	return _c
+}
+
+func (_c *MockManager_ReadPreference_Call) Return(_a0 *readpref.ReadPref, _a1 error) *MockManager_ReadPreference_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockManager_ReadPreference_Call) RunAndReturn(run func(string) (*readpref.ReadPref, error)) *MockManager_ReadPreference_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Shutdown provides a mock function with given fields: ctx
+func (_m *MockManager) Shutdown(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Shutdown")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockManager_Shutdown_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Shutdown'
+type MockManager_Shutdown_Call struct {
+	*mock.Call
+}
+
+// Shutdown is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockManager_Expecter) Shutdown(ctx interface{}) *MockManager_Shutdown_Call {
+	return &MockManager_Shutdown_Call{Call: _e.mock.On("Shutdown", ctx)}
+}
+
+func (_c *MockManager_Shutdown_Call) Run(run func(ctx context.Context)) *MockManager_Shutdown_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockManager_Shutdown_Call) Return(_a0 error) *MockManager_Shutdown_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockManager_Shutdown_Call) RunAndReturn(run func(context.Context) error) *MockManager_Shutdown_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // StartSession provides a mock function with given fields: opts
 func (_m *MockManager) StartSession(opts ...*options.SessionOptions) (mongo.Session, error) {
 	_va := make([]interface{}, len(opts))
@@ -1475,6 +1909,111 @@ func (_c *MockManager_Stats_Call) RunAndReturn(run func(context.Context) (map[st
 	return _c
 }
 
+// SubscribeTopology provides a mock function with given fields: buffer
+func (_m *MockManager) SubscribeTopology(buffer int) (<-chan mongodb.TopologyEvent, func()) {
+	ret := _m.Called(buffer)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SubscribeTopology")
+	}
+
+	var r0 <-chan mongodb.TopologyEvent
+	var r1 func()
+	if rf, ok := ret.Get(0).(func(int) (<-chan mongodb.TopologyEvent, func())); ok {
+		return rf(buffer)
+	}
+	if rf, ok := ret.Get(0).(func(int) <-chan mongodb.TopologyEvent); ok {
+		r0 = rf(buffer)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan mongodb.TopologyEvent)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(int) func()); ok {
+		r1 = rf(buffer)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(func())
+		}
+	}
+
+	return r0, r1
+}
+
+// MockManager_SubscribeTopology_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SubscribeTopology'
+type MockManager_SubscribeTopology_Call struct {
+	*mock.Call
+}
+
+// SubscribeTopology is a helper method to define mock.On call
+//   - buffer int
+func (_e *MockManager_Expecter) SubscribeTopology(buffer interface{}) *MockManager_SubscribeTopology_Call {
+	return &MockManager_SubscribeTopology_Call{Call: _e.mock.On("SubscribeTopology", buffer)}
+}
+
+func (_c *MockManager_SubscribeTopology_Call) Run(run func(buffer int)) *MockManager_SubscribeTopology_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(int))
+	})
+	return _c
+}
+
+func (_c *MockManager_SubscribeTopology_Call) Return(_a0 <-chan mongodb.TopologyEvent, _a1 func()) *MockManager_SubscribeTopology_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockManager_SubscribeTopology_Call) RunAndReturn(run func(int) (<-chan mongodb.TopologyEvent, func())) *MockManager_SubscribeTopology_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Topology provides a mock function with no fields
+func (_m *MockManager) Topology() mongodb.Topology {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for Topology")
+	}
+
+	var r0 mongodb.Topology
+	if rf, ok := ret.Get(0).(func() mongodb.Topology); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(mongodb.Topology)
+	}
+
+	return r0
+}
+
+// MockManager_Topology_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Topology'
+type MockManager_Topology_Call struct {
+	*mock.Call
+}
+
+// Topology is a helper method to define mock.On call
+func (_e *MockManager_Expecter) Topology() *MockManager_Topology_Call {
+	return &MockManager_Topology_Call{Call: _e.mock.On("Topology")}
+}
+
+func (_c *MockManager_Topology_Call) Run(run func()) *MockManager_Topology_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run()
+	})
+	return _c
+}
+
+func (_c *MockManager_Topology_Call) Return(_a0 mongodb.Topology) *MockManager_Topology_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockManager_Topology_Call) RunAndReturn(run func() mongodb.Topology) *MockManager_Topology_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // UseSession provides a mock function with given fields: ctx, fn
 func (_m *MockManager) UseSession(ctx context.Context, fn func(mongo.SessionContext) error) error {
 	ret := _m.Called(ctx, fn)
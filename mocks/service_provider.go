@@ -3,8 +3,11 @@
 package mongodb_mocks
 
 import (
-	mock "github.com/stretchr/testify/mock"
+	context "context"
+
 	di "go.fork.vn/di"
+
+	mock "github.com/stretchr/testify/mock"
 )
 
 // MockServiceProvider is an autogenerated mock type for the ServiceProvider type
@@ -180,6 +183,52 @@ func (_c *MockServiceProvider_Requires_Call) RunAndReturn(run func() []string) *
 	return _c
 }
 
+// Shutdown provides a mock function with given fields: ctx
+func (_m *MockServiceProvider) Shutdown(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Shutdown")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockServiceProvider_Shutdown_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Shutdown'
+type MockServiceProvider_Shutdown_Call struct {
+	*mock.Call
+}
+
+// Shutdown is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockServiceProvider_Expecter) Shutdown(ctx interface{}) *MockServiceProvider_Shutdown_Call {
+	return &MockServiceProvider_Shutdown_Call{Call: _e.mock.On("Shutdown", ctx)}
+}
+
+func (_c *MockServiceProvider_Shutdown_Call) Run(run func(ctx context.Context)) *MockServiceProvider_Shutdown_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *MockServiceProvider_Shutdown_Call) Return(_a0 error) *MockServiceProvider_Shutdown_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockServiceProvider_Shutdown_Call) RunAndReturn(run func(context.Context) error) *MockServiceProvider_Shutdown_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockServiceProvider creates a new instance of MockServiceProvider. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockServiceProvider(t interface {
@@ -0,0 +1,121 @@
+// Package mongootel traces the commands of a mongodb.Manager with OpenTelemetry.
+//
+// Pass a Tracer to the manager with mongodb.WithTracer; every command gets a client span
+// following the OpenTelemetry database semantic conventions, child of the span in the
+// context of the operation:
+//
+//	manager, err := mongodb.Open(ctx, config, mongodb.WithTracer(mongootel.NewTracer()))
+package mongootel
+
+import (
+	"context"
+
+	"go.fork.vn/mongodb"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ScopeName is the instrumentation scope of the spans
+const ScopeName = "go.fork.vn/mongodb/mongootel"
+
+// Attribute keys from the OpenTelemetry database semantic conventions
+const (
+	DBSystemKey          = attribute.Key("db.system")
+	DBNameKey            = attribute.Key("db.name")
+	DBMongoDBCollection  = attribute.Key("db.mongodb.collection")
+	DBOperationKey       = attribute.Key("db.operation")
+	ServerAddressKey     = attribute.Key("server.address")
+	ServerPortKey        = attribute.Key("server.port")
+	DBSystemMongoDBValue = "mongodb"
+)
+
+// Option configures a Tracer
+type Option func(*Tracer)
+
+// WithTracerProvider uses provider instead of the global tracer provider
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(t *Tracer) {
+		t.provider = provider
+	}
+}
+
+// WithAttributes adds attributes to every span, e.g. the connection name
+func WithAttributes(attrs ...attribute.KeyValue) Option {
+	return func(t *Tracer) {
+		t.attrs = append(t.attrs, attrs...)
+	}
+}
+
+// Tracer starts OpenTelemetry spans for MongoDB commands and implements mongodb.Tracer
+type Tracer struct {
+	provider trace.TracerProvider
+	tracer   trace.Tracer
+	attrs    []attribute.KeyValue
+}
+
+var _ mongodb.Tracer = (*Tracer)(nil)
+
+// NewTracer creates a tracer using the global tracer provider unless WithTracerProvider is given
+//
+// The global provider is a no-op until the application installs one.
+func NewTracer(opts ...Option) *Tracer {
+	t := &Tracer{}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if t.provider == nil {
+		t.provider = otel.GetTracerProvider()
+	}
+	t.tracer = t.provider.Tracer(ScopeName)
+	return t
+}
+
+// StartCommand starts a client span for a command
+func (t *Tracer) StartCommand(ctx context.Context, info mongodb.CommandInfo) mongodb.CommandSpan {
+	attrs := make([]attribute.KeyValue, 0, len(t.attrs)+6)
+	attrs = append(attrs,
+		DBSystemKey.String(DBSystemMongoDBValue),
+		DBNameKey.String(info.Database),
+		DBOperationKey.String(info.Command),
+	)
+	if info.Collection != "" {
+		attrs = append(attrs, DBMongoDBCollection.String(info.Collection))
+	}
+	if info.ServerAddress != "" {
+		attrs = append(attrs, ServerAddressKey.String(info.ServerAddress))
+	}
+	if info.ServerPort != 0 {
+		attrs = append(attrs, ServerPortKey.Int(info.ServerPort))
+	}
+	attrs = append(attrs, t.attrs...)
+
+	_, span := t.tracer.Start(ctx, spanName(info),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attrs...),
+	)
+	return commandSpan{span: span}
+}
+
+// spanName returns "<operation> <database>.<collection>", or "<operation> <database>" without a collection
+func spanName(info mongodb.CommandInfo) string {
+	if info.Collection == "" {
+		return info.Command + " " + info.Database
+	}
+	return info.Command + " " + info.Database + "." + info.Collection
+}
+
+// commandSpan wraps an OpenTelemetry span
+type commandSpan struct {
+	span trace.Span
+}
+
+// End records the error, if any, and ends the span
+func (s commandSpan) End(err error) {
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+	s.span.End()
+}
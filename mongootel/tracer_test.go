@@ -0,0 +1,56 @@
+package mongootel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.fork.vn/mongodb"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracer_StartCommand(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	tracer := NewTracer(WithTracerProvider(provider), WithAttributes(attribute.String("db.connection", "default")))
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "handler")
+	tracer.StartCommand(ctx, mongodb.CommandInfo{
+		Command:       "find",
+		Database:      "shop",
+		Collection:    "orders",
+		ServerAddress: "db-1",
+		ServerPort:    27017,
+	}).End(nil)
+	tracer.StartCommand(ctx, mongodb.CommandInfo{Command: "ping", Database: "admin"}).End(errors.New("not primary"))
+	parent.End()
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 3)
+
+	find := spans[0]
+	assert.Equal(t, "find shop.orders", find.Name())
+	assert.Equal(t, trace.SpanKindClient, find.SpanKind())
+	assert.Equal(t, parent.SpanContext().SpanID(), find.Parent().SpanID())
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		DBSystemKey.String("mongodb"),
+		DBNameKey.String("shop"),
+		DBOperationKey.String("find"),
+		DBMongoDBCollection.String("orders"),
+		ServerAddressKey.String("db-1"),
+		ServerPortKey.Int(27017),
+		attribute.String("db.connection", "default"),
+	}, find.Attributes())
+	assert.Equal(t, codes.Unset, find.Status().Code)
+
+	ping := spans[1]
+	assert.Equal(t, "ping admin", ping.Name())
+	assert.Equal(t, codes.Error, ping.Status().Code)
+	assert.Equal(t, "not primary", ping.Status().Description)
+	assert.Len(t, ping.Events(), 1)
+}
@@ -0,0 +1,148 @@
+// Package mongoprom exposes the metrics collected by a mongodb.Manager as a Prometheus collector.
+//
+// Register a Collector with a Prometheus registry and pass it to the manager with
+// mongodb.WithMetrics:
+//
+//	collector := mongoprom.NewCollector(mongoprom.Options{})
+//	prometheus.MustRegister(collector)
+//	manager, err := mongodb.Open(ctx, config, mongodb.WithMetrics(collector))
+//
+// Every metric carries a connection label. Managers created by mongodb.NewConnections are
+// labelled with their connection name; other managers sharing a collector should each be
+// given their own name with ForConnection:
+//
+//	orders, err := mongodb.Open(ctx, ordersConfig, mongodb.WithMetrics(collector.ForConnection("orders")))
+package mongoprom
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.fork.vn/mongodb"
+)
+
+// DefaultNamespace prefixes the metric names when Options.Namespace is empty
+const DefaultNamespace = "mongodb"
+
+// Options configures a Collector
+type Options struct {
+	Namespace      string            // Metric name prefix (default DefaultNamespace)
+	ConstLabels    prometheus.Labels // Labels added to every metric, e.g. the service name; must not contain "connection"
+	CommandBuckets []float64         // Command duration buckets in seconds (default prometheus.DefBuckets)
+	WaitBuckets    []float64         // Pool checkout wait buckets in seconds (default prometheus.DefBuckets)
+}
+
+// Collector records manager metrics and exposes them to Prometheus.
+//
+// It implements both prometheus.Collector and mongodb.ConnectionMetricsRecorder, and can
+// be shared by several managers as long as each records under its own connection name.
+// Metrics recorded directly on the collector are labelled mongodb.DefaultConnectionName.
+type Collector struct {
+	commandDuration   *prometheus.HistogramVec
+	poolCheckedOut    *prometheus.GaugeVec
+	poolIdle          *prometheus.GaugeVec
+	poolWait          *prometheus.HistogramVec
+	heartbeatFailures *prometheus.CounterVec
+
+	// connection is the value of the connection label of the recorded metrics
+	connection string
+}
+
+var _ mongodb.ConnectionMetricsRecorder = (*Collector)(nil)
+var _ prometheus.Collector = (*Collector)(nil)
+
+// NewCollector creates a collector with the given options
+func NewCollector(opts Options) *Collector {
+	if opts.Namespace == "" {
+		opts.Namespace = DefaultNamespace
+	}
+	if opts.CommandBuckets == nil {
+		opts.CommandBuckets = prometheus.DefBuckets
+	}
+	if opts.WaitBuckets == nil {
+		opts.WaitBuckets = prometheus.DefBuckets
+	}
+
+	return &Collector{
+		commandDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   opts.Namespace,
+			Name:        "command_duration_seconds",
+			Help:        "Duration of MongoDB commands by command, collection and outcome.",
+			ConstLabels: opts.ConstLabels,
+			Buckets:     opts.CommandBuckets,
+		}, []string{"connection", "command", "collection", "outcome"}),
+		poolCheckedOut: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   opts.Namespace,
+			Name:        "pool_checked_out_connections",
+			Help:        "Connections currently checked out of the pool of a server.",
+			ConstLabels: opts.ConstLabels,
+		}, []string{"connection", "address"}),
+		poolIdle: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace:   opts.Namespace,
+			Name:        "pool_idle_connections",
+			Help:        "Open connections idle in the pool of a server.",
+			ConstLabels: opts.ConstLabels,
+		}, []string{"connection", "address"}),
+		poolWait: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   opts.Namespace,
+			Name:        "pool_wait_duration_seconds",
+			Help:        "Time spent waiting to check out a connection from the pool of a server.",
+			ConstLabels: opts.ConstLabels,
+			Buckets:     opts.WaitBuckets,
+		}, []string{"connection", "address"}),
+		heartbeatFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   opts.Namespace,
+			Name:        "heartbeat_failures_total",
+			Help:        "Failed heartbeats to a server.",
+			ConstLabels: opts.ConstLabels,
+		}, []string{"connection", "address"}),
+		connection: mongodb.DefaultConnectionName,
+	}
+}
+
+// ForConnection returns a recorder sharing the metrics of the collector and labelling
+// them with the connection name
+func (c *Collector) ForConnection(name string) mongodb.MetricsRecorder {
+	named := *c
+	named.connection = name
+	return &named
+}
+
+// ObserveCommand records the duration of a finished command
+func (c *Collector) ObserveCommand(command, collection, outcome string, duration time.Duration) {
+	c.commandDuration.WithLabelValues(c.connection, command, collection, outcome).Observe(duration.Seconds())
+}
+
+// SetPoolConnections records the number of checked-out and idle connections in the pool of a server
+func (c *Collector) SetPoolConnections(address string, checkedOut, idle int) {
+	c.poolCheckedOut.WithLabelValues(c.connection, address).Set(float64(checkedOut))
+	c.poolIdle.WithLabelValues(c.connection, address).Set(float64(idle))
+}
+
+// ObservePoolWait records the time spent waiting to check out a connection from the pool of a server
+func (c *Collector) ObservePoolWait(address string, wait time.Duration) {
+	c.poolWait.WithLabelValues(c.connection, address).Observe(wait.Seconds())
+}
+
+// IncHeartbeatFailures counts a failed heartbeat to a server
+func (c *Collector) IncHeartbeatFailures(address string) {
+	c.heartbeatFailures.WithLabelValues(c.connection, address).Inc()
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.commandDuration.Describe(ch)
+	c.poolCheckedOut.Describe(ch)
+	c.poolIdle.Describe(ch)
+	c.poolWait.Describe(ch)
+	c.heartbeatFailures.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.commandDuration.Collect(ch)
+	c.poolCheckedOut.Collect(ch)
+	c.poolIdle.Collect(ch)
+	c.poolWait.Collect(ch)
+	c.heartbeatFailures.Collect(ch)
+}
@@ -0,0 +1,62 @@
+package mongoprom
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	collector := NewCollector(Options{ConstLabels: prometheus.Labels{"service": "orders"}})
+	registry := prometheus.NewPedanticRegistry()
+	assert.NoError(t, registry.Register(collector))
+
+	collector.ObserveCommand("find", "users", "success", 20*time.Millisecond)
+	collector.SetPoolConnections("localhost:27017", 3, 2)
+	collector.ObservePoolWait("localhost:27017", time.Millisecond)
+	collector.IncHeartbeatFailures("localhost:27017")
+	collector.IncHeartbeatFailures("localhost:27017")
+
+	assert.Equal(t, 1, testutil.CollectAndCount(collector, "mongodb_command_duration_seconds"))
+	assert.Equal(t, float64(3), testutil.ToFloat64(collector.poolCheckedOut.WithLabelValues("default", "localhost:27017")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(collector.poolIdle.WithLabelValues("default", "localhost:27017")))
+
+	err := testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP mongodb_heartbeat_failures_total Failed heartbeats to a server.
+# TYPE mongodb_heartbeat_failures_total counter
+mongodb_heartbeat_failures_total{address="localhost:27017",connection="default",service="orders"} 2
+`), "mongodb_heartbeat_failures_total")
+	assert.NoError(t, err)
+}
+
+func TestCollector_ForConnection(t *testing.T) {
+	collector := NewCollector(Options{})
+	registry := prometheus.NewPedanticRegistry()
+	assert.NoError(t, registry.Register(collector))
+
+	// Two managers connected to the same server keep their own pool gauges
+	collector.ForConnection("orders").SetPoolConnections("localhost:27017", 3, 2)
+	collector.ForConnection("audit").SetPoolConnections("localhost:27017", 1, 4)
+	collector.ForConnection("audit").ObserveCommand("insert", "events", "success", time.Millisecond)
+
+	err := testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP mongodb_pool_checked_out_connections Connections currently checked out of the pool of a server.
+# TYPE mongodb_pool_checked_out_connections gauge
+mongodb_pool_checked_out_connections{address="localhost:27017",connection="audit"} 1
+mongodb_pool_checked_out_connections{address="localhost:27017",connection="orders"} 3
+`), "mongodb_pool_checked_out_connections")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, testutil.CollectAndCount(collector, "mongodb_command_duration_seconds"))
+	assert.Equal(t, float64(4), testutil.ToFloat64(collector.poolIdle.WithLabelValues("audit", "localhost:27017")))
+}
+
+func TestNewCollector_Namespace(t *testing.T) {
+	collector := NewCollector(Options{Namespace: "orders_db"})
+	collector.ObserveCommand("insert", "orders", "failure", time.Second)
+
+	assert.Equal(t, 1, testutil.CollectAndCount(collector, "orders_db_command_duration_seconds"))
+}
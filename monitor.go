@@ -0,0 +1,208 @@
+package mongodb
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// redactedValue replaces every value in a command shape
+const redactedValue = "?"
+
+// ignoredCommandFields are driver bookkeeping fields left out of command shapes
+var ignoredCommandFields = map[string]bool{
+	"lsid":             true,
+	"$clusterTime":     true,
+	"$db":              true,
+	"txnNumber":        true,
+	"autocommit":       true,
+	"startTransaction": true,
+	"signature":        true,
+}
+
+// combineCommandMonitors returns a monitor forwarding every event to each non-nil monitor in order
+func combineCommandMonitors(monitors ...*event.CommandMonitor) *event.CommandMonitor {
+	var active []*event.CommandMonitor
+	for _, monitor := range monitors {
+		if monitor != nil {
+			active = append(active, monitor)
+		}
+	}
+	switch len(active) {
+	case 0:
+		return nil
+	case 1:
+		return active[0]
+	}
+
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, evt *event.CommandStartedEvent) {
+			for _, monitor := range active {
+				if monitor.Started != nil {
+					monitor.Started(ctx, evt)
+				}
+			}
+		},
+		Succeeded: func(ctx context.Context, evt *event.CommandSucceededEvent) {
+			for _, monitor := range active {
+				if monitor.Succeeded != nil {
+					monitor.Succeeded(ctx, evt)
+				}
+			}
+		},
+		Failed: func(ctx context.Context, evt *event.CommandFailedEvent) {
+			for _, monitor := range active {
+				if monitor.Failed != nil {
+					monitor.Failed(ctx, evt)
+				}
+			}
+		},
+	}
+}
+
+// operationTracker counts the commands sent to the server that have not completed yet.
+//
+// The zero value is ready to use.
+type operationTracker struct {
+	mu     sync.Mutex
+	active map[int64]struct{} // keyed by driver request ID
+	idle   chan struct{}      // closed once the commands in flight complete, nil when nobody waits
+}
+
+// monitor returns a command monitor feeding the tracker
+func (t *operationTracker) monitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(_ context.Context, evt *event.CommandStartedEvent) {
+			t.start(evt.RequestID)
+		},
+		Succeeded: func(_ context.Context, evt *event.CommandSucceededEvent) {
+			t.finish(evt.RequestID)
+		},
+		Failed: func(_ context.Context, evt *event.CommandFailedEvent) {
+			t.finish(evt.RequestID)
+		},
+	}
+}
+
+// start records a command as in flight
+func (t *operationTracker) start(requestID int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.active == nil {
+		t.active = make(map[int64]struct{})
+	}
+	t.active[requestID] = struct{}{}
+}
+
+// finish records a command as completed
+func (t *operationTracker) finish(requestID int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.active, requestID)
+	if len(t.active) == 0 && t.idle != nil {
+		close(t.idle)
+		t.idle = nil
+	}
+}
+
+// count returns the number of commands in flight
+func (t *operationTracker) count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.active)
+}
+
+// wait blocks until no command is in flight or ctx is done
+func (t *operationTracker) wait(ctx context.Context) error {
+	t.mu.Lock()
+	if len(t.active) == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	if t.idle == nil {
+		t.idle = make(chan struct{})
+	}
+	idle := t.idle
+	t.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// commandCollection returns the collection a command targets, or an empty string for
+// database and server level commands
+func commandCollection(cmd bson.Raw) string {
+	elems, err := cmd.Elements()
+	if err != nil || len(elems) == 0 {
+		return ""
+	}
+	if elems[0].Key() == "getMore" {
+		collection, _ := cmd.Lookup("collection").StringValueOK()
+		return collection
+	}
+	collection, _ := elems[0].Value().StringValueOK()
+	return collection
+}
+
+// commandShape returns the command with every value replaced by a placeholder.
+//
+// Keys and operators are kept so the shape of filters, updates and pipelines stays
+// visible without exposing data. The command name and its collection are kept as is.
+func commandShape(cmd bson.Raw) bson.D {
+	elems, err := cmd.Elements()
+	if err != nil {
+		return nil
+	}
+
+	shape := make(bson.D, 0, len(elems))
+	for i, elem := range elems {
+		if ignoredCommandFields[elem.Key()] {
+			continue
+		}
+		value := valueShape(elem.Value())
+		if i == 0 {
+			value = elem.Value()
+		}
+		shape = append(shape, bson.E{Key: elem.Key(), Value: value})
+	}
+	return shape
+}
+
+// valueShape replaces the scalar values of a BSON value with a placeholder
+func valueShape(value bson.RawValue) interface{} {
+	switch value.Type {
+	case bson.TypeEmbeddedDocument:
+		elems, err := value.Document().Elements()
+		if err != nil {
+			return redactedValue
+		}
+		shape := make(bson.D, 0, len(elems))
+		for _, elem := range elems {
+			shape = append(shape, bson.E{Key: elem.Key(), Value: valueShape(elem.Value())})
+		}
+		return shape
+	case bson.TypeArray:
+		values, err := value.Array().Values()
+		if err != nil {
+			return redactedValue
+		}
+		// Arrays of scalars, such as $in lists, collapse into a single placeholder
+		shape := make(bson.A, 0, len(values))
+		for _, v := range values {
+			if v.Type != bson.TypeEmbeddedDocument && v.Type != bson.TypeArray {
+				return redactedValue
+			}
+			shape = append(shape, valueShape(v))
+		}
+		return shape
+	}
+	return redactedValue
+}
@@ -0,0 +1,116 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+func TestCombineCommandMonitors(t *testing.T) {
+	assert.Nil(t, combineCommandMonitors())
+	assert.Nil(t, combineCommandMonitors(nil, nil))
+
+	only := &event.CommandMonitor{}
+	assert.Same(t, only, combineCommandMonitors(nil, only))
+
+	var calls []string
+	first := &event.CommandMonitor{
+		Started:   func(context.Context, *event.CommandStartedEvent) { calls = append(calls, "first started") },
+		Succeeded: func(context.Context, *event.CommandSucceededEvent) { calls = append(calls, "first succeeded") },
+	}
+	second := &event.CommandMonitor{
+		Started: func(context.Context, *event.CommandStartedEvent) { calls = append(calls, "second started") },
+		Failed:  func(context.Context, *event.CommandFailedEvent) { calls = append(calls, "second failed") },
+	}
+
+	combined := combineCommandMonitors(first, nil, second)
+	combined.Started(context.Background(), &event.CommandStartedEvent{})
+	combined.Succeeded(context.Background(), &event.CommandSucceededEvent{})
+	combined.Failed(context.Background(), &event.CommandFailedEvent{})
+
+	assert.Equal(t, []string{"first started", "second started", "first succeeded", "second failed"}, calls)
+}
+
+func TestOperationTracker(t *testing.T) {
+	var tracker operationTracker
+	monitor := tracker.monitor()
+	ctx := context.Background()
+
+	// Nothing in flight
+	assert.NoError(t, tracker.wait(ctx))
+
+	monitor.Started(ctx, &event.CommandStartedEvent{RequestID: 1})
+	monitor.Started(ctx, &event.CommandStartedEvent{RequestID: 2})
+	assert.Equal(t, 2, tracker.count())
+
+	t.Run("wait times out", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, tracker.wait(ctx), context.DeadlineExceeded)
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- tracker.wait(ctx) }()
+
+	monitor.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: event.CommandFinishedEvent{RequestID: 1}})
+	select {
+	case <-done:
+		t.Fatal("wait returned while a command is still in flight")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	monitor.Failed(ctx, &event.CommandFailedEvent{CommandFinishedEvent: event.CommandFinishedEvent{RequestID: 2}})
+	assert.NoError(t, <-done)
+	assert.Equal(t, 0, tracker.count())
+
+	// Finishing an unknown command is ignored
+	monitor.Failed(ctx, &event.CommandFailedEvent{CommandFinishedEvent: event.CommandFinishedEvent{RequestID: 3}})
+	assert.NoError(t, tracker.wait(ctx))
+}
+
+func TestCommandShape(t *testing.T) {
+	cmd, err := bson.Marshal(bson.D{
+		{Key: "update", Value: "users"},
+		{Key: "updates", Value: bson.A{
+			bson.D{
+				{Key: "q", Value: bson.D{{Key: "age", Value: bson.D{{Key: "$gt", Value: 21}}}, {Key: "tags", Value: bson.D{{Key: "$in", Value: bson.A{"a", "b"}}}}}},
+				{Key: "u", Value: bson.D{{Key: "$set", Value: bson.D{{Key: "name", Value: "Jane"}}}}},
+			},
+		}},
+		{Key: "ordered", Value: true},
+		{Key: "lsid", Value: bson.D{{Key: "id", Value: 1}}},
+		{Key: "$db", Value: "testdb"},
+	})
+	assert.NoError(t, err)
+
+	data, err := bson.MarshalExtJSON(commandShape(cmd), false, false)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"update":"users","updates":[{"q":{"age":{"$gt":"?"},"tags":{"$in":"?"}},"u":{"$set":{"name":"?"}}}],"ordered":"?"}`, string(data))
+	assert.Equal(t, "users", commandCollection(cmd))
+}
+
+func TestCommandCollection(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  bson.D
+		want string
+	}{
+		{name: "find", cmd: bson.D{{Key: "find", Value: "users"}}, want: "users"},
+		{name: "getMore", cmd: bson.D{{Key: "getMore", Value: int64(42)}, {Key: "collection", Value: "users"}}, want: "users"},
+		{name: "database aggregate", cmd: bson.D{{Key: "aggregate", Value: 1}}, want: ""},
+		{name: "ping", cmd: bson.D{{Key: "ping", Value: 1}}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd, err := bson.Marshal(tt.cmd)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, commandCollection(cmd))
+		})
+	}
+	assert.Equal(t, "", commandCollection(nil))
+}
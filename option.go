@@ -0,0 +1,53 @@
+package mongodb
+
+import "log/slog"
+
+// Option customizes a manager created by Open or NewManagerWithConfig
+type Option func(*manager)
+
+// WithConnectRetry overrides the connect retry policy from the configuration
+func WithConnectRetry(retry ConnectRetryConfig) Option {
+	return func(m *manager) {
+		m.config.ConnectRetry = retry
+	}
+}
+
+// WithLogger enables command logging and writes it to logger instead of slog.Default()
+//
+// The level, per command overrides and body length are taken from the logging configuration.
+func WithLogger(logger *slog.Logger) Option {
+	return func(m *manager) {
+		m.logger = logger
+		m.config.Logging.Enabled = true
+	}
+}
+
+// WithSlowQuerySink enables slow query detection and delivers the reports to sink
+//
+// The threshold, explain and ignored commands are taken from the slow query configuration.
+func WithSlowQuerySink(sink SlowQuerySink) Option {
+	return func(m *manager) {
+		m.slowQuerySink = sink
+		m.config.SlowQuery.Enabled = true
+	}
+}
+
+// WithMetrics records command, connection pool and heartbeat metrics to recorder
+func WithMetrics(recorder MetricsRecorder) Option {
+	return func(m *manager) {
+		m.metrics = newMetricsCollector(recorder)
+	}
+}
+
+// WithTracer starts a span with tracer for every command sent by the manager
+//
+// A nil tracer leaves tracing disabled.
+func WithTracer(tracer Tracer) Option {
+	return func(m *manager) {
+		if tracer == nil {
+			m.tracer = nil
+			return
+		}
+		m.tracer = newCommandTracer(tracer)
+	}
+}
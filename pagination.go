@@ -0,0 +1,368 @@
+package mongodb
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PageQuery selects a page of documents ordered by a sort on one or more fields
+type PageQuery struct {
+	Filter interface{} // Documents to page through, nil matches every document
+	Sort   bson.D      // Fields and directions (exactly 1 or -1), _id is appended as a tie-breaker when missing
+	Size   int         // Documents per page (0 = default_page_size), capped at max_page_size
+	Token  string      // Next or Previous token of a page, empty for the first page
+}
+
+// Page is a page of documents with the tokens of its neighbours
+type Page[T any] struct {
+	Items    []T
+	Next     string // Token of the following page, empty on the last page
+	Previous string // Token of the preceding page, empty on the first page
+}
+
+// pageToken is the signed position a page starts after
+type pageToken struct {
+	Backward   bool            `bson:"b"` // Page precedes the position
+	Collection string          `bson:"c"` // Collection the position belongs to
+	Filter     []byte          `bson:"f"` // Hash of the filter the position belongs to
+	Sort       string          `bson:"s"` // Sort the position belongs to
+	Values     []bson.RawValue `bson:"v"` // Sort field values of the document at the position
+}
+
+// processPageSecret signs page tokens when pagination.secret is not set.
+//
+// It is random and generated once per process, so such tokens cannot be forged but are
+// rejected by other processes and after a restart. A warning is logged when it is created,
+// since deployments running several instances must configure a shared secret.
+var processPageSecret = sync.OnceValue(func() []byte {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic("failed to generate the MongoDB page token secret: " + err.Error())
+	}
+	slog.Default().Warn("MongoDB pagination.secret is not set, page tokens are signed with a random per-process key and are rejected by other instances and after a restart")
+	return secret
+})
+
+// secret returns the key signing page tokens
+func (c PaginationConfig) secret() []byte {
+	if c.Secret == "" {
+		return processPageSecret()
+	}
+	return []byte(c.Secret)
+}
+
+// validate checks the page sizes
+func (c PaginationConfig) validate() error {
+	if c.DefaultPageSize < 0 || c.MaxPageSize < 0 {
+		return fmt.Errorf("pagination: page sizes must not be negative")
+	}
+	if c.MaxPageSize > 0 && c.DefaultPageSize > c.MaxPageSize {
+		return fmt.Errorf("pagination: default_page_size must not exceed max_page_size")
+	}
+	return nil
+}
+
+// pageSize returns the size of a page, applying the default and maximum
+func (c PaginationConfig) pageSize(size int) int {
+	if size <= 0 {
+		size = c.DefaultPageSize
+	}
+	if size <= 0 {
+		size = DefaultPageSize
+	}
+	if c.MaxPageSize > 0 && size > c.MaxPageSize {
+		size = c.MaxPageSize
+	}
+	return size
+}
+
+// Paginate returns a page of documents of the named collection using keyset pagination.
+//
+// Pages continue after the sort values of the last document of the previous page instead
+// of skipping documents, so the cost of a page does not grow with its position. The sort
+// fields should be covered by an index ending with _id. Tokens are base64 encoded and
+// signed with pagination.secret, or a random per-process key when it is not set; a token
+// that was altered or belongs to another collection, filter or sort is rejected with an
+// error wrapping ErrInvalidPageToken. An invalid sort or filter is reported with an error
+// wrapping ErrInvalidPageQuery.
+//
+// Every document must have a non-null value of the same BSON type for each sort field.
+// Pages continue with $gt and $lt on the sort values, which only match values of the
+// same type, so documents where a sort field is missing, null or of another type are
+// skipped or repeated.
+func Paginate[T any](ctx context.Context, m Manager, collection string, query PageQuery) (*Page[T], error) {
+	config := m.Config().Pagination
+	secret := config.secret()
+
+	sort, err := pageSort(query.Sort)
+	if err != nil {
+		return nil, err
+	}
+	filterHash, err := pageFilterHash(orEmptyFilter(query.Filter))
+	if err != nil {
+		return nil, err
+	}
+	scope := pageToken{Collection: collection, Filter: filterHash, Sort: sortSignature(sort)}
+
+	var after *pageToken
+	if query.Token != "" {
+		if after, err = decodePageToken(query.Token, secret, scope, len(sort)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := m.Connect(ctx); err != nil {
+		return nil, err
+	}
+
+	backward := after != nil && after.Backward
+	filter := orEmptyFilter(query.Filter)
+	if after != nil {
+		filter = bson.D{{Key: "$and", Value: bson.A{filter, keysetFilter(sort, after.Values, backward)}}}
+	}
+	findSort := sort
+	if backward {
+		findSort = reverseSort(sort)
+	}
+	size := config.pageSize(query.Size)
+
+	cursor, err := m.Collection(collection).Find(ctx, filter, options.Find().SetSort(findSort).SetLimit(int64(size)+1))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	page := &Page[T]{Items: make([]T, 0, size)}
+	var first, last bson.Raw
+	more := false
+	for cursor.Next(ctx) {
+		if len(page.Items) == size {
+			more = true
+			break
+		}
+		var item T
+		if err := cursor.Decode(&item); err != nil {
+			return nil, err
+		}
+		page.Items = append(page.Items, item)
+		if first == nil {
+			first = append(bson.Raw{}, cursor.Current...)
+		}
+		last = append(last[:0], cursor.Current...)
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	if backward {
+		// Documents were read in reverse order
+		for i, j := 0, len(page.Items)-1; i < j; i, j = i+1, j-1 {
+			page.Items[i], page.Items[j] = page.Items[j], page.Items[i]
+		}
+		first, last = last, first
+	}
+	if len(page.Items) == 0 {
+		return page, nil
+	}
+
+	// A page reached through a token has a neighbour on the side it came from
+	hasNext, hasPrevious := more, after != nil
+	if backward {
+		hasNext, hasPrevious = true, more
+	}
+	if hasNext {
+		next := scope
+		next.Values = sortValues(last, sort)
+		if page.Next, err = encodePageToken(next, secret); err != nil {
+			return nil, err
+		}
+	}
+	if hasPrevious {
+		previous := scope
+		previous.Backward, previous.Values = true, sortValues(first, sort)
+		if page.Previous, err = encodePageToken(previous, secret); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// Page returns a page of documents using keyset pagination, see Paginate
+func (r *Repository[T]) Page(ctx context.Context, query PageQuery) (*Page[T], error) {
+	return Paginate[T](ctx, r.manager, r.collection, query)
+}
+
+// pageSort validates the sort directions and appends _id when it is missing
+func pageSort(sort bson.D) (bson.D, error) {
+	normalized := make(bson.D, 0, len(sort)+1)
+	hasID := false
+	for _, elem := range sort {
+		var direction int
+		switch value := elem.Value.(type) {
+		case int:
+			direction = value
+		case int32:
+			direction = int(value)
+		case int64:
+			direction = int(value)
+		case float64:
+			// 1.5 must not pass as 1
+			if value == 1 || value == -1 {
+				direction = int(value)
+			}
+		}
+		if elem.Key == "" || (direction != 1 && direction != -1) {
+			return nil, fmt.Errorf("%w: sort %q must have direction 1 or -1", ErrInvalidPageQuery, elem.Key)
+		}
+		hasID = hasID || elem.Key == "_id"
+		normalized = append(normalized, bson.E{Key: elem.Key, Value: direction})
+	}
+	if !hasID {
+		normalized = append(normalized, bson.E{Key: "_id", Value: 1})
+	}
+	return normalized, nil
+}
+
+// pageFilterHash identifies a filter in page tokens
+//
+// Keys are sorted first, so that a bson.M filter hashes the same on every call.
+func pageFilterHash(filter interface{}) ([]byte, error) {
+	data, err := bson.Marshal(filter)
+	if err != nil {
+		return nil, fmt.Errorf("%w: filter: %w", ErrInvalidPageQuery, err)
+	}
+	var doc bson.D
+	if err := bson.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: filter: %w", ErrInvalidPageQuery, err)
+	}
+	if data, err = bson.Marshal(sortedKeys(doc)); err != nil {
+		return nil, fmt.Errorf("%w: filter: %w", ErrInvalidPageQuery, err)
+	}
+	sum := sha256.Sum256(data)
+	return sum[:], nil
+}
+
+// sortedKeys orders the keys of a document and of the documents it contains
+func sortedKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case bson.D:
+		doc := make(bson.D, len(v))
+		for i, elem := range v {
+			doc[i] = bson.E{Key: elem.Key, Value: sortedKeys(elem.Value)}
+		}
+		sort.SliceStable(doc, func(i, j int) bool { return doc[i].Key < doc[j].Key })
+		return doc
+	case bson.A:
+		values := make(bson.A, len(v))
+		for i, elem := range v {
+			values[i] = sortedKeys(elem)
+		}
+		return values
+	}
+	return value
+}
+
+// sortSignature identifies a sort in page tokens
+func sortSignature(sort bson.D) string {
+	fields := make([]string, len(sort))
+	for i, elem := range sort {
+		fields[i] = elem.Key + ":" + strconv.Itoa(elem.Value.(int))
+	}
+	return strings.Join(fields, ",")
+}
+
+// reverseSort inverts every direction of a sort
+func reverseSort(sort bson.D) bson.D {
+	reversed := make(bson.D, len(sort))
+	for i, elem := range sort {
+		reversed[i] = bson.E{Key: elem.Key, Value: -elem.Value.(int)}
+	}
+	return reversed
+}
+
+// keysetFilter matches the documents after values in the sort order, or before them when backward.
+//
+// For sort (a, b, _id) it yields {$or: [{a: >va}, {a: va, b: >vb}, {a: va, b: vb, _id: >vid}]}.
+func keysetFilter(sort bson.D, values []bson.RawValue, backward bool) bson.D {
+	branches := make(bson.A, 0, len(sort))
+	for i, elem := range sort {
+		branch := make(bson.D, 0, i+1)
+		for j := 0; j < i; j++ {
+			branch = append(branch, bson.E{Key: sort[j].Key, Value: values[j]})
+		}
+		op := "$gt"
+		if (elem.Value.(int) < 0) != backward {
+			op = "$lt"
+		}
+		branch = append(branch, bson.E{Key: elem.Key, Value: bson.D{{Key: op, Value: values[i]}}})
+		branches = append(branches, branch)
+	}
+	return bson.D{{Key: "$or", Value: branches}}
+}
+
+// sortValues returns the values of the sort fields of a document, null for missing fields
+func sortValues(doc bson.Raw, sort bson.D) []bson.RawValue {
+	values := make([]bson.RawValue, len(sort))
+	for i, elem := range sort {
+		value, err := doc.LookupErr(strings.Split(elem.Key, ".")...)
+		if err != nil {
+			value = bson.RawValue{Type: bsontype.Null}
+		}
+		values[i] = value
+	}
+	return values
+}
+
+// encodePageToken signs a token with HMAC-SHA256 and encodes it as URL-safe base64
+func encodePageToken(token pageToken, secret []byte) (string, error) {
+	data, err := bson.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(data)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(data)), nil
+}
+
+// decodePageToken verifies the signature of a token and that it belongs to the collection,
+// filter and sort of scope
+func decodePageToken(encoded string, secret []byte, scope pageToken, fields int) (*pageToken, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(raw) <= sha256.Size {
+		return nil, fmt.Errorf("%w: malformed", ErrInvalidPageToken)
+	}
+	data, sum := raw[:len(raw)-sha256.Size], raw[len(raw)-sha256.Size:]
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(data)
+	if !hmac.Equal(sum, mac.Sum(nil)) {
+		return nil, fmt.Errorf("%w: signature mismatch", ErrInvalidPageToken)
+	}
+
+	var token pageToken
+	if err := bson.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPageToken, err)
+	}
+	switch {
+	case token.Collection != scope.Collection:
+		return nil, fmt.Errorf("%w: token belongs to collection %q", ErrInvalidPageToken, token.Collection)
+	case !bytes.Equal(token.Filter, scope.Filter):
+		return nil, fmt.Errorf("%w: token belongs to another filter", ErrInvalidPageToken)
+	case token.Sort != scope.Sort || len(token.Values) != fields:
+		return nil, fmt.Errorf("%w: token belongs to sort %q", ErrInvalidPageToken, token.Sort)
+	}
+	return &token, nil
+}
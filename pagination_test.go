@@ -0,0 +1,225 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestPageSort(t *testing.T) {
+	sort, err := pageSort(bson.D{{Key: "score", Value: -1}, {Key: "name", Value: int32(1)}})
+	assert.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "score", Value: -1}, {Key: "name", Value: 1}, {Key: "_id", Value: 1}}, sort)
+	assert.Equal(t, "score:-1,name:1,_id:1", sortSignature(sort))
+	assert.Equal(t, bson.D{{Key: "score", Value: 1}, {Key: "name", Value: -1}, {Key: "_id", Value: -1}}, reverseSort(sort))
+
+	sort, err = pageSort(bson.D{{Key: "_id", Value: -1}})
+	assert.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "_id", Value: -1}}, sort)
+
+	_, err = pageSort(bson.D{{Key: "score", Value: "desc"}})
+	assert.ErrorIs(t, err, ErrInvalidPageQuery)
+
+	sort, err = pageSort(bson.D{{Key: "score", Value: -1.0}})
+	assert.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "score", Value: -1}, {Key: "_id", Value: 1}}, sort)
+
+	for _, direction := range []interface{}{1.5, -0.5, 2, int64(-2)} {
+		_, err = pageSort(bson.D{{Key: "score", Value: direction}})
+		assert.ErrorIs(t, err, ErrInvalidPageQuery, "direction %v", direction)
+	}
+}
+
+func TestPageFilterHash(t *testing.T) {
+	ordered, err := pageFilterHash(bson.D{{Key: "status", Value: "active"}, {Key: "age", Value: bson.D{{Key: "$gt", Value: 18}, {Key: "$lt", Value: 65}}}})
+	assert.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		hash, err := pageFilterHash(bson.M{"age": bson.M{"$lt": 65, "$gt": 18}, "status": "active"})
+		assert.NoError(t, err)
+		assert.Equal(t, ordered, hash, "map filters hash the same whatever the key order")
+	}
+
+	other, err := pageFilterHash(bson.D{{Key: "status", Value: "archived"}})
+	assert.NoError(t, err)
+	assert.NotEqual(t, ordered, other)
+
+	_, err = pageFilterHash("status")
+	assert.ErrorIs(t, err, ErrInvalidPageQuery)
+}
+
+func TestPaginationConfig_Secret(t *testing.T) {
+	assert.Equal(t, []byte("secret"), PaginationConfig{Secret: "secret"}.secret())
+
+	generated := PaginationConfig{}.secret()
+	assert.Len(t, generated, 32)
+	assert.Equal(t, generated, PaginationConfig{}.secret(), "the generated secret is kept for the process")
+}
+
+func TestKeysetFilter(t *testing.T) {
+	sort := bson.D{{Key: "score", Value: -1}, {Key: "_id", Value: 1}}
+	values := []bson.RawValue{{Type: bson.TypeInt32, Value: []byte{5, 0, 0, 0}}, {Type: bson.TypeInt32, Value: []byte{9, 0, 0, 0}}}
+
+	assert.Equal(t, bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "score", Value: bson.D{{Key: "$lt", Value: values[0]}}}},
+		bson.D{{Key: "score", Value: values[0]}, {Key: "_id", Value: bson.D{{Key: "$gt", Value: values[1]}}}},
+	}}}, keysetFilter(sort, values, false))
+
+	assert.Equal(t, bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "score", Value: bson.D{{Key: "$gt", Value: values[0]}}}},
+		bson.D{{Key: "score", Value: values[0]}, {Key: "_id", Value: bson.D{{Key: "$lt", Value: values[1]}}}},
+	}}}, keysetFilter(sort, values, true))
+}
+
+func TestPageToken(t *testing.T) {
+	secret := []byte("secret")
+	doc, err := bson.Marshal(bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "profile", Value: bson.D{{Key: "age", Value: 42}}}})
+	assert.NoError(t, err)
+	sort := bson.D{{Key: "profile.age", Value: 1}, {Key: "missing", Value: 1}, {Key: "_id", Value: 1}}
+	values := sortValues(doc, sort)
+	assert.Equal(t, int32(42), values[0].Int32())
+	assert.Equal(t, bson.TypeNull, values[1].Type)
+
+	filter, err := pageFilterHash(bson.D{{Key: "status", Value: "active"}})
+	assert.NoError(t, err)
+	scope := pageToken{Collection: "users", Filter: filter, Sort: sortSignature(sort)}
+	position := scope
+	position.Backward, position.Values = true, values
+	encoded, err := encodePageToken(position, secret)
+	assert.NoError(t, err)
+
+	token, err := decodePageToken(encoded, secret, scope, len(sort))
+	assert.NoError(t, err)
+	assert.True(t, token.Backward)
+	assert.Equal(t, values[2].ObjectID(), token.Values[2].ObjectID())
+
+	t.Run("altered", func(t *testing.T) {
+		altered := []byte(encoded)
+		altered[10] ^= 1
+		_, err := decodePageToken(string(altered), secret, scope, len(sort))
+		assert.ErrorIs(t, err, ErrInvalidPageToken)
+	})
+
+	t.Run("other secret", func(t *testing.T) {
+		_, err := decodePageToken(encoded, []byte("other"), scope, len(sort))
+		assert.ErrorIs(t, err, ErrInvalidPageToken)
+	})
+
+	t.Run("other sort", func(t *testing.T) {
+		other := scope
+		other.Sort = "_id:1"
+		_, err := decodePageToken(encoded, secret, other, 1)
+		assert.ErrorIs(t, err, ErrInvalidPageToken)
+	})
+
+	t.Run("other collection", func(t *testing.T) {
+		other := scope
+		other.Collection = "admins"
+		_, err := decodePageToken(encoded, secret, other, len(sort))
+		assert.ErrorContains(t, err, `token belongs to collection "users"`)
+	})
+
+	t.Run("other filter", func(t *testing.T) {
+		hash, err := pageFilterHash(bson.D{})
+		assert.NoError(t, err)
+		other := scope
+		other.Filter = hash
+		_, err = decodePageToken(encoded, secret, other, len(sort))
+		assert.ErrorContains(t, err, "token belongs to another filter")
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err := decodePageToken("not a token!", secret, scope, len(sort))
+		assert.ErrorIs(t, err, ErrInvalidPageToken)
+	})
+}
+
+func TestPaginationConfig_PageSize(t *testing.T) {
+	assert.Equal(t, DefaultPageSize, PaginationConfig{}.pageSize(0))
+	assert.Equal(t, 10, PaginationConfig{DefaultPageSize: 10}.pageSize(0))
+	assert.Equal(t, 50, PaginationConfig{MaxPageSize: 50}.pageSize(500))
+	assert.Equal(t, 500, PaginationConfig{}.pageSize(500))
+}
+
+func TestPaginate(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	ctx := context.Background()
+	cfg := Config{URI: "mongodb://localhost:27017", Database: "testdb", Pagination: PaginationConfig{Secret: "secret", DefaultPageSize: 2}}
+	ids := []primitive.ObjectID{primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()}
+	user := func(i int, email string) bson.D {
+		return bson.D{{Key: "_id", Value: ids[i]}, {Key: "email", Value: email}}
+	}
+	sort := bson.D{{Key: "email", Value: 1}}
+
+	var next string
+	mt.Run("first page", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "testdb.users", mtest.FirstBatch,
+			user(0, "a@example.com"), user(1, "b@example.com"), user(2, "b@example.com")))
+		m := createTestManager(mt, cfg)
+
+		page, err := Paginate[testUser](ctx, m, "users", PageQuery{Sort: sort})
+		assert.NoError(t, err)
+		assert.Equal(t, []testUser{{ID: ids[0], Email: "a@example.com"}, {ID: ids[1], Email: "b@example.com"}}, page.Items)
+		assert.NotEmpty(t, page.Next)
+		assert.Empty(t, page.Previous)
+		next = page.Next
+
+		command := mt.GetStartedEvent().Command
+		assert.Equal(t, int64(3), command.Lookup("limit").AsInt64())
+		assert.Equal(t, "email", command.Lookup("sort").Document().Index(0).Key())
+		assert.Equal(t, "_id", command.Lookup("sort").Document().Index(1).Key())
+	})
+
+	var previous string
+	mt.Run("next page", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "testdb.users", mtest.FirstBatch, user(2, "b@example.com")))
+		m := createTestManager(mt, cfg)
+
+		page, err := NewRepository[testUser](m, "users").Page(ctx, PageQuery{Sort: sort, Token: next})
+		assert.NoError(t, err)
+		assert.Equal(t, []testUser{{ID: ids[2], Email: "b@example.com"}}, page.Items)
+		assert.Empty(t, page.Next)
+		assert.NotEmpty(t, page.Previous)
+		previous = page.Previous
+
+		// The tie on email is broken by _id
+		tieBreak := mt.GetStartedEvent().Command.Lookup("filter", "$and", "1", "$or", "1").Document()
+		assert.Equal(t, "b@example.com", tieBreak.Lookup("email").StringValue())
+		assert.Equal(t, ids[1], tieBreak.Lookup("_id", "$gt").ObjectID())
+	})
+
+	mt.Run("previous page", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "testdb.users", mtest.FirstBatch,
+			user(1, "b@example.com"), user(0, "a@example.com")))
+		m := createTestManager(mt, cfg)
+
+		page, err := Paginate[testUser](ctx, m, "users", PageQuery{Sort: sort, Token: previous})
+		assert.NoError(t, err)
+		assert.Equal(t, []testUser{{ID: ids[0], Email: "a@example.com"}, {ID: ids[1], Email: "b@example.com"}}, page.Items)
+		assert.NotEmpty(t, page.Next)
+		assert.Empty(t, page.Previous)
+
+		command := mt.GetStartedEvent().Command
+		assert.Equal(t, int32(-1), command.Lookup("sort", "email").Int32())
+		assert.Equal(t, ids[2], command.Lookup("filter", "$and", "1", "$or", "1", "_id", "$lt").ObjectID())
+	})
+
+	mt.Run("token of another sort", func(mt *mtest.T) {
+		m := createTestManager(mt, cfg)
+
+		_, err := Paginate[testUser](ctx, m, "users", PageQuery{Sort: bson.D{{Key: "email", Value: -1}}, Token: next})
+		assert.ErrorIs(t, err, ErrInvalidPageToken)
+	})
+	mt.Run("token of another collection or filter", func(mt *mtest.T) {
+		m := createTestManager(mt, cfg)
+
+		_, err := Paginate[testUser](ctx, m, "admins", PageQuery{Sort: sort, Token: next})
+		assert.ErrorIs(t, err, ErrInvalidPageToken)
+		_, err = Paginate[testUser](ctx, m, "users", PageQuery{Filter: bson.D{{Key: "email", Value: "a@example.com"}}, Sort: sort, Token: next})
+		assert.ErrorIs(t, err, ErrInvalidPageToken)
+		assert.Nil(t, mt.GetStartedEvent())
+	})
+}
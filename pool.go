@@ -0,0 +1,119 @@
+package mongodb
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// PoolStats holds the connection pool statistics of a server
+type PoolStats struct {
+	Address             string        // Server address
+	Open                int           // Connections open, in use or idle
+	InUse               int           // Connections checked out
+	Idle                int           // Open connections available for checkout
+	Pending             int           // Checkouts waiting for a connection
+	Checkouts           int64         // Successful checkouts since the pool was created
+	WaitQueueTimeouts   int64         // Checkouts that timed out waiting for a connection
+	AverageCheckoutTime time.Duration // Average time a successful checkout waited for a connection
+}
+
+// serverPool accumulates the pool events of a server
+type serverPool struct {
+	open              int
+	inUse             int
+	pending           int
+	checkouts         int64
+	waitQueueTimeouts int64
+	checkoutTime      time.Duration
+}
+
+// stats returns the statistics of the pool
+func (p *serverPool) stats(address string) PoolStats {
+	stats := PoolStats{
+		Address:           address,
+		Open:              p.open,
+		InUse:             p.inUse,
+		Idle:              max(p.open-p.inUse, 0),
+		Pending:           p.pending,
+		Checkouts:         p.checkouts,
+		WaitQueueTimeouts: p.waitQueueTimeouts,
+	}
+	if p.checkouts > 0 {
+		stats.AverageCheckoutTime = p.checkoutTime / time.Duration(p.checkouts)
+	}
+	return stats
+}
+
+// poolTracker maintains per server pool statistics from pool events.
+//
+// The zero value is ready to use.
+type poolTracker struct {
+	mu    sync.Mutex
+	pools map[string]*serverPool // keyed by server address
+}
+
+// event applies a pool event and returns the statistics of its server afterwards
+func (t *poolTracker) event(evt *event.PoolEvent) PoolStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if evt.Type == event.PoolClosedEvent {
+		delete(t.pools, evt.Address)
+		return PoolStats{Address: evt.Address}
+	}
+
+	if t.pools == nil {
+		t.pools = make(map[string]*serverPool)
+	}
+	pool, ok := t.pools[evt.Address]
+	if !ok {
+		pool = &serverPool{}
+		t.pools[evt.Address] = pool
+	}
+
+	switch evt.Type {
+	case event.ConnectionCreated:
+		pool.open++
+	case event.ConnectionClosed:
+		pool.open--
+	case event.GetStarted:
+		pool.pending++
+	case event.GetSucceeded:
+		pool.pending--
+		pool.inUse++
+		pool.checkouts++
+		pool.checkoutTime += evt.Duration
+	case event.GetFailed:
+		pool.pending--
+		if evt.Reason == event.ReasonTimedOut {
+			pool.waitQueueTimeouts++
+		}
+	case event.ConnectionReturned:
+		pool.inUse--
+	}
+	return pool.stats(evt.Address)
+}
+
+// stats returns the statistics of every tracked server sorted by address
+func (t *poolTracker) stats() []PoolStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := make([]PoolStats, 0, len(t.pools))
+	for address, pool := range t.pools {
+		stats = append(stats, pool.stats(address))
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Address < stats[j].Address })
+	return stats
+}
+
+// PoolStats returns the connection pool statistics of every server the client is connected to
+//
+// The statistics are maintained from pool events of the current client, sorted by server
+// address, and empty before the manager connects.
+func (m *manager) PoolStats() []PoolStats {
+	return m.pools.stats()
+}
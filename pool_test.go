@@ -0,0 +1,58 @@
+package mongodb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+func TestPoolTracker(t *testing.T) {
+	var tracker poolTracker
+	assert.Empty(t, tracker.stats())
+
+	for _, evt := range []*event.PoolEvent{
+		{Type: event.PoolCreated, Address: "db-2:27017"},
+		{Type: event.ConnectionCreated, Address: "db-1:27017"},
+		{Type: event.ConnectionCreated, Address: "db-1:27017"},
+		{Type: event.GetStarted, Address: "db-1:27017"},
+		{Type: event.GetSucceeded, Address: "db-1:27017", Duration: 2 * time.Millisecond},
+		{Type: event.GetStarted, Address: "db-1:27017"},
+		{Type: event.GetSucceeded, Address: "db-1:27017", Duration: 4 * time.Millisecond},
+		{Type: event.GetStarted, Address: "db-1:27017"},
+		{Type: event.GetFailed, Address: "db-1:27017", Reason: event.ReasonTimedOut},
+		{Type: event.GetStarted, Address: "db-1:27017"},
+		{Type: event.ConnectionReturned, Address: "db-1:27017"},
+	} {
+		tracker.event(evt)
+	}
+
+	assert.Equal(t, []PoolStats{
+		{
+			Address:             "db-1:27017",
+			Open:                2,
+			InUse:               1,
+			Idle:                1,
+			Pending:             1,
+			Checkouts:           2,
+			WaitQueueTimeouts:   1,
+			AverageCheckoutTime: 3 * time.Millisecond,
+		},
+		{Address: "db-2:27017"},
+	}, tracker.stats())
+
+	stats := tracker.event(&event.PoolEvent{Type: event.PoolClosedEvent, Address: "db-1:27017"})
+	assert.Equal(t, PoolStats{Address: "db-1:27017"}, stats)
+	assert.Equal(t, []PoolStats{{Address: "db-2:27017"}}, tracker.stats())
+}
+
+func TestManager_PoolStats(t *testing.T) {
+	mgr, err := newManager(Config{URI: "mongodb://localhost:27017"})
+	assert.NoError(t, err)
+	assert.Empty(t, mgr.PoolStats())
+
+	monitor := mgr.poolMonitor()
+	monitor.Event(&event.PoolEvent{Type: event.ConnectionCreated, Address: "localhost:27017"})
+	assert.Equal(t, []PoolStats{{Address: "localhost:27017", Open: 1, Idle: 1}}, mgr.PoolStats())
+}
@@ -1,6 +1,10 @@
 package mongodb
 
 import (
+	"context"
+	"fmt"
+	"sync"
+
 	"go.fork.vn/config"
 	"go.fork.vn/di"
 )
@@ -11,6 +15,9 @@ import (
 // các phương thức cần thiết cho một MongoDB service provider.
 type ServiceProvider interface {
 	di.ServiceProvider
+
+	// Shutdown đóng tất cả các kết nối MongoDB một cách an toàn khi ứng dụng dừng.
+	Shutdown(ctx context.Context) error
 }
 
 // serviceProvider là implementation của ServiceProvider.
@@ -18,7 +25,12 @@ type ServiceProvider interface {
 // serviceProvider chịu trách nhiệm đăng ký các dịch vụ MongoDB vào DI container
 // và cung cấp MongoDB client cho các module khác trong ứng dụng.
 type serviceProvider struct {
-	providers []string
+	providers   []string
+	connections Connections
+
+	// warmUp theo dõi các goroutine kết nối nền được khởi động bởi Boot
+	warmUp       sync.WaitGroup
+	cancelWarmUp context.CancelFunc
 }
 
 // NewServiceProvider tạo một MongoDB service provider mới.
@@ -28,9 +40,16 @@ func NewServiceProvider() ServiceProvider {
 
 // Register đăng ký các dịch vụ MongoDB với DI container.
 //
-// Phương thức này đăng ký MongoDB manager vào container DI của ứng dụng.
-// Nó khởi tạo một MongoDB manager mới và đăng ký nó dưới các alias "mongodb",
-// "mongo.client" và "mongo".
+// Phương thức này đọc cấu hình "mongodb" cùng các kết nối có tên trong "mongodb.connections",
+// tạo registry Connections và đăng ký vào container DI:
+//   - "mongodb": Manager của kết nối mặc định
+//   - "mongodb.connections": registry Connections
+//   - "mongodb.<name>": Manager của từng kết nối có tên
+//   - "mongodb.client", "mongodb.database": client và database của kết nối mặc định
+//
+// Register không kết nối tới MongoDB: client và database được đăng ký dưới dạng singleton
+// lazy và chỉ kết nối khi được resolve lần đầu. Việc kết nối sớm được thực hiện trong Boot
+// tùy theo connect_mode.
 //
 // Params:
 //   - app: Interface của ứng dụng, phải cung cấp phương thức Container() để lấy container DI
@@ -46,48 +65,116 @@ func (p *serviceProvider) Register(app di.Application) {
 	}
 
 	// Kiểm tra xem container đã có config manager chưa
-	mongoConfig := DefaultConfig()
 	configService, ok := c.MustMake("config").(config.Manager)
 	if !ok {
 		panic("MongoDB provider requires config service to be registered")
 	}
-	err := configService.UnmarshalKey("mongodb", &mongoConfig)
+	configs, defaultName, err := loadConnectionConfigs(configService)
 	if err != nil {
 		panic("MongoDB config unmarshal error: " + err.Error())
 	}
 
-	// Tạo MongoDB manager với cấu hình
-	manager := NewManagerWithConfig(*mongoConfig) // Đăng ký manager vào container
+	// Tạo registry các kết nối, mỗi kết nối kết nối lazy khi được sử dụng lần đầu
+	conns, err := NewConnections(configs, defaultName)
+	if err != nil {
+		panic(err.Error())
+	}
+	manager := conns.Default()
 	c.Instance("mongodb", manager)
+	c.Instance("mongodb.connections", conns)
+	p.providers = append(p.providers, "mongodb", "mongodb.connections")
 
-	// Đăng ký client và database instances (sẽ sử dụng lazy initialization trong manager)
-	client := manager.Client()
-	c.Instance("mongodb.client", client)
+	// Đăng ký Manager của từng kết nối có tên
+	for _, name := range conns.Names() {
+		c.Instance("mongodb."+name, conns.Connection(name))
+		p.providers = append(p.providers, "mongodb."+name)
+	}
 
-	database := manager.Database()
-	c.Instance("mongodb.database", database)
+	// Đăng ký client và database của kết nối mặc định, chỉ kết nối khi được resolve
+	c.Singleton("mongodb.client", func(c di.Container) interface{} {
+		return manager.Client()
+	})
+	c.Singleton("mongodb.database", func(c di.Container) interface{} {
+		return manager.Database()
+	})
 
-	// Add to providers list - the test expects these specific entries
-	p.providers = append(p.providers, "mongodb")
-	p.providers = append(p.providers, "mongodb.client")
-	p.providers = append(p.providers, "mongodb.database")
+	p.connections = conns
 
+	// Đăng ký hook đóng kết nối nếu ứng dụng hỗ trợ
+	if registrar, ok := app.(ShutdownHookRegistrar); ok {
+		registrar.OnShutdown(p.Shutdown)
+	}
+
+	p.providers = append(p.providers, "mongodb.client", "mongodb.database")
 }
 
 // Boot khởi động MongoDB provider.
 //
-// Phương thức này khởi động MongoDB provider sau khi tất cả các service provider đã được đăng ký.
-// Trong trường hợp này, không cần thực hiện thêm tác vụ nào trong Boot vì các cấu hình
-// đã được xử lý trong Register.
+// Phương thức này thiết lập kết nối cho từng kết nối theo connect_mode của nó:
+//   - lazy (mặc định): không làm gì, kết nối khi được sử dụng lần đầu
+//   - eager: kết nối ngay và panic nếu không thể kết nối, làm dừng quá trình khởi động
+//   - background: bắt đầu kết nối trong goroutine riêng mà không chặn quá trình khởi động
 //
 // Params:
 //   - app: di.Application của ứng dụng
 func (p *serviceProvider) Boot(app di.Application) {
-	// Không cần thực hiện thêm tác vụ nào trong Boot
-	// vì cấu hình đã được xử lý trong Register
 	if app == nil {
 		panic("application cannot be nil")
 	}
+	// Register chưa được gọi, không có kết nối nào cần thiết lập
+	if p.connections == nil {
+		return
+	}
+
+	warmUpCtx, cancel := context.WithCancel(context.Background())
+	p.cancelWarmUp = cancel
+
+	for _, name := range p.connections.Names() {
+		manager := p.connections.Connection(name)
+		switch manager.Config().ConnectMode {
+		case ConnectModeEager:
+			if err := manager.Connect(context.Background()); err != nil {
+				panic(fmt.Sprintf("MongoDB connection %q failed: %s", name, err.Error()))
+			}
+		case ConnectModeBackground:
+			// Lỗi được bỏ qua, lần sử dụng tiếp theo sẽ kết nối lại
+			p.warmUp.Add(1)
+			go func() {
+				defer p.warmUp.Done()
+				_ = manager.Connect(warmUpCtx)
+			}()
+		}
+	}
+}
+
+// Shutdown đóng tất cả các kết nối MongoDB một cách an toàn.
+//
+// Phương thức này dừng các goroutine kết nối nền, đóng các change stream và session đang được
+// theo dõi, chờ các thao tác đang thực hiện hoàn tất rồi ngắt kết nối mọi client. Nếu ctx
+// không có deadline, DefaultDisconnectTimeout được sử dụng.
+//
+// Params:
+//   - ctx: context giới hạn thời gian chờ đóng kết nối
+//
+// Trả về:
+//   - error: lỗi gộp từ các kết nối không thể đóng an toàn
+func (p *serviceProvider) Shutdown(ctx context.Context) error {
+	if p.cancelWarmUp != nil {
+		p.cancelWarmUp()
+	}
+	p.warmUp.Wait()
+
+	// Register chưa được gọi, không có kết nối nào cần đóng
+	if p.connections == nil {
+		return nil
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultDisconnectTimeout)
+		defer cancel()
+	}
+	return p.connections.Shutdown(ctx)
 }
 
 // Providers trả về danh sách các service được cung cấp bởi MongoDB provider.
@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	config_mocks "go.fork.vn/config/mocks"
+	"go.fork.vn/di"
 	di_mocks "go.fork.vn/di/mocks"
 	"go.fork.vn/mongodb"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -80,11 +81,6 @@ func TestNewServiceProvider(t *testing.T) {
 
 func TestServiceProvider_Register(t *testing.T) {
 	t.Run("registers mongodb services to container with config", func(t *testing.T) {
-		// Skip test if MongoDB is not available
-		if !isMongoDBAvailable() {
-			t.Skip("MongoDB not available, skipping integration test")
-		}
-
 		// Arrange
 		mockContainer := di_mocks.NewMockContainer(t)
 		mockConfig := config_mocks.NewMockManager(t)
@@ -100,8 +96,10 @@ func TestServiceProvider_Register(t *testing.T) {
 			}
 		}).Return(nil)
 		mockContainer.On("Instance", "mongodb", mock.Anything).Return(nil)
-		mockContainer.On("Instance", "mongodb.client", mock.Anything).Return(nil)
-		mockContainer.On("Instance", "mongodb.database", mock.Anything).Return(nil)
+		mockContainer.On("Instance", "mongodb.connections", mock.Anything).Return(nil)
+		mockContainer.On("Instance", "mongodb.default", mock.Anything).Return(nil)
+		mockContainer.On("Singleton", "mongodb.client", mock.Anything).Return(nil)
+		mockContainer.On("Singleton", "mongodb.database", mock.Anything).Return(nil)
 
 		provider := mongodb.NewServiceProvider()
 
@@ -112,8 +110,10 @@ func TestServiceProvider_Register(t *testing.T) {
 
 		// Verify that the container methods were called
 		mockContainer.AssertCalled(t, "Instance", "mongodb", mock.Anything)
-		mockContainer.AssertCalled(t, "Instance", "mongodb.client", mock.Anything)
-		mockContainer.AssertCalled(t, "Instance", "mongodb.database", mock.Anything)
+		mockContainer.AssertCalled(t, "Instance", "mongodb.connections", mock.Anything)
+		mockContainer.AssertCalled(t, "Instance", "mongodb.default", mock.Anything)
+		mockContainer.AssertCalled(t, "Singleton", "mongodb.client", mock.Anything)
+		mockContainer.AssertCalled(t, "Singleton", "mongodb.database", mock.Anything)
 	})
 
 	t.Run("panics when config service is missing", func(t *testing.T) {
@@ -177,6 +177,159 @@ func TestServiceProvider_Boot(t *testing.T) {
 	})
 }
 
+// unreachableMongoConfig returns a config pointing at a closed port with short timeouts
+func unreachableMongoConfig(connectMode string) *mongodb.Config {
+	cfg := mongodb.DefaultConfig()
+	cfg.URI = "mongodb://127.0.0.1:1"
+	cfg.ConnectTimeout = 200
+	cfg.ServerSelectionTimeout = 200
+	cfg.ConnectRetry = mongodb.ConnectRetryConfig{MaxAttempts: 1}
+	cfg.ConnectMode = connectMode
+	return cfg
+}
+
+// testContainer is a minimal in-memory container recording instances and singleton factories
+type testContainer struct {
+	di.Container
+	instances map[string]interface{}
+	bindings  map[string]di.BindingFunc
+}
+
+func (c *testContainer) Instance(abstract string, instance interface{}) {
+	c.instances[abstract] = instance
+}
+
+func (c *testContainer) Singleton(abstract string, concrete di.BindingFunc) {
+	c.bindings[abstract] = concrete
+}
+
+func (c *testContainer) MustMake(abstract string) interface{} {
+	if instance, ok := c.instances[abstract]; ok {
+		return instance
+	}
+	if binding, ok := c.bindings[abstract]; ok {
+		return binding(c)
+	}
+	panic("binding not found: " + abstract)
+}
+
+// registerWithConfig registers a provider against a container serving cfg as the mongodb config
+func registerWithConfig(t *testing.T, cfg *mongodb.Config) (mongodb.ServiceProvider, di.Application, *testContainer) {
+	mockConfig := config_mocks.NewMockManager(t)
+	mockConfig.EXPECT().UnmarshalKey("mongodb", mock.Anything).Run(func(_ string, out interface{}) {
+		if c, ok := out.(*mongodb.Config); ok {
+			*c = *cfg
+		}
+	}).Return(nil)
+
+	container := &testContainer{
+		instances: map[string]interface{}{"config": mockConfig},
+		bindings:  make(map[string]di.BindingFunc),
+	}
+	mockApp := di_mocks.NewMockApplication(t)
+	mockApp.On("Container").Return(container)
+
+	provider := mongodb.NewServiceProvider()
+	provider.Register(mockApp)
+	return provider, mockApp, container
+}
+
+func TestServiceProvider_RegisterDoesNotConnect(t *testing.T) {
+	start := time.Now()
+	_, _, container := registerWithConfig(t, unreachableMongoConfig(mongodb.ConnectModeLazy))
+	assert.Less(t, time.Since(start), time.Second, "Register should not wait for MongoDB")
+
+	assert.Contains(t, container.bindings, "mongodb.client")
+	assert.Contains(t, container.bindings, "mongodb.database")
+
+	// Resolving the client is what connects
+	assert.Panics(t, func() {
+		container.MustMake("mongodb.client")
+	})
+}
+
+func TestServiceProvider_BootConnectMode(t *testing.T) {
+	t.Run("lazy does not connect", func(t *testing.T) {
+		provider, app, _ := registerWithConfig(t, unreachableMongoConfig(mongodb.ConnectModeLazy))
+		start := time.Now()
+		assert.NotPanics(t, func() { provider.Boot(app) })
+		assert.Less(t, time.Since(start), 100*time.Millisecond)
+	})
+
+	t.Run("background does not block", func(t *testing.T) {
+		provider, app, _ := registerWithConfig(t, unreachableMongoConfig(mongodb.ConnectModeBackground))
+		start := time.Now()
+		assert.NotPanics(t, func() { provider.Boot(app) })
+		assert.Less(t, time.Since(start), 100*time.Millisecond)
+	})
+
+	t.Run("eager fails startup", func(t *testing.T) {
+		provider, app, _ := registerWithConfig(t, unreachableMongoConfig(mongodb.ConnectModeEager))
+		assert.Panics(t, func() { provider.Boot(app) })
+	})
+
+	t.Run("eager connects", func(t *testing.T) {
+		if !isMongoDBAvailable() {
+			t.Skip("MongoDB not available, skipping integration test")
+		}
+
+		cfg := setupTestMongoConfig()
+		cfg.ConnectMode = mongodb.ConnectModeEager
+		provider, app, container := registerWithConfig(t, cfg)
+		assert.NotPanics(t, func() { provider.Boot(app) })
+		assert.NotNil(t, container.MustMake("mongodb.client"))
+	})
+}
+
+// hookApp is an application supporting shutdown hooks
+type hookApp struct {
+	di.Application
+	container di.Container
+	hooks     []func(ctx context.Context) error
+}
+
+func (a *hookApp) Container() di.Container {
+	return a.container
+}
+
+func (a *hookApp) OnShutdown(hook func(ctx context.Context) error) {
+	a.hooks = append(a.hooks, hook)
+}
+
+func TestServiceProvider_Shutdown(t *testing.T) {
+	t.Run("before register", func(t *testing.T) {
+		provider := mongodb.NewServiceProvider()
+		assert.NoError(t, provider.Shutdown(context.Background()))
+	})
+
+	t.Run("stops background warm-up", func(t *testing.T) {
+		cfg := unreachableMongoConfig(mongodb.ConnectModeBackground)
+		cfg.ServerSelectionTimeout = 30000
+		cfg.ConnectTimeout = 30000
+		provider, app, _ := registerWithConfig(t, cfg)
+		provider.Boot(app)
+
+		start := time.Now()
+		assert.NoError(t, provider.Shutdown(context.Background()))
+		assert.Less(t, time.Since(start), 5*time.Second, "Shutdown should cancel the warm-up instead of waiting for it")
+	})
+
+	t.Run("registers hook with supporting application", func(t *testing.T) {
+		mockConfig := config_mocks.NewMockManager(t)
+		mockConfig.EXPECT().UnmarshalKey("mongodb", mock.Anything).Return(nil)
+		app := &hookApp{container: &testContainer{
+			instances: map[string]interface{}{"config": mockConfig},
+			bindings:  make(map[string]di.BindingFunc),
+		}}
+
+		provider := mongodb.NewServiceProvider()
+		provider.Register(app)
+
+		assert.Len(t, app.hooks, 1)
+		assert.NoError(t, app.hooks[0](context.Background()))
+	})
+}
+
 func TestServiceProvider_BootWithNil(t *testing.T) {
 	// Test Boot with nil app parameter
 	provider := mongodb.NewServiceProvider()
@@ -206,11 +359,6 @@ func TestServiceProvider_Requires(t *testing.T) {
 }
 
 func TestDynamicProvidersList(t *testing.T) {
-	// Skip test if MongoDB is not available
-	if !isMongoDBAvailable() {
-		t.Skip("MongoDB not available, skipping integration test")
-	}
-
 	// Test that providers are correctly registered in the dynamic list
 	mockContainer := di_mocks.NewMockContainer(t)
 	mockConfig := config_mocks.NewMockManager(t)
@@ -225,8 +373,10 @@ func TestDynamicProvidersList(t *testing.T) {
 		}
 	}).Return(nil)
 	mockContainer.On("Instance", "mongodb", mock.Anything).Return(nil)
-	mockContainer.On("Instance", "mongodb.client", mock.Anything).Return(nil)
-	mockContainer.On("Instance", "mongodb.database", mock.Anything).Return(nil)
+	mockContainer.On("Instance", "mongodb.connections", mock.Anything).Return(nil)
+	mockContainer.On("Instance", "mongodb.default", mock.Anything).Return(nil)
+	mockContainer.On("Singleton", "mongodb.client", mock.Anything).Return(nil)
+	mockContainer.On("Singleton", "mongodb.database", mock.Anything).Return(nil)
 
 	provider := mongodb.NewServiceProvider()
 
@@ -240,8 +390,8 @@ func TestDynamicProvidersList(t *testing.T) {
 	// Check providers list after registration
 	providers := provider.Providers()
 
-	// We expect 3 entries: mongodb, mongodb.client, mongodb.database
-	expectedItems := []string{"mongodb", "mongodb.client", "mongodb.database"}
+	// We expect 5 entries: mongodb, mongodb.connections, mongodb.default, mongodb.client, mongodb.database
+	expectedItems := []string{"mongodb", "mongodb.connections", "mongodb.default", "mongodb.client", "mongodb.database"}
 	for _, expected := range expectedItems {
 		assert.Contains(t, providers, expected, "Expected to find '%s' in providers list", expected)
 	}
@@ -0,0 +1,172 @@
+// Package query builds MongoDB filter and update documents.
+//
+// Builders return bson.D values that can be passed to any driver or Manager method:
+//
+//	filter := query.Where(
+//		query.Eq("status", "active"),
+//		query.Gte("age", 18),
+//		query.Or(query.In("role", "admin", "owner"), query.Exists("invited_by", true)),
+//	)
+//	update := query.Combine(query.Set("status", "archived"), query.Inc("version", 1))
+//
+// Field paths are plain strings; SchemaOf and Validate check them against the bson tags
+// of a document type so that renamed fields fail in tests.
+package query
+
+import (
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Eq matches documents whose field equals value
+func Eq(field string, value interface{}) bson.D {
+	return bson.D{{Key: field, Value: value}}
+}
+
+// Ne matches documents whose field does not equal value
+func Ne(field string, value interface{}) bson.D {
+	return operator(field, "$ne", value)
+}
+
+// Gt matches documents whose field is greater than value
+func Gt(field string, value interface{}) bson.D {
+	return operator(field, "$gt", value)
+}
+
+// Gte matches documents whose field is greater than or equal to value
+func Gte(field string, value interface{}) bson.D {
+	return operator(field, "$gte", value)
+}
+
+// Lt matches documents whose field is less than value
+func Lt(field string, value interface{}) bson.D {
+	return operator(field, "$lt", value)
+}
+
+// Lte matches documents whose field is less than or equal to value
+func Lte(field string, value interface{}) bson.D {
+	return operator(field, "$lte", value)
+}
+
+// In matches documents whose field equals one of values
+func In(field string, values ...interface{}) bson.D {
+	return operator(field, "$in", array(values))
+}
+
+// Nin matches documents whose field equals none of values
+func Nin(field string, values ...interface{}) bson.D {
+	return operator(field, "$nin", array(values))
+}
+
+// Exists matches documents that have the field, or do not have it when exists is false
+func Exists(field string, exists bool) bson.D {
+	return operator(field, "$exists", exists)
+}
+
+// Regex matches documents whose string field matches pattern with the given options, e.g. "i"
+func Regex(field, pattern, options string) bson.D {
+	return operator(field, "$regex", primitive.Regex{Pattern: pattern, Options: options})
+}
+
+// ElemMatch matches documents whose array field has an element matching every filter.
+//
+// Field paths in filters are relative to the array element.
+func ElemMatch(field string, filters ...bson.D) bson.D {
+	return operator(field, "$elemMatch", Where(filters...))
+}
+
+// And matches documents matching every filter
+func And(filters ...bson.D) bson.D {
+	return logical("$and", filters)
+}
+
+// Or matches documents matching at least one filter
+func Or(filters ...bson.D) bson.D {
+	return logical("$or", filters)
+}
+
+// Nor matches documents matching none of the filters
+func Nor(filters ...bson.D) bson.D {
+	return logical("$nor", filters)
+}
+
+// Where merges filters into a single document matching all of them.
+//
+// Conditions on the same field are merged into one operator document, so
+// Where(Gte("age", 18), Lt("age", 65)) yields {age: {$gte: 18, $lt: 65}}. Other repeated
+// keys, including the same operator twice on a field, are moved into $and.
+func Where(filters ...bson.D) bson.D {
+	merged := bson.D{}
+	index := make(map[string]int)
+	var conflicts bson.A
+	for _, filter := range filters {
+		for _, elem := range filter {
+			i, ok := index[elem.Key]
+			if !ok {
+				index[elem.Key] = len(merged)
+				merged = append(merged, elem)
+				continue
+			}
+			existing, _ := merged[i].Value.(bson.D)
+			conditions, _ := elem.Value.(bson.D)
+			if isOperatorDocument(existing) && isOperatorDocument(conditions) && !sharesKey(existing, conditions) {
+				merged[i].Value = append(append(bson.D{}, existing...), conditions...)
+				continue
+			}
+			conflicts = append(conflicts, bson.D{elem})
+		}
+	}
+	if len(conflicts) == 0 {
+		return merged
+	}
+	if i, ok := index["$and"]; ok {
+		if filters, isArray := merged[i].Value.(bson.A); isArray {
+			merged[i].Value = append(append(bson.A{}, filters...), conflicts...)
+			return merged
+		}
+	}
+	return append(merged, bson.E{Key: "$and", Value: conflicts})
+}
+
+// operator returns {field: {op: value}}
+func operator(field, op string, value interface{}) bson.D {
+	return bson.D{{Key: field, Value: bson.D{{Key: op, Value: value}}}}
+}
+
+// array converts values to an array, which is never nil so that it is not encoded as null
+func array(values []interface{}) bson.A {
+	return append(bson.A{}, values...)
+}
+
+// logical returns {op: [filters...]}
+func logical(op string, filters []bson.D) bson.D {
+	values := make(bson.A, 0, len(filters))
+	for _, filter := range filters {
+		values = append(values, filter)
+	}
+	return bson.D{{Key: op, Value: values}}
+}
+
+// sharesKey reports whether a and b have a key in common
+func sharesKey(a, b bson.D) bool {
+	for _, elem := range b {
+		for _, other := range a {
+			if elem.Key == other.Key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isOperatorDocument reports whether every key of doc is an operator
+func isOperatorDocument(doc bson.D) bool {
+	for _, elem := range doc {
+		if !strings.HasPrefix(elem.Key, "$") {
+			return false
+		}
+	}
+	return len(doc) > 0
+}
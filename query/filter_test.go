@@ -0,0 +1,89 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestFieldOperators(t *testing.T) {
+	assert.Equal(t, bson.D{{Key: "status", Value: "active"}}, Eq("status", "active"))
+	assert.Equal(t, bson.D{{Key: "age", Value: bson.D{{Key: "$gt", Value: 18}}}}, Gt("age", 18))
+	assert.Equal(t, bson.D{{Key: "role", Value: bson.D{{Key: "$in", Value: bson.A{"admin", "owner"}}}}}, In("role", "admin", "owner"))
+	assert.Equal(t, bson.D{{Key: "role", Value: bson.D{{Key: "$nin", Value: bson.A{}}}}}, Nin("role"))
+	assert.Equal(t, bson.D{{Key: "deleted_at", Value: bson.D{{Key: "$exists", Value: false}}}}, Exists("deleted_at", false))
+	assert.Equal(t,
+		bson.D{{Key: "name", Value: bson.D{{Key: "$regex", Value: primitive.Regex{Pattern: "^jo", Options: "i"}}}}},
+		Regex("name", "^jo", "i"),
+	)
+}
+
+func TestElemMatch(t *testing.T) {
+	filter := ElemMatch("items", Eq("sku", "A1"), Gte("qty", 2), Lt("qty", 10))
+
+	assert.Equal(t, bson.D{{Key: "items", Value: bson.D{{Key: "$elemMatch", Value: bson.D{
+		{Key: "sku", Value: "A1"},
+		{Key: "qty", Value: bson.D{{Key: "$gte", Value: 2}, {Key: "$lt", Value: 10}}},
+	}}}}}, filter)
+}
+
+func TestLogicalOperators(t *testing.T) {
+	filter := Or(Eq("a", 1), Nor(Eq("b", 2)))
+
+	assert.Equal(t, bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "a", Value: 1}},
+		bson.D{{Key: "$nor", Value: bson.A{bson.D{{Key: "b", Value: 2}}}}},
+	}}}, filter)
+	assert.Equal(t, bson.D{{Key: "$and", Value: bson.A{}}}, And())
+}
+
+func TestWhere(t *testing.T) {
+	t.Run("merges operators on the same field", func(t *testing.T) {
+		filter := Where(Eq("status", "active"), Gte("age", 18), Lt("age", 65))
+
+		assert.Equal(t, bson.D{
+			{Key: "status", Value: "active"},
+			{Key: "age", Value: bson.D{{Key: "$gte", Value: 18}, {Key: "$lt", Value: 65}}},
+		}, filter)
+	})
+
+	t.Run("moves conflicting conditions into $and", func(t *testing.T) {
+		filter := Where(Eq("status", "active"), Eq("status", "pending"))
+
+		assert.Equal(t, bson.D{
+			{Key: "status", Value: "active"},
+			{Key: "$and", Value: bson.A{bson.D{{Key: "status", Value: "pending"}}}},
+		}, filter)
+	})
+
+	t.Run("moves a repeated operator on a field into $and", func(t *testing.T) {
+		filter := Where(Gt("age", 18), Lt("age", 65), Gt("age", 21))
+
+		assert.Equal(t, bson.D{
+			{Key: "age", Value: bson.D{{Key: "$gt", Value: 18}, {Key: "$lt", Value: 65}}},
+			{Key: "$and", Value: bson.A{bson.D{{Key: "age", Value: bson.D{{Key: "$gt", Value: 21}}}}}},
+		}, filter)
+	})
+
+	t.Run("appends conflicts to an existing $and", func(t *testing.T) {
+		filter := Where(And(Eq("a", 1)), Eq("b", 2), Eq("b", 3))
+
+		assert.Equal(t, bson.D{
+			{Key: "$and", Value: bson.A{bson.D{{Key: "a", Value: 1}}, bson.D{{Key: "b", Value: 3}}}},
+			{Key: "b", Value: 2},
+		}, filter)
+	})
+
+	t.Run("does not modify its arguments", func(t *testing.T) {
+		gte := Gte("age", 18)
+		Where(gte, Lt("age", 65))
+
+		assert.Equal(t, Gte("age", 18), gte)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		assert.Equal(t, bson.D{}, Where())
+	})
+}
@@ -0,0 +1,260 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ErrUnknownField is returned when a filter or update uses a field the document type does not have
+var ErrUnknownField = errors.New("unknown field")
+
+// logicalOperators take an array of filters on the same document
+var logicalOperators = map[string]bool{
+	"$and": true,
+	"$or":  true,
+	"$nor": true,
+}
+
+// updateOperators take a document of field paths
+var updateOperators = map[string]bool{
+	"$set":         true,
+	"$setOnInsert": true,
+	"$unset":       true,
+	"$inc":         true,
+	"$mul":         true,
+	"$min":         true,
+	"$max":         true,
+	"$rename":      true,
+	"$currentDate": true,
+	"$push":        true,
+	"$pull":        true,
+	"$pullAll":     true,
+	"$addToSet":    true,
+	"$pop":         true,
+}
+
+// leafPackages hold struct types stored as single BSON values
+var leafPackages = map[string]bool{
+	"time": true,
+	"go.mongodb.org/mongo-driver/bson/primitive": true,
+}
+
+// Schema holds the field paths of a document type, derived from its bson tags
+type Schema struct {
+	name   string
+	fields map[string]bool
+	// open paths accept any sub path, e.g. maps and interface fields
+	open map[string]bool
+}
+
+// SchemaOf returns the schema of the document type T
+func SchemaOf[T any]() *Schema {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	s := &Schema{
+		name:   t.String(),
+		fields: make(map[string]bool),
+		open:   make(map[string]bool),
+	}
+	s.walk(t, "", make(map[reflect.Type]bool))
+	return s
+}
+
+// Validate checks every field path of a filter or update document against the bson tags of T
+func Validate[T any](doc interface{}) error {
+	return SchemaOf[T]().Validate(doc)
+}
+
+// walk records the field paths of t below prefix
+func (s *Schema) walk(t reflect.Type, prefix string, visiting map[reflect.Type]bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == reflect.TypeOf(bson.D{}), t == reflect.TypeOf(bson.Raw{}):
+		s.open[prefix] = true
+		return
+	case leafPackages[t.PkgPath()]:
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Map, reflect.Interface:
+		s.open[prefix] = true
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() != reflect.Uint8 {
+			s.walk(t.Elem(), prefix, visiting)
+		}
+	case reflect.Struct:
+		if visiting[t] {
+			// Recursive types accept any sub path
+			s.open[prefix] = true
+			return
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, flags, _ := strings.Cut(field.Tag.Get("bson"), ",")
+			if name == "-" {
+				continue
+			}
+			if hasFlag(flags, "inline") {
+				s.walk(field.Type, prefix, visiting)
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			path := joinPath(prefix, name)
+			s.fields[path] = true
+			s.walk(field.Type, path, visiting)
+		}
+	}
+}
+
+// hasFlag reports whether a comma separated list of bson tag flags contains flag
+func hasFlag(flags, flag string) bool {
+	for _, f := range strings.Split(flags, ",") {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// joinPath appends a field name to a dotted path
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// Has reports whether path is a field of the document type.
+//
+// Array indexes and positional operators ($, $[], $[identifier]) are ignored.
+func (s *Schema) Has(path string) bool {
+	if s.open[""] {
+		return true
+	}
+	var current string
+	for _, segment := range strings.Split(path, ".") {
+		if isPositional(segment) {
+			continue
+		}
+		current = joinPath(current, segment)
+		if s.open[current] {
+			return true
+		}
+		if !s.fields[current] {
+			return false
+		}
+	}
+	return current != ""
+}
+
+// isPositional reports whether a path segment is an array index or positional operator
+func isPositional(segment string) bool {
+	if strings.HasPrefix(segment, "$") {
+		return true
+	}
+	_, err := strconv.Atoi(segment)
+	return err == nil
+}
+
+// Validate checks every field path of a filter or update document
+//
+// It returns an error wrapping ErrUnknownField for each unknown path.
+func (s *Schema) Validate(doc interface{}) error {
+	var errs []error
+	s.validateDocument(doc, "", &errs)
+	return errors.Join(errs...)
+}
+
+// validateDocument checks the keys of a filter or update document below prefix
+func (s *Schema) validateDocument(doc interface{}, prefix string, errs *[]error) {
+	for _, elem := range elements(doc) {
+		switch {
+		case logicalOperators[elem.Key]:
+			for _, filter := range arrayValues(elem.Value) {
+				s.validateDocument(filter, prefix, errs)
+			}
+		case updateOperators[elem.Key]:
+			for _, field := range elements(elem.Value) {
+				s.check(joinPath(prefix, field.Key), errs)
+				if elem.Key == "$rename" {
+					if target, ok := field.Value.(string); ok {
+						s.check(joinPath(prefix, target), errs)
+					}
+				}
+			}
+		case strings.HasPrefix(elem.Key, "$"):
+			// Other top level operators, such as $expr or $text, do not name fields
+		default:
+			path := joinPath(prefix, elem.Key)
+			s.check(path, errs)
+			for _, condition := range elements(elem.Value) {
+				if condition.Key == "$elemMatch" {
+					s.validateDocument(condition.Value, path, errs)
+				}
+			}
+		}
+	}
+}
+
+// check records an error when path is unknown
+func (s *Schema) check(path string, errs *[]error) {
+	if !s.Has(path) {
+		*errs = append(*errs, fmt.Errorf("%w %q in %s", ErrUnknownField, path, s.name))
+	}
+}
+
+// elements returns the elements of a document given as bson.D or bson.M
+func elements(doc interface{}) bson.D {
+	switch d := doc.(type) {
+	case bson.D:
+		return d
+	case bson.M:
+		elems := make(bson.D, 0, len(d))
+		for key, value := range d {
+			elems = append(elems, bson.E{Key: key, Value: value})
+		}
+		return elems
+	case map[string]interface{}:
+		return elements(bson.M(d))
+	}
+	return nil
+}
+
+// arrayValues returns the values of an array given as bson.A or a slice of documents
+func arrayValues(value interface{}) []interface{} {
+	switch a := value.(type) {
+	case bson.A:
+		return a
+	case []interface{}:
+		return a
+	case []bson.D:
+		values := make([]interface{}, len(a))
+		for i, doc := range a {
+			values[i] = doc
+		}
+		return values
+	case []bson.M:
+		values := make([]interface{}, len(a))
+		for i, doc := range a {
+			values[i] = doc
+		}
+		return values
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package query
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type address struct {
+	City string `bson:"city"`
+}
+
+type item struct {
+	SKU string `bson:"sku"`
+	Qty int    `bson:"qty"`
+}
+
+type audit struct {
+	CreatedAt time.Time `bson:"created_at"`
+}
+
+type node struct {
+	Name     string `bson:"name"`
+	Children []node `bson:"children"`
+}
+
+type user struct {
+	ID       primitive.ObjectID     `bson:"_id,omitempty"`
+	Name     string                 `bson:"name"`
+	Age      int                    `bson:"age,omitempty"`
+	Email    string                 // stored as "email"
+	Password string                 `bson:"-"`
+	Address  *address               `bson:"address"`
+	Items    []item                 `bson:"items"`
+	Meta     map[string]interface{} `bson:"meta"`
+	Tree     node                   `bson:"tree"`
+	Audit    audit                  `bson:",inline"`
+}
+
+func TestSchemaOf(t *testing.T) {
+	schema := SchemaOf[user]()
+
+	for _, path := range []string{
+		"_id", "name", "age", "email", "address", "address.city", "items", "items.sku",
+		"items.0.qty", "items.$.qty", "items.$[].sku", "items.$[i].sku", "meta.anything.deep",
+		"created_at", "tree.children.name", "tree.children.children.name",
+	} {
+		assert.True(t, schema.Has(path), path)
+	}
+	for _, path := range []string{"password", "Password", "address.zip", "items.price", "created", "", "created_at.seconds"} {
+		assert.False(t, schema.Has(path), path)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("valid filter", func(t *testing.T) {
+		filter := Where(
+			Eq("name", "jo"),
+			Gte("age", 18),
+			Or(Eq("address.city", "Hanoi"), Exists("meta.vip", true)),
+			ElemMatch("items", Eq("sku", "A1"), Gt("qty", 1)),
+			bson.D{{Key: "$expr", Value: bson.D{{Key: "$gt", Value: bson.A{"$age", 1}}}}},
+		)
+		assert.NoError(t, Validate[user](filter))
+	})
+
+	t.Run("valid update", func(t *testing.T) {
+		update := Combine(Set("items.$.qty", 3), Inc("age", 1), Unset("meta.old"),
+			bson.D{{Key: "$rename", Value: bson.M{"name": "email"}}})
+		assert.NoError(t, Validate[user](update))
+	})
+
+	t.Run("unknown fields", func(t *testing.T) {
+		filter := Where(
+			Eq("nmae", "jo"),
+			Or(Eq("address.zip", "100000")),
+			ElemMatch("items", Eq("price", 1)),
+		)
+
+		err := Validate[user](filter)
+		assert.True(t, errors.Is(err, ErrUnknownField))
+		assert.ErrorContains(t, err, `"nmae"`)
+		assert.ErrorContains(t, err, `"address.zip"`)
+		assert.ErrorContains(t, err, `"items.price"`)
+		assert.ErrorContains(t, err, "query.user")
+	})
+
+	t.Run("unknown update fields", func(t *testing.T) {
+		update := Combine(Set("password", "x"), bson.D{{Key: "$rename", Value: bson.D{{Key: "name", Value: "fullname"}}}})
+
+		err := Validate[user](update)
+		assert.ErrorIs(t, err, ErrUnknownField)
+		assert.ErrorContains(t, err, `"password"`)
+		assert.ErrorContains(t, err, `"fullname"`)
+	})
+
+	t.Run("bson.M", func(t *testing.T) {
+		assert.NoError(t, Validate[user](bson.M{"name": "jo", "$or": []bson.M{{"age": 1}}}))
+		assert.ErrorIs(t, Validate[user](bson.M{"$or": bson.A{bson.M{"agee": 1}}}), ErrUnknownField)
+	})
+}
@@ -0,0 +1,68 @@
+package query
+
+import "go.mongodb.org/mongo-driver/bson"
+
+// Set sets field to value
+func Set(field string, value interface{}) bson.D {
+	return updateOperator("$set", field, value)
+}
+
+// Inc increments field by amount
+func Inc(field string, amount interface{}) bson.D {
+	return updateOperator("$inc", field, amount)
+}
+
+// Push appends value to the array field
+func Push(field string, value interface{}) bson.D {
+	return updateOperator("$push", field, value)
+}
+
+// Pull removes from the array field every element equal to value or matching it when value is a filter
+func Pull(field string, value interface{}) bson.D {
+	return updateOperator("$pull", field, value)
+}
+
+// AddToSet appends value to the array field unless it is already present
+func AddToSet(field string, value interface{}) bson.D {
+	return updateOperator("$addToSet", field, value)
+}
+
+// Unset removes fields
+func Unset(fields ...string) bson.D {
+	removed := make(bson.D, 0, len(fields))
+	for _, field := range fields {
+		removed = append(removed, bson.E{Key: field, Value: ""})
+	}
+	return bson.D{{Key: "$unset", Value: removed}}
+}
+
+// Combine merges update documents, grouping the fields of the same operator
+//
+// Combine(Set("a", 1), Inc("n", 1), Set("b", 2)) yields {$set: {a: 1, b: 2}, $inc: {n: 1}}.
+func Combine(updates ...bson.D) bson.D {
+	combined := bson.D{}
+	index := make(map[string]int)
+	for _, update := range updates {
+		for _, elem := range update {
+			fields, ok := elem.Value.(bson.D)
+			if !ok {
+				// Operators given as other document types are kept as they are
+				combined = append(combined, elem)
+				continue
+			}
+			i, ok := index[elem.Key]
+			if !ok {
+				index[elem.Key] = len(combined)
+				combined = append(combined, bson.E{Key: elem.Key, Value: append(bson.D{}, fields...)})
+				continue
+			}
+			combined[i].Value = append(combined[i].Value.(bson.D), fields...)
+		}
+	}
+	return combined
+}
+
+// updateOperator returns {op: {field: value}}
+func updateOperator(op, field string, value interface{}) bson.D {
+	return bson.D{{Key: op, Value: bson.D{{Key: field, Value: value}}}}
+}
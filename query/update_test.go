@@ -0,0 +1,30 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestUpdateOperators(t *testing.T) {
+	assert.Equal(t, bson.D{{Key: "$set", Value: bson.D{{Key: "status", Value: "archived"}}}}, Set("status", "archived"))
+	assert.Equal(t, bson.D{{Key: "$inc", Value: bson.D{{Key: "version", Value: 1}}}}, Inc("version", 1))
+	assert.Equal(t, bson.D{{Key: "$push", Value: bson.D{{Key: "tags", Value: "new"}}}}, Push("tags", "new"))
+	assert.Equal(t, bson.D{{Key: "$pull", Value: bson.D{{Key: "tags", Value: "old"}}}}, Pull("tags", "old"))
+	assert.Equal(t, bson.D{{Key: "$addToSet", Value: bson.D{{Key: "tags", Value: "x"}}}}, AddToSet("tags", "x"))
+	assert.Equal(t,
+		bson.D{{Key: "$unset", Value: bson.D{{Key: "a", Value: ""}, {Key: "b", Value: ""}}}},
+		Unset("a", "b"),
+	)
+}
+
+func TestCombine(t *testing.T) {
+	update := Combine(Set("a", 1), Inc("n", 1), Set("b", 2), bson.D{{Key: "$currentDate", Value: bson.M{"updated_at": true}}})
+
+	assert.Equal(t, bson.D{
+		{Key: "$set", Value: bson.D{{Key: "a", Value: 1}, {Key: "b", Value: 2}}},
+		{Key: "$inc", Value: bson.D{{Key: "n", Value: 1}}},
+		{Key: "$currentDate", Value: bson.M{"updated_at": true}},
+	}, update)
+}
@@ -0,0 +1,67 @@
+package mongodb
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/tag"
+)
+
+// minMaxStaleness is the smallest max staleness (in seconds) accepted by MongoDB
+const minMaxStaleness = 90
+
+// newReadPref builds a driver read preference from the read preference configuration.
+//
+// An empty mode returns nil so that the driver default (primary) applies. Unknown modes
+// and combinations MongoDB rejects, such as tag sets or max staleness with primary, are
+// reported as errors.
+func newReadPref(cfg ReadPreferenceConfig) (*readpref.ReadPref, error) {
+	if cfg.Mode == "" {
+		return nil, nil
+	}
+
+	mode, err := readpref.ModeFromString(cfg.Mode)
+	if err != nil {
+		return nil, fmt.Errorf("invalid read preference mode %q: must be one of primary, primaryPreferred, secondary, secondaryPreferred, nearest", cfg.Mode)
+	}
+
+	var opts []readpref.Option
+	if len(cfg.TagSets) > 0 {
+		opts = append(opts, readpref.WithTagSets(tag.NewTagSetsFromMaps(cfg.TagSets)...))
+	}
+	if cfg.MaxStaleness < 0 {
+		return nil, fmt.Errorf("invalid read preference max_staleness %d: must not be negative", cfg.MaxStaleness)
+	}
+	if cfg.MaxStaleness > 0 {
+		if cfg.MaxStaleness < minMaxStaleness {
+			return nil, fmt.Errorf("invalid read preference max_staleness %d: must be at least %d seconds", cfg.MaxStaleness, minMaxStaleness)
+		}
+		opts = append(opts, readpref.WithMaxStaleness(time.Duration(cfg.MaxStaleness)*time.Second))
+	}
+	if cfg.HedgeEnabled {
+		opts = append(opts, readpref.WithHedgeEnabled(true))
+	}
+
+	if mode == readpref.PrimaryMode && len(opts) > 0 {
+		return nil, fmt.Errorf("invalid read preference: tag_sets, max_staleness and hedge_enabled cannot be used with mode primary")
+	}
+
+	return readpref.New(mode, opts...)
+}
+
+// validateReadPreferences checks the default read preference and every named profile.
+func validateReadPreferences(config Config) error {
+	if _, err := newReadPref(config.ReadPreference); err != nil {
+		return err
+	}
+	for name, profile := range config.ReadPreferenceProfiles {
+		if profile.Mode == "" {
+			return fmt.Errorf("read preference profile %q: mode is required", name)
+		}
+		if _, err := newReadPref(profile); err != nil {
+			return fmt.Errorf("read preference profile %q: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package mongodb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+func TestNewReadPref(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ReadPreferenceConfig
+		mode    readpref.Mode
+		wantErr string
+	}{
+		{name: "primary", cfg: ReadPreferenceConfig{Mode: "primary"}, mode: readpref.PrimaryMode},
+		{name: "camel case mode", cfg: ReadPreferenceConfig{Mode: "secondaryPreferred"}, mode: readpref.SecondaryPreferredMode},
+		{name: "nearest with hedge", cfg: ReadPreferenceConfig{Mode: "nearest", HedgeEnabled: true}, mode: readpref.NearestMode},
+		{name: "unknown mode", cfg: ReadPreferenceConfig{Mode: "fastest"}, wantErr: "invalid read preference mode"},
+		{name: "staleness with primary", cfg: ReadPreferenceConfig{Mode: "primary", MaxStaleness: 120}, wantErr: "cannot be used with mode primary"},
+		{name: "tag sets with primary", cfg: ReadPreferenceConfig{Mode: "primary", TagSets: []map[string]string{{"dc": "east"}}}, wantErr: "cannot be used with mode primary"},
+		{name: "hedge with primary", cfg: ReadPreferenceConfig{Mode: "primary", HedgeEnabled: true}, wantErr: "cannot be used with mode primary"},
+		{name: "staleness too small", cfg: ReadPreferenceConfig{Mode: "secondary", MaxStaleness: 30}, wantErr: "at least 90 seconds"},
+		{name: "negative staleness", cfg: ReadPreferenceConfig{Mode: "secondary", MaxStaleness: -1}, wantErr: "must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp, err := newReadPref(tt.cfg)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.mode, rp.Mode())
+		})
+	}
+
+	t.Run("empty mode", func(t *testing.T) {
+		rp, err := newReadPref(ReadPreferenceConfig{})
+		assert.NoError(t, err)
+		assert.Nil(t, rp)
+	})
+
+	t.Run("tag sets and staleness", func(t *testing.T) {
+		rp, err := newReadPref(ReadPreferenceConfig{
+			Mode:         "secondary",
+			TagSets:      []map[string]string{{"dc": "east", "usage": "analytics"}, {}},
+			MaxStaleness: 120,
+			HedgeEnabled: true,
+		})
+		assert.NoError(t, err)
+
+		assert.Len(t, rp.TagSets(), 2)
+		assert.True(t, rp.TagSets()[0].Contains("dc", "east"))
+		assert.True(t, rp.TagSets()[0].Contains("usage", "analytics"))
+
+		staleness, ok := rp.MaxStaleness()
+		assert.True(t, ok)
+		assert.Equal(t, 120*time.Second, staleness)
+
+		assert.NotNil(t, rp.HedgeEnabled())
+		assert.True(t, *rp.HedgeEnabled())
+	})
+}
+
+func TestValidateReadPreferences(t *testing.T) {
+	t.Run("valid profiles", func(t *testing.T) {
+		cfg := Config{
+			ReadPreference: ReadPreferenceConfig{Mode: "primary"},
+			ReadPreferenceProfiles: map[string]ReadPreferenceConfig{
+				"analytics": {Mode: "secondary", TagSets: []map[string]string{{"usage": "analytics"}}},
+				"reports":   {Mode: "nearest", MaxStaleness: 90},
+			},
+		}
+		assert.NoError(t, validateReadPreferences(cfg))
+	})
+
+	t.Run("invalid default", func(t *testing.T) {
+		cfg := Config{ReadPreference: ReadPreferenceConfig{Mode: "primary", MaxStaleness: 90}}
+		assert.Error(t, validateReadPreferences(cfg))
+	})
+
+	t.Run("invalid profile", func(t *testing.T) {
+		cfg := Config{
+			ReadPreferenceProfiles: map[string]ReadPreferenceConfig{
+				"analytics": {Mode: "secundary"},
+			},
+		}
+		assert.ErrorContains(t, validateReadPreferences(cfg), `profile "analytics"`)
+	})
+
+	t.Run("profile without mode", func(t *testing.T) {
+		cfg := Config{
+			ReadPreferenceProfiles: map[string]ReadPreferenceConfig{
+				"analytics": {},
+			},
+		}
+		assert.ErrorContains(t, validateReadPreferences(cfg), "mode is required")
+	})
+}
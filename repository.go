@@ -0,0 +1,267 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// objectIDType is the type of ObjectID _id fields
+var objectIDType = reflect.TypeOf(primitive.ObjectID{})
+
+// Repository provides typed CRUD operations on a collection of the manager's default database.
+//
+// IDs may be given as the type of T's _id field or, when that field is a primitive.ObjectID,
+// as its hex string. Operations on a single document return an error wrapping ErrNotFound
+// when no document matches.
+type Repository[T any] struct {
+	manager    Manager
+	collection string
+
+	// idField is the index of T's _id field, nil when T has none
+	idField []int
+	// objectID reports whether T's _id field is a primitive.ObjectID
+	objectID bool
+}
+
+// NewRepository creates a repository of documents of type T stored in the named collection
+func NewRepository[T any](manager Manager, collection string) *Repository[T] {
+	r := &Repository[T]{manager: manager, collection: collection}
+	if field, ok := idField(reflect.TypeOf((*T)(nil)).Elem()); ok {
+		r.idField = field.Index
+		r.objectID = field.Type == objectIDType
+	}
+	return r
+}
+
+// idField returns the struct field mapped to _id, looking into inline structs
+//
+// The Index of the returned field is the path from t, usable with FieldByIndex.
+func idField(t reflect.Type) (reflect.StructField, bool) {
+	if t.Kind() != reflect.Struct {
+		return reflect.StructField{}, false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, flags, _ := strings.Cut(field.Tag.Get("bson"), ",")
+		if name == "_id" {
+			return field, true
+		}
+		if !field.IsExported() || !hasTagFlag(flags, "inline") {
+			continue
+		}
+		inline := field.Type
+		for inline.Kind() == reflect.Pointer {
+			inline = inline.Elem()
+		}
+		if nested, ok := idField(inline); ok {
+			nested.Index = append([]int{i}, nested.Index...)
+			return nested, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// Name returns the name of the collection
+func (r *Repository[T]) Name() string {
+	return r.collection
+}
+
+// databaseEnsurer is implemented by managers that connect and return the default database in one step
+type databaseEnsurer interface {
+	ensureDatabase(ctx context.Context) (*mongo.Database, error)
+}
+
+// Collection returns the underlying collection, connecting on first use
+func (r *Repository[T]) Collection(ctx context.Context) (*mongo.Collection, error) {
+	if m, ok := r.manager.(databaseEnsurer); ok {
+		database, err := m.ensureDatabase(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return database.Collection(r.collection), nil
+	}
+	if err := r.manager.Connect(ctx); err != nil {
+		return nil, err
+	}
+	return r.manager.Collection(r.collection), nil
+}
+
+// FindByID returns the document with the given ID
+func (r *Repository[T]) FindByID(ctx context.Context, id interface{}, opts ...*options.FindOneOptions) (*T, error) {
+	filter, err := r.idFilter(id)
+	if err != nil {
+		return nil, err
+	}
+	return r.FindOne(ctx, filter, opts...)
+}
+
+// FindOne returns the first document matching filter
+func (r *Repository[T]) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) (*T, error) {
+	collection, err := r.Collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc T
+	err = collection.FindOne(ctx, orEmptyFilter(filter), opts...).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("%w in %s", ErrNotFound, r.collection)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// Find returns every document matching filter
+func (r *Repository[T]) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) ([]T, error) {
+	collection, err := r.Collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := collection.Find(ctx, orEmptyFilter(filter), opts...)
+	if err != nil {
+		return nil, err
+	}
+	docs := []T{}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// Insert inserts a document and returns its ID
+//
+// When the server or driver generates the ID, it is also set on doc's _id field.
+func (r *Repository[T]) Insert(ctx context.Context, doc *T) (interface{}, error) {
+	collection, err := r.Collection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := collection.InsertOne(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+	r.setID(doc, result.InsertedID)
+	return result.InsertedID, nil
+}
+
+// Update applies an update document, such as {"$set": ...}, to the document with the given ID
+func (r *Repository[T]) Update(ctx context.Context, id interface{}, update interface{}, opts ...*options.UpdateOptions) error {
+	filter, err := r.idFilter(id)
+	if err != nil {
+		return err
+	}
+	collection, err := r.Collection(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := collection.UpdateOne(ctx, filter, update, opts...)
+	if err != nil {
+		return err
+	}
+	// With SetUpsert(true), a missing document is inserted rather than reported
+	if result.MatchedCount == 0 && result.UpsertedCount == 0 {
+		return fmt.Errorf("%w in %s: _id %v", ErrNotFound, r.collection, id)
+	}
+	return nil
+}
+
+// Upsert replaces the document with the given ID, inserting it when it does not exist
+func (r *Repository[T]) Upsert(ctx context.Context, id interface{}, doc *T) error {
+	filter, err := r.idFilter(id)
+	if err != nil {
+		return err
+	}
+	collection, err := r.Collection(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.ReplaceOne(ctx, filter, doc, options.Replace().SetUpsert(true))
+	return err
+}
+
+// Delete deletes the document with the given ID
+func (r *Repository[T]) Delete(ctx context.Context, id interface{}) error {
+	filter, err := r.idFilter(id)
+	if err != nil {
+		return err
+	}
+	collection, err := r.Collection(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("%w in %s: _id %v", ErrNotFound, r.collection, id)
+	}
+	return nil
+}
+
+// Count returns the number of documents matching filter
+func (r *Repository[T]) Count(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+	collection, err := r.Collection(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return collection.CountDocuments(ctx, orEmptyFilter(filter), opts...)
+}
+
+// Exists reports whether a document matches filter
+func (r *Repository[T]) Exists(ctx context.Context, filter interface{}) (bool, error) {
+	count, err := r.Count(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// idFilter returns the filter matching an ID, converting hex strings for ObjectID _id fields
+func (r *Repository[T]) idFilter(id interface{}) (bson.D, error) {
+	if hex, ok := id.(string); ok && r.objectID {
+		objectID, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q is not an ObjectID", ErrInvalidID, hex)
+		}
+		id = objectID
+	}
+	return bson.D{{Key: "_id", Value: id}}, nil
+}
+
+// setID sets an inserted ID on doc's _id field when the field is empty and the types match
+func (r *Repository[T]) setID(doc *T, id interface{}) {
+	if r.idField == nil || doc == nil {
+		return
+	}
+	// A nil inline struct pointer leaves no field to set
+	field, err := reflect.ValueOf(doc).Elem().FieldByIndexErr(r.idField)
+	value := reflect.ValueOf(id)
+	if err != nil || !field.CanSet() || !field.IsZero() || !value.IsValid() || !value.Type().AssignableTo(field.Type()) {
+		return
+	}
+	field.Set(value)
+}
+
+// orEmptyFilter replaces a nil filter by an empty document, which matches every document
+func orEmptyFilter(filter interface{}) interface{} {
+	if filter == nil {
+		return bson.D{}
+	}
+	return filter
+}
@@ -0,0 +1,183 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// testUser is a document with an ObjectID _id
+type testUser struct {
+	ID    primitive.ObjectID `bson:"_id,omitempty"`
+	Email string             `bson:"email"`
+}
+
+// testSetting is a document with a string _id
+type testSetting struct {
+	Key   string `bson:"_id"`
+	Value string `bson:"value"`
+}
+
+// testAudited is a document whose _id comes from an inline struct
+type testAudited struct {
+	Base  testUser `bson:",inline"`
+	Notes string   `bson:"notes"`
+}
+
+func TestNewRepository(t *testing.T) {
+	users := NewRepository[testUser](nil, "users")
+	assert.Equal(t, "users", users.Name())
+	assert.True(t, users.objectID)
+
+	settings := NewRepository[testSetting](nil, "settings")
+	assert.NotNil(t, settings.idField)
+	assert.False(t, settings.objectID)
+
+	documents := NewRepository[bson.M](nil, "documents")
+	assert.Nil(t, documents.idField)
+
+	audited := NewRepository[testAudited](nil, "audited")
+	assert.Equal(t, []int{0, 0}, audited.idField)
+	assert.True(t, audited.objectID)
+
+	id := primitive.NewObjectID()
+	filter, err := audited.idFilter(id.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "_id", Value: id}}, filter)
+
+	doc := &testAudited{}
+	audited.setID(doc, id)
+	assert.Equal(t, id, doc.Base.ID)
+}
+
+func TestRepository_IDFilter(t *testing.T) {
+	id := primitive.NewObjectID()
+	users := NewRepository[testUser](nil, "users")
+
+	filter, err := users.idFilter(id.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "_id", Value: id}}, filter)
+
+	_, err = users.idFilter("not-an-object-id")
+	assert.ErrorIs(t, err, ErrInvalidID)
+
+	filter, err = NewRepository[testSetting](nil, "settings").idFilter(id.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, bson.D{{Key: "_id", Value: id.Hex()}}, filter)
+}
+
+func TestRepository(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	ctx := context.Background()
+	cfg := Config{URI: "mongodb://localhost:27017", Database: "testdb"}
+	id := primitive.NewObjectID()
+
+	mt.Run("find by ID", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "testdb.users", mtest.FirstBatch, bson.D{{Key: "_id", Value: id}, {Key: "email", Value: "jane@example.com"}}))
+		users := NewRepository[testUser](createTestManager(mt, cfg), "users")
+
+		user, err := users.FindByID(ctx, id.Hex())
+		assert.NoError(t, err)
+		assert.Equal(t, &testUser{ID: id, Email: "jane@example.com"}, user)
+		assert.Equal(t, id, mt.GetStartedEvent().Command.Lookup("filter", "_id").ObjectID())
+	})
+
+	mt.Run("find one not found", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "testdb.users", mtest.FirstBatch))
+		users := NewRepository[testUser](createTestManager(mt, cfg), "users")
+
+		user, err := users.FindOne(ctx, bson.D{{Key: "email", Value: "nobody@example.com"}})
+		assert.Nil(t, user)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	mt.Run("find", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(1, "testdb.users", mtest.FirstBatch, bson.D{{Key: "email", Value: "a@example.com"}}),
+			mtest.CreateCursorResponse(0, "testdb.users", mtest.NextBatch, bson.D{{Key: "email", Value: "b@example.com"}}),
+		)
+		users := NewRepository[testUser](createTestManager(mt, cfg), "users")
+
+		docs, err := users.Find(ctx, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, []testUser{{Email: "a@example.com"}, {Email: "b@example.com"}}, docs)
+	})
+
+	mt.Run("insert sets the generated ID", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		users := NewRepository[testUser](createTestManager(mt, cfg), "users")
+
+		user := &testUser{Email: "jane@example.com"}
+		inserted, err := users.Insert(ctx, user)
+		assert.NoError(t, err)
+		assert.False(t, user.ID.IsZero())
+		assert.Equal(t, user.ID, inserted)
+	})
+
+	mt.Run("update and delete report missing documents", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}, bson.E{Key: "nModified", Value: 0}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 0}),
+		)
+		settings := NewRepository[testSetting](createTestManager(mt, cfg), "settings")
+
+		err := settings.Update(ctx, "theme", bson.D{{Key: "$set", Value: bson.D{{Key: "value", Value: "dark"}}}})
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.ErrorIs(t, settings.Delete(ctx, "theme"), ErrNotFound)
+	})
+
+	mt.Run("update and delete", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "nModified", Value: 1}),
+			mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}),
+		)
+		settings := NewRepository[testSetting](createTestManager(mt, cfg), "settings")
+
+		assert.NoError(t, settings.Update(ctx, "theme", bson.D{{Key: "$set", Value: bson.D{{Key: "value", Value: "dark"}}}}))
+		assert.NoError(t, settings.Delete(ctx, "theme"))
+	})
+
+	mt.Run("update with upsert inserts a missing document", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(
+			bson.E{Key: "n", Value: 1},
+			bson.E{Key: "nModified", Value: 0},
+			bson.E{Key: "upserted", Value: bson.A{bson.D{{Key: "index", Value: 0}, {Key: "_id", Value: "theme"}}}},
+		))
+		settings := NewRepository[testSetting](createTestManager(mt, cfg), "settings")
+
+		err := settings.Update(ctx, "theme", bson.D{{Key: "$set", Value: bson.D{{Key: "value", Value: "dark"}}}}, options.Update().SetUpsert(true))
+		assert.NoError(t, err)
+		assert.True(t, mt.GetStartedEvent().Command.Lookup("updates", "0", "upsert").Boolean())
+	})
+
+	mt.Run("upsert", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "n", Value: 1}, bson.E{Key: "upserted", Value: bson.A{bson.D{{Key: "index", Value: 0}, {Key: "_id", Value: "theme"}}}}))
+		settings := NewRepository[testSetting](createTestManager(mt, cfg), "settings")
+
+		assert.NoError(t, settings.Upsert(ctx, "theme", &testSetting{Key: "theme", Value: "dark"}))
+		started := mt.GetStartedEvent()
+		assert.Equal(t, "update", started.CommandName)
+		assert.True(t, started.Command.Lookup("updates", "0", "upsert").Boolean())
+	})
+
+	mt.Run("count and exists", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "testdb.users", mtest.FirstBatch, bson.D{{Key: "n", Value: int64(3)}}),
+			mtest.CreateCursorResponse(0, "testdb.users", mtest.FirstBatch),
+		)
+		users := NewRepository[testUser](createTestManager(mt, cfg), "users")
+
+		count, err := users.Count(ctx, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), count)
+
+		exists, err := users.Exists(ctx, bson.D{{Key: "email", Value: "nobody@example.com"}})
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	})
+}
@@ -0,0 +1,57 @@
+package mongodb
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// maxAttempts returns the number of connection attempts, applying the default for zero values
+func (r ConnectRetryConfig) maxAttempts() int {
+	if r.MaxAttempts <= 0 {
+		return DefaultConnectMaxAttempts
+	}
+	return r.MaxAttempts
+}
+
+// delay returns how long to wait after the given number of failed attempts.
+//
+// The delay starts at BaseDelay, doubles after each failure and is capped at MaxDelay.
+// Jitter removes a random fraction of the delay so that replicas restarting together
+// do not retry in lockstep.
+func (r ConnectRetryConfig) delay(failures int) time.Duration {
+	base := time.Duration(r.BaseDelay) * time.Millisecond
+	if base <= 0 {
+		base = DefaultConnectBaseDelay * time.Millisecond
+	}
+	limit := time.Duration(r.MaxDelay) * time.Millisecond
+	if limit <= 0 {
+		limit = DefaultConnectMaxDelay * time.Millisecond
+	}
+
+	delay := base
+	for i := 1; i < failures && delay < limit; i++ {
+		delay *= 2
+	}
+	if delay > limit {
+		delay = limit
+	}
+
+	if r.Jitter > 0 {
+		delay -= time.Duration(rand.Float64() * r.Jitter * float64(delay))
+	}
+	return delay
+}
+
+// sleepContext waits for d or until ctx is done, returning the context error in the latter case
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
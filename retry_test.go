@@ -0,0 +1,43 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnectRetryConfig_Delay(t *testing.T) {
+	retry := ConnectRetryConfig{BaseDelay: 100, MaxDelay: 1000}
+
+	assert.Equal(t, 100*time.Millisecond, retry.delay(1))
+	assert.Equal(t, 200*time.Millisecond, retry.delay(2))
+	assert.Equal(t, 400*time.Millisecond, retry.delay(3))
+	assert.Equal(t, 800*time.Millisecond, retry.delay(4))
+	assert.Equal(t, time.Second, retry.delay(5))
+	assert.Equal(t, time.Second, retry.delay(50))
+
+	t.Run("defaults", func(t *testing.T) {
+		assert.Equal(t, DefaultConnectBaseDelay*time.Millisecond, ConnectRetryConfig{}.delay(1))
+		assert.Equal(t, DefaultConnectMaxDelay*time.Millisecond, ConnectRetryConfig{}.delay(20))
+		assert.Equal(t, DefaultConnectMaxAttempts, ConnectRetryConfig{}.maxAttempts())
+	})
+
+	t.Run("jitter", func(t *testing.T) {
+		retry := ConnectRetryConfig{BaseDelay: 1000, MaxDelay: 1000, Jitter: 0.5}
+		for i := 0; i < 100; i++ {
+			delay := retry.delay(1)
+			assert.GreaterOrEqual(t, delay, 500*time.Millisecond)
+			assert.LessOrEqual(t, delay, time.Second)
+		}
+	})
+}
+
+func TestSleepContext(t *testing.T) {
+	assert.NoError(t, sleepContext(context.Background(), time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, sleepContext(ctx, time.Hour), context.Canceled)
+}
@@ -0,0 +1,52 @@
+package mongodb
+
+import (
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Server error codes returned when a command does not comply with the declared Stable API
+const (
+	apiVersionErrorCode     = 322
+	apiStrictErrorCode      = 323
+	apiDeprecationErrorCode = 324
+)
+
+// newServerAPIOptions builds the Stable API options from the server API configuration.
+//
+// An empty version returns nil so that no API version is declared to the server.
+func newServerAPIOptions(cfg ServerAPIConfig) (*options.ServerAPIOptions, error) {
+	if cfg.Version == "" {
+		if cfg.Strict || cfg.DeprecationErrors {
+			return nil, errors.New("invalid server API configuration: strict and deprecation_errors require a version")
+		}
+		return nil, nil
+	}
+
+	version := options.ServerAPIVersion(cfg.Version)
+	if err := version.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid server API configuration: %w", err)
+	}
+
+	return options.ServerAPI(version).
+		SetStrict(cfg.Strict).
+		SetDeprecationErrors(cfg.DeprecationErrors), nil
+}
+
+// wrapServerAPIError annotates errors returned when the server rejects the declared Stable API.
+func wrapServerAPIError(err error, cfg ServerAPIConfig) error {
+	var serverErr mongo.ServerError
+	if err == nil || cfg.Version == "" || !errors.As(err, &serverErr) {
+		return err
+	}
+	if serverErr.HasErrorCode(apiVersionErrorCode) ||
+		serverErr.HasErrorCode(apiStrictErrorCode) ||
+		serverErr.HasErrorCode(apiDeprecationErrorCode) {
+		return fmt.Errorf("server rejected Stable API version %q (strict: %t, deprecation_errors: %t): %w",
+			cfg.Version, cfg.Strict, cfg.DeprecationErrors, err)
+	}
+	return err
+}
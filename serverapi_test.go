@@ -0,0 +1,66 @@
+package mongodb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestNewServerAPIOptions(t *testing.T) {
+	t.Run("no version", func(t *testing.T) {
+		opts, err := newServerAPIOptions(ServerAPIConfig{})
+		assert.NoError(t, err)
+		assert.Nil(t, opts)
+	})
+
+	t.Run("version 1 with strict and deprecation errors", func(t *testing.T) {
+		opts, err := newServerAPIOptions(ServerAPIConfig{Version: "1", Strict: true, DeprecationErrors: true})
+		assert.NoError(t, err)
+		assert.Equal(t, options.ServerAPIVersion1, opts.ServerAPIVersion)
+		assert.True(t, *opts.Strict)
+		assert.True(t, *opts.DeprecationErrors)
+	})
+
+	t.Run("unsupported version", func(t *testing.T) {
+		_, err := newServerAPIOptions(ServerAPIConfig{Version: "2"})
+		assert.ErrorContains(t, err, "not supported")
+	})
+
+	t.Run("strict without version", func(t *testing.T) {
+		_, err := newServerAPIOptions(ServerAPIConfig{Strict: true})
+		assert.ErrorContains(t, err, "require a version")
+	})
+}
+
+func TestWrapServerAPIError(t *testing.T) {
+	cfg := ServerAPIConfig{Version: "1", Strict: true}
+
+	t.Run("api version error", func(t *testing.T) {
+		cmdErr := mongo.CommandError{Code: apiVersionErrorCode, Name: "APIVersionError", Message: "API version 1 is not supported"}
+		err := wrapServerAPIError(cmdErr, cfg)
+		assert.ErrorContains(t, err, `server rejected Stable API version "1"`)
+		assert.True(t, errors.As(err, &mongo.CommandError{}))
+	})
+
+	t.Run("api strict error", func(t *testing.T) {
+		err := wrapServerAPIError(mongo.CommandError{Code: apiStrictErrorCode, Name: "APIStrictError"}, cfg)
+		assert.ErrorContains(t, err, "server rejected Stable API version")
+	})
+
+	t.Run("unrelated error is untouched", func(t *testing.T) {
+		cmdErr := mongo.CommandError{Code: 13, Name: "Unauthorized"}
+		assert.Equal(t, cmdErr, wrapServerAPIError(cmdErr, cfg))
+	})
+
+	t.Run("no version configured", func(t *testing.T) {
+		cmdErr := mongo.CommandError{Code: apiVersionErrorCode}
+		assert.Equal(t, cmdErr, wrapServerAPIError(cmdErr, ServerAPIConfig{}))
+	})
+
+	t.Run("nil error", func(t *testing.T) {
+		assert.NoError(t, wrapServerAPIError(nil, cfg))
+	})
+}
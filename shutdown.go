@@ -0,0 +1,137 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ShutdownHookRegistrar is implemented by applications that run hooks while shutting down.
+//
+// When the application passed to ServiceProvider.Register implements it, the provider
+// registers its Shutdown method so that connections are closed gracefully on termination.
+type ShutdownHookRegistrar interface {
+	OnShutdown(hook func(ctx context.Context) error)
+}
+
+// trackChangeStream records a change stream opened through the manager so Shutdown can close it
+func (m *manager) trackChangeStream(stream *mongo.ChangeStream) {
+	m.trackedMu.Lock()
+	defer m.trackedMu.Unlock()
+
+	m.pruneTrackedLocked()
+	if m.changeStreams == nil {
+		m.changeStreams = make(map[*mongo.ChangeStream]struct{})
+	}
+	m.changeStreams[stream] = struct{}{}
+}
+
+// trackSession records a session started through the manager so Shutdown can end it
+func (m *manager) trackSession(session mongo.Session) {
+	m.trackedMu.Lock()
+	defer m.trackedMu.Unlock()
+
+	m.pruneTrackedLocked()
+	if m.sessions == nil {
+		m.sessions = make(map[mongo.Session]struct{})
+	}
+	m.sessions[session] = struct{}{}
+}
+
+// pruneTracked stops tracking the change streams and sessions their owners already closed
+func (m *manager) pruneTracked() {
+	m.trackedMu.Lock()
+	defer m.trackedMu.Unlock()
+
+	m.pruneTrackedLocked()
+}
+
+// pruneTrackedLocked is pruneTracked for callers holding trackedMu
+//
+// Pruning runs whenever a stream or session is tracked, so the maps only keep
+// the resources still open plus those closed since the last one was opened.
+func (m *manager) pruneTrackedLocked() {
+	for stream := range m.changeStreams {
+		if changeStreamClosed(stream) {
+			delete(m.changeStreams, stream)
+		}
+	}
+	for session := range m.sessions {
+		if sessionEnded(session) {
+			delete(m.sessions, session)
+		}
+	}
+}
+
+// changeStreamClosed reports whether Close was called on a change stream
+//
+// The ID cannot tell: a stream whose first batch came back without a cursor also
+// reports a zero ID before it is closed. Close drops the stream's cursor, which is
+// only visible through reflection, so streams are kept when that field is missing.
+func changeStreamClosed(stream *mongo.ChangeStream) bool {
+	cursor := reflect.ValueOf(stream).Elem().FieldByName("cursor")
+	return cursor.IsValid() && cursor.Kind() == reflect.Interface && cursor.IsNil()
+}
+
+// sessionEnded reports whether EndSession was called on a session
+func sessionEnded(session mongo.Session) bool {
+	xs, ok := session.(mongo.XSession)
+	return ok && xs.ClientSession().Terminated
+}
+
+// releaseTracked returns the tracked change streams and sessions and stops tracking them
+func (m *manager) releaseTracked() ([]*mongo.ChangeStream, []mongo.Session) {
+	m.trackedMu.Lock()
+	defer m.trackedMu.Unlock()
+
+	streams := make([]*mongo.ChangeStream, 0, len(m.changeStreams))
+	for stream := range m.changeStreams {
+		streams = append(streams, stream)
+	}
+	sessions := make([]mongo.Session, 0, len(m.sessions))
+	for session := range m.sessions {
+		sessions = append(sessions, session)
+	}
+	m.changeStreams = nil
+	m.sessions = nil
+	return streams, sessions
+}
+
+// Shutdown gracefully closes the connection
+//
+// Change streams opened by Watch* and sessions returned by StartSession are closed first,
+// so their consumers stop. Shutdown then waits until the commands in flight complete or
+// ctx is done, and finally disconnects; connections still in use when ctx is done are
+// closed forcibly. Closing a change stream or session that was already closed is harmless.
+//
+// The manager cannot be used afterwards: every method needing a connection returns ErrClosed
+// (Client and Database panic with it) instead of dialing a new pool.
+func (m *manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+
+	var errs []error
+
+	streams, sessions := m.releaseTracked()
+	for _, stream := range streams {
+		if err := stream.Close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close change stream: %w", err))
+		}
+	}
+	for _, session := range sessions {
+		session.EndSession(ctx)
+	}
+
+	if err := m.operations.wait(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("%d MongoDB operations still in flight: %w", m.operations.count(), err))
+	}
+
+	if err := m.Disconnect(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to disconnect from MongoDB: %w", err))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,130 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestManager_Shutdown(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("closes tracked change streams and sessions", func(mt *mtest.T) {
+		first := mtest.CreateCursorResponse(1, "testdb.$cmd.aggregate", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: bson.D{{Key: "_data", Value: "test"}}},
+			{Key: "operationType", Value: "insert"},
+		})
+		killCursors := mtest.CreateCursorResponse(0, "testdb.$cmd.aggregate", mtest.NextBatch)
+		mt.AddMockResponses(first, killCursors)
+
+		mgr := createTestManager(mt, Config{URI: "mongodb://localhost:27017", Database: "testdb"}).(*manager)
+
+		stream, err := mgr.Watch(context.Background(), mongo.Pipeline{})
+		assert.NoError(t, err)
+		session, err := mgr.StartSession()
+		assert.NoError(t, err)
+		assert.Len(t, mgr.changeStreams, 1)
+		assert.Len(t, mgr.sessions, 1)
+
+		// The mock deployment cannot be disconnected, so only the tracked resources are closed
+		mgr.client, mgr.database = nil, nil
+		assert.NoError(t, mgr.Shutdown(context.Background()))
+
+		assert.Empty(t, mgr.changeStreams)
+		assert.Empty(t, mgr.sessions)
+		assert.Equal(t, int64(0), stream.ID())
+		assert.Error(t, session.AdvanceClusterTime(bson.Raw{}), "session should have been ended")
+	})
+
+	mt.Run("forgets closed change streams and sessions", func(mt *mtest.T) {
+		mgr := createTestManager(mt, Config{URI: "mongodb://localhost:27017", Database: "testdb"}).(*manager)
+		ctx := context.Background()
+
+		for i := 0; i < 5; i++ {
+			mt.AddMockResponses(
+				mtest.CreateCursorResponse(1, "testdb.$cmd.aggregate", mtest.FirstBatch),
+				mtest.CreateCursorResponse(0, "testdb.$cmd.aggregate", mtest.NextBatch),
+			)
+			stream, err := mgr.Watch(ctx, mongo.Pipeline{})
+			assert.NoError(t, err)
+			session, err := mgr.StartSession()
+			assert.NoError(t, err)
+			assert.LessOrEqual(t, len(mgr.changeStreams), 1)
+			assert.LessOrEqual(t, len(mgr.sessions), 1)
+
+			assert.NoError(t, stream.Close(ctx))
+			session.EndSession(ctx)
+		}
+
+		mgr.pruneTracked()
+		assert.Empty(t, mgr.changeStreams)
+		assert.Empty(t, mgr.sessions)
+	})
+
+	mt.Run("keeps open change streams that report a zero ID", func(mt *mtest.T) {
+		mgr := createTestManager(mt, Config{URI: "mongodb://localhost:27017", Database: "testdb"}).(*manager)
+		ctx := context.Background()
+
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "testdb.$cmd.aggregate", mtest.FirstBatch))
+		stream, err := mgr.Watch(ctx, mongo.Pipeline{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), stream.ID())
+
+		mgr.pruneTracked()
+		assert.Len(t, mgr.changeStreams, 1)
+
+		assert.NoError(t, stream.Close(ctx))
+		mgr.pruneTracked()
+		assert.Empty(t, mgr.changeStreams)
+	})
+
+	t.Run("gives up waiting for in-flight operations", func(t *testing.T) {
+		var calls int32
+		mgr := &manager{config: &Config{URI: "mongodb://localhost:27017", Database: "testdb"}}
+		mgr.dial = countingDialer(t, &calls)
+		assert.NoError(t, mgr.Connect(context.Background()))
+		mgr.operations.start(42)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		err := mgr.Shutdown(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.ErrorContains(t, err, "1 MongoDB operations still in flight")
+		assert.Nil(t, mgr.client, "the client is disconnected even when operations are still in flight")
+	})
+}
+
+func TestManager_UseAfterShutdown(t *testing.T) {
+	var calls int32
+	mgr := &manager{config: &Config{URI: "mongodb://localhost:27017", Database: "testdb"}}
+	mgr.dial = countingDialer(t, &calls)
+	ctx := context.Background()
+	assert.NoError(t, mgr.Connect(ctx))
+	assert.NoError(t, mgr.Shutdown(ctx))
+
+	assert.ErrorIs(t, mgr.Connect(ctx), ErrClosed)
+	assert.ErrorIs(t, mgr.Ping(ctx), ErrClosed)
+	_, err := mgr.runCommand(ctx, "admin", bson.D{{Key: "ping", Value: 1}})
+	assert.ErrorIs(t, err, ErrClosed)
+	_, err = mgr.StartSession()
+	assert.ErrorIs(t, err, ErrClosed)
+	_, err = mgr.Watch(ctx, mongo.Pipeline{})
+	assert.ErrorIs(t, err, ErrClosed)
+	_, err = NewRepository[testUser](mgr, "users").Collection(ctx)
+	assert.ErrorIs(t, err, ErrClosed)
+	assert.Panics(t, func() { mgr.Database() })
+	assert.Equal(t, int32(1), calls, "no new pool is dialed after Shutdown")
+	assert.NoError(t, mgr.Disconnect(ctx))
+}
+
+func TestManager_ShutdownBeforeConnect(t *testing.T) {
+	mgr, err := newManager(Config{URI: "mongodb://localhost:27017"})
+	assert.NoError(t, err)
+	assert.NoError(t, mgr.Shutdown(context.Background()))
+}
@@ -0,0 +1,393 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// explainableCommands are the commands the explain command accepts
+var explainableCommands = map[string]bool{
+	"find":          true,
+	"aggregate":     true,
+	"count":         true,
+	"distinct":      true,
+	"update":        true,
+	"delete":        true,
+	"findAndModify": true,
+}
+
+// unexplainableFields are command fields the explain command rejects in the explained command
+var unexplainableFields = map[string]bool{
+	"$readPreference": true,
+	"readConcern":     true,
+	"writeConcern":    true,
+}
+
+// SlowQuery describes a command that took longer than the slow query threshold
+type SlowQuery struct {
+	Command      string        // Command name, e.g. find or aggregate
+	Database     string        // Database the command ran against
+	Collection   string        // Collection the command targeted, empty for database level commands
+	Duration     time.Duration // Time the command took
+	RequestID    int64         // Driver request ID
+	ConnectionID string        // Driver connection ID
+	Filter       bson.D        // Shape of the filter (values replaced by "?"), nil when the command has none
+	Shape        bson.D        // Shape of the whole command (values replaced by "?")
+	Error        string        // Failure message when the command failed
+	Time         time.Time     // When the command finished
+
+	// Plan is the explain summary, filled in before reporting when slow_query.explain is enabled
+	Plan *ExplainSummary
+	// PlanError is set when the explain requested by slow_query.explain failed
+	PlanError string
+
+	explainer func(ctx context.Context) (*ExplainSummary, error)
+}
+
+// Namespace returns the namespace of the query as "database.collection"
+func (q SlowQuery) Namespace() string {
+	if q.Collection == "" {
+		return q.Database
+	}
+	return q.Database + "." + q.Collection
+}
+
+// Explain runs the explain command for the query with queryPlanner verbosity
+//
+// The original command, including its values, is explained, so the plan matches the one
+// the server chose. Only find, aggregate, count, distinct, update, delete and findAndModify
+// can be explained.
+func (q SlowQuery) Explain(ctx context.Context) (*ExplainSummary, error) {
+	if q.explainer == nil {
+		return nil, fmt.Errorf("command %q cannot be explained", q.Command)
+	}
+	return q.explainer(ctx)
+}
+
+// ExplainSummary summarizes the winning plan of an explain result
+type ExplainSummary struct {
+	Stages         []string // Plan stages from the root down, e.g. FETCH, IXSCAN
+	Indexes        []string // Indexes used by the plan
+	CollectionScan bool     // Whether the plan scans the whole collection
+}
+
+// SlowQuerySink receives slow query reports
+type SlowQuerySink interface {
+	// Report delivers a slow query; it must not block for long
+	Report(ctx context.Context, query SlowQuery)
+}
+
+// logSlowQuerySink reports slow queries through slog
+type logSlowQuerySink struct {
+	logger *slog.Logger
+}
+
+// NewLogSlowQuerySink returns a sink logging slow queries at warn level
+func NewLogSlowQuerySink(logger *slog.Logger) SlowQuerySink {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &logSlowQuerySink{logger: logger}
+}
+
+// Report logs the slow query
+func (s *logSlowQuerySink) Report(ctx context.Context, query SlowQuery) {
+	attrs := []slog.Attr{
+		slog.String("command", query.Command),
+		slog.String("namespace", query.Namespace()),
+		slog.Duration("duration", query.Duration),
+		slog.Int64("request_id", query.RequestID),
+	}
+	if query.Filter != nil {
+		if filter, err := bson.MarshalExtJSON(query.Filter, false, false); err == nil {
+			attrs = append(attrs, slog.String("filter", string(filter)))
+		}
+	}
+	if query.Error != "" {
+		attrs = append(attrs, slog.String("error", query.Error))
+	}
+	if query.Plan != nil {
+		attrs = append(attrs,
+			slog.Any("plan", query.Plan.Stages),
+			slog.Any("indexes", query.Plan.Indexes),
+			slog.Bool("collection_scan", query.Plan.CollectionScan),
+		)
+	}
+	if query.PlanError != "" {
+		attrs = append(attrs, slog.String("plan_error", query.PlanError))
+	}
+	s.logger.LogAttrs(ctx, slog.LevelWarn, "MongoDB slow query", attrs...)
+}
+
+// MemorySlowQuerySink keeps slow query reports in memory, mainly for tests
+type MemorySlowQuerySink struct {
+	mu      sync.Mutex
+	queries []SlowQuery
+}
+
+// NewMemorySlowQuerySink returns an empty in-memory sink
+func NewMemorySlowQuerySink() *MemorySlowQuerySink {
+	return &MemorySlowQuerySink{}
+}
+
+// Report stores the slow query
+func (s *MemorySlowQuerySink) Report(_ context.Context, query SlowQuery) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queries = append(s.queries, query)
+}
+
+// Queries returns the reported slow queries in order
+func (s *MemorySlowQuerySink) Queries() []SlowQuery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SlowQuery(nil), s.queries...)
+}
+
+// Reset forgets the reported slow queries
+func (s *MemorySlowQuerySink) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queries = nil
+}
+
+// validate checks the slow query configuration
+func (c SlowQueryConfig) validate() error {
+	if c.Enabled && c.Threshold == 0 {
+		return fmt.Errorf("slow_query: threshold must be greater than 0")
+	}
+	return nil
+}
+
+// slowQueryDetector reports commands exceeding the slow query threshold to a sink
+type slowQueryDetector struct {
+	threshold time.Duration
+	explain   bool
+	ignored   map[string]bool
+	sink      SlowQuerySink
+
+	// run executes a command against a database, used to explain slow queries
+	run func(ctx context.Context, database string, cmd bson.D) (bson.Raw, error)
+
+	// explains holds a slot per explain running for a report, see DefaultSlowQueryMaxExplains
+	explains chan struct{}
+
+	// commands keeps the started commands by request ID until they finish
+	commands sync.Map
+}
+
+// startedCommand is a command waiting for its finished event
+type startedCommand struct {
+	command bson.Raw
+}
+
+// newSlowQueryDetector creates a detector from the slow query configuration
+func newSlowQueryDetector(cfg SlowQueryConfig, sink SlowQuerySink, run func(ctx context.Context, database string, cmd bson.D) (bson.Raw, error)) *slowQueryDetector {
+	ignored := map[string]bool{"explain": true}
+	for _, name := range cfg.IgnoreCommands {
+		ignored[name] = true
+	}
+	return &slowQueryDetector{
+		threshold: time.Duration(cfg.Threshold) * time.Millisecond,
+		explain:   cfg.Explain,
+		ignored:   ignored,
+		sink:      sink,
+		run:       run,
+		explains:  make(chan struct{}, DefaultSlowQueryMaxExplains),
+	}
+}
+
+// monitor returns a command monitor feeding the detector
+func (d *slowQueryDetector) monitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(_ context.Context, evt *event.CommandStartedEvent) {
+			if d.ignored[evt.CommandName] {
+				return
+			}
+			// The event only owns the command during the callback
+			d.commands.Store(evt.RequestID, startedCommand{command: append(bson.Raw(nil), evt.Command...)})
+		},
+		Succeeded: func(ctx context.Context, evt *event.CommandSucceededEvent) {
+			d.finished(ctx, &evt.CommandFinishedEvent, "")
+		},
+		Failed: func(ctx context.Context, evt *event.CommandFailedEvent) {
+			d.finished(ctx, &evt.CommandFinishedEvent, evt.Failure)
+		},
+	}
+}
+
+// finished reports a command when it exceeded the threshold
+func (d *slowQueryDetector) finished(ctx context.Context, evt *event.CommandFinishedEvent, failure string) {
+	value, ok := d.commands.LoadAndDelete(evt.RequestID)
+	if !ok || evt.Duration < d.threshold {
+		return
+	}
+	cmd := value.(startedCommand).command
+
+	query := SlowQuery{
+		Command:      evt.CommandName,
+		Database:     evt.DatabaseName,
+		Collection:   commandCollection(cmd),
+		Duration:     evt.Duration,
+		RequestID:    evt.RequestID,
+		ConnectionID: evt.ConnectionID,
+		Filter:       filterShape(evt.CommandName, cmd),
+		Shape:        commandShape(cmd),
+		Error:        failure,
+		Time:         time.Now(),
+	}
+	if explainableCommands[evt.CommandName] && d.run != nil {
+		database := evt.DatabaseName
+		query.explainer = func(ctx context.Context) (*ExplainSummary, error) {
+			result, err := d.run(ctx, database, bson.D{
+				{Key: "explain", Value: explainableCommand(cmd)},
+				{Key: "verbosity", Value: "queryPlanner"},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to explain %s: %w", query.Command, err)
+			}
+			return summarizeExplain(result), nil
+		}
+	}
+
+	if !d.explain || query.explainer == nil {
+		d.sink.Report(ctx, query)
+		return
+	}
+
+	// When queries are slow because the server is overloaded, explaining all of them would
+	// add to the load, so the query is reported without plan once every slot is taken
+	select {
+	case d.explains <- struct{}{}:
+	default:
+		query.PlanError = "explain skipped: too many explains in progress"
+		d.sink.Report(ctx, query)
+		return
+	}
+
+	// Running explain inside the event callback would delay the application's operation
+	go func() {
+		defer func() { <-d.explains }()
+		explainCtx, cancel := context.WithTimeout(context.Background(), DefaultSlowQueryExplainTimeout)
+		defer cancel()
+		plan, err := query.Explain(explainCtx)
+		if err != nil {
+			query.PlanError = err.Error()
+		}
+		query.Plan = plan
+		d.sink.Report(explainCtx, query)
+	}()
+}
+
+// filterShape returns the shape of the filter of a command, or nil when it has none
+func filterShape(command string, cmd bson.Raw) bson.D {
+	var filter bson.RawValue
+	switch command {
+	case "find":
+		filter = cmd.Lookup("filter")
+	case "count", "distinct", "findAndModify":
+		filter = cmd.Lookup("query")
+	case "update":
+		filter = cmd.Lookup("updates", "0", "q")
+	case "delete":
+		filter = cmd.Lookup("deletes", "0", "q")
+	case "aggregate":
+		// The leading $match stage filters the documents read from the collection
+		filter = cmd.Lookup("pipeline", "0", "$match")
+	}
+	if filter.Type != bson.TypeEmbeddedDocument {
+		return nil
+	}
+	shape, _ := valueShape(filter).(bson.D)
+	return shape
+}
+
+// explainableCommand strips the driver bookkeeping and the fields the explain command rejects
+func explainableCommand(cmd bson.Raw) bson.D {
+	elems, err := cmd.Elements()
+	if err != nil {
+		return nil
+	}
+	doc := make(bson.D, 0, len(elems))
+	for _, elem := range elems {
+		if ignoredCommandFields[elem.Key()] || unexplainableFields[elem.Key()] {
+			continue
+		}
+		doc = append(doc, bson.E{Key: elem.Key(), Value: elem.Value()})
+	}
+	return doc
+}
+
+// summarizeExplain extracts the winning plan stages and indexes from an explain result
+func summarizeExplain(result bson.Raw) *ExplainSummary {
+	summary := &ExplainSummary{}
+	plan, ok := findWinningPlan(result)
+	if !ok {
+		return summary
+	}
+	// Slot based execution wraps the classic plan
+	if inner, ok := plan.Lookup("queryPlan").DocumentOK(); ok {
+		plan = inner
+	}
+
+	for {
+		stage, _ := plan.Lookup("stage").StringValueOK()
+		if stage != "" {
+			summary.Stages = append(summary.Stages, stage)
+		}
+		if stage == "COLLSCAN" {
+			summary.CollectionScan = true
+		}
+		if index, ok := plan.Lookup("indexName").StringValueOK(); ok {
+			summary.Indexes = append(summary.Indexes, index)
+		}
+
+		next, ok := plan.Lookup("inputStage").DocumentOK()
+		if !ok {
+			// Plans such as OR have several inputs, follow the first one
+			if stages, isArray := plan.Lookup("inputStages").ArrayOK(); isArray {
+				if first, err := stages.IndexErr(0); err == nil {
+					next, ok = first.Value().DocumentOK()
+				}
+			}
+		}
+		if !ok {
+			return summary
+		}
+		plan = next
+	}
+}
+
+// findWinningPlan looks up queryPlanner.winningPlan anywhere in an explain result, since
+// aggregate explains nest it inside their first stage
+func findWinningPlan(doc bson.Raw) (bson.Raw, bool) {
+	if plan, ok := doc.Lookup("queryPlanner", "winningPlan").DocumentOK(); ok {
+		return plan, true
+	}
+	elems, err := doc.Elements()
+	if err != nil {
+		return nil, false
+	}
+	for _, elem := range elems {
+		value := elem.Value()
+		var nested bson.Raw
+		switch value.Type {
+		case bson.TypeEmbeddedDocument:
+			nested = value.Document()
+		case bson.TypeArray:
+			nested = value.Array()
+		default:
+			continue
+		}
+		if plan, ok := findWinningPlan(nested); ok {
+			return plan, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,253 @@
+package mongodb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// marshalRaw marshals a document for the tests
+func marshalRaw(t *testing.T, doc interface{}) bson.Raw {
+	t.Helper()
+	raw, err := bson.Marshal(doc)
+	assert.NoError(t, err)
+	return raw
+}
+
+// runSlowCommand feeds a started and a succeeded event with the given duration to a monitor
+func runSlowCommand(monitor *event.CommandMonitor, requestID int64, name string, cmd bson.Raw, duration time.Duration) {
+	ctx := context.Background()
+	monitor.Started(ctx, &event.CommandStartedEvent{Command: cmd, CommandName: name, DatabaseName: "testdb", RequestID: requestID})
+	monitor.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: event.CommandFinishedEvent{
+		CommandName: name, DatabaseName: "testdb", RequestID: requestID, Duration: duration,
+	}})
+}
+
+func TestSlowQueryDetector(t *testing.T) {
+	cfg := SlowQueryConfig{Enabled: true, Threshold: 100, IgnoreCommands: []string{"getMore"}}
+
+	t.Run("reports commands over the threshold", func(t *testing.T) {
+		sink := NewMemorySlowQuerySink()
+		monitor := newSlowQueryDetector(cfg, sink, nil).monitor()
+
+		runSlowCommand(monitor, 1, "find", findCommand(t), 50*time.Millisecond)
+		runSlowCommand(monitor, 2, "find", findCommand(t), 150*time.Millisecond)
+		runSlowCommand(monitor, 3, "getMore", marshalRaw(t, bson.D{{Key: "getMore", Value: int64(1)}, {Key: "collection", Value: "users"}}), time.Second)
+
+		queries := sink.Queries()
+		assert.Len(t, queries, 1)
+		query := queries[0]
+		assert.Equal(t, "find", query.Command)
+		assert.Equal(t, "testdb.users", query.Namespace())
+		assert.Equal(t, int64(2), query.RequestID)
+		assert.Equal(t, 150*time.Millisecond, query.Duration)
+		assert.Equal(t, bson.D{{Key: "email", Value: "?"}}, query.Filter)
+		assert.NotContains(t, query.Shape, bson.E{Key: "lsid", Value: bson.D{{Key: "id", Value: "?"}}})
+	})
+
+	t.Run("reports failed commands", func(t *testing.T) {
+		sink := NewMemorySlowQuerySink()
+		monitor := newSlowQueryDetector(cfg, sink, nil).monitor()
+
+		monitor.Started(context.Background(), &event.CommandStartedEvent{Command: findCommand(t), CommandName: "find", DatabaseName: "testdb", RequestID: 4})
+		monitor.Failed(context.Background(), &event.CommandFailedEvent{CommandFinishedEvent: event.CommandFinishedEvent{
+			CommandName: "find", DatabaseName: "testdb", RequestID: 4, Duration: time.Second,
+		}, Failure: "operation exceeded time limit"})
+
+		assert.Len(t, sink.Queries(), 1)
+		assert.Equal(t, "operation exceeded time limit", sink.Queries()[0].Error)
+	})
+
+	t.Run("explains on demand", func(t *testing.T) {
+		var explained bson.D
+		run := func(_ context.Context, database string, cmd bson.D) (bson.Raw, error) {
+			assert.Equal(t, "testdb", database)
+			explained = cmd
+			return marshalRaw(t, bson.D{{Key: "queryPlanner", Value: bson.D{{Key: "winningPlan", Value: bson.D{{Key: "stage", Value: "COLLSCAN"}}}}}}), nil
+		}
+		sink := NewMemorySlowQuerySink()
+		monitor := newSlowQueryDetector(cfg, sink, run).monitor()
+		runSlowCommand(monitor, 5, "find", findCommand(t), time.Second)
+
+		query := sink.Queries()[0]
+		assert.Nil(t, query.Plan)
+		plan, err := query.Explain(context.Background())
+		assert.NoError(t, err)
+		assert.True(t, plan.CollectionScan)
+
+		// The original values are explained, without the session bookkeeping
+		inner, ok := explained[0].Value.(bson.D)
+		assert.True(t, ok)
+		assert.Equal(t, "explain", explained[0].Key)
+		assert.Len(t, inner, 2)
+		assert.Equal(t, "jane@example.com", inner[1].Value.(bson.RawValue).Document().Lookup("email").StringValue())
+	})
+
+	t.Run("attaches the plan when explain is enabled", func(t *testing.T) {
+		run := func(context.Context, string, bson.D) (bson.Raw, error) {
+			return nil, errors.New("not authorized")
+		}
+		sink := NewMemorySlowQuerySink()
+		monitor := newSlowQueryDetector(SlowQueryConfig{Enabled: true, Threshold: 1, Explain: true}, sink, run).monitor()
+		runSlowCommand(monitor, 6, "find", findCommand(t), time.Second)
+
+		assert.Eventually(t, func() bool { return len(sink.Queries()) == 1 }, time.Second, time.Millisecond)
+		assert.Contains(t, sink.Queries()[0].PlanError, "not authorized")
+	})
+
+	t.Run("explains running at once are bounded", func(t *testing.T) {
+		release := make(chan struct{})
+		run := func(context.Context, string, bson.D) (bson.Raw, error) {
+			<-release
+			return marshalRaw(t, bson.D{{Key: "ok", Value: 1}}), nil
+		}
+		sink := NewMemorySlowQuerySink()
+		monitor := newSlowQueryDetector(SlowQueryConfig{Enabled: true, Threshold: 1, Explain: true}, sink, run).monitor()
+		for i := 0; i < DefaultSlowQueryMaxExplains+2; i++ {
+			runSlowCommand(monitor, int64(10+i), "find", findCommand(t), time.Second)
+		}
+
+		// The queries beyond the limit are reported at once, without plan
+		skipped := sink.Queries()
+		assert.Len(t, skipped, 2)
+		for _, query := range skipped {
+			assert.Nil(t, query.Plan)
+			assert.Contains(t, query.PlanError, "explain skipped")
+		}
+
+		close(release)
+		assert.Eventually(t, func() bool { return len(sink.Queries()) == DefaultSlowQueryMaxExplains+2 }, time.Second, time.Millisecond)
+	})
+
+	t.Run("commands without a plan cannot be explained", func(t *testing.T) {
+		sink := NewMemorySlowQuerySink()
+		monitor := newSlowQueryDetector(cfg, sink, nil).monitor()
+		runSlowCommand(monitor, 7, "insert", marshalRaw(t, bson.D{{Key: "insert", Value: "users"}}), time.Second)
+
+		_, err := sink.Queries()[0].Explain(context.Background())
+		assert.ErrorContains(t, err, `command "insert" cannot be explained`)
+	})
+}
+
+func TestFilterShape(t *testing.T) {
+	filter := bson.D{{Key: "age", Value: bson.D{{Key: "$gt", Value: 30}}}}
+	want := bson.D{{Key: "age", Value: bson.D{{Key: "$gt", Value: "?"}}}}
+
+	tests := []struct {
+		name    string
+		command string
+		cmd     bson.D
+		want    bson.D
+	}{
+		{name: "find", command: "find", cmd: bson.D{{Key: "find", Value: "users"}, {Key: "filter", Value: filter}}, want: want},
+		{name: "count", command: "count", cmd: bson.D{{Key: "count", Value: "users"}, {Key: "query", Value: filter}}, want: want},
+		{name: "update", command: "update", cmd: bson.D{{Key: "update", Value: "users"}, {Key: "updates", Value: bson.A{bson.D{{Key: "q", Value: filter}}}}}, want: want},
+		{name: "delete", command: "delete", cmd: bson.D{{Key: "delete", Value: "users"}, {Key: "deletes", Value: bson.A{bson.D{{Key: "q", Value: filter}}}}}, want: want},
+		{name: "aggregate", command: "aggregate", cmd: bson.D{{Key: "aggregate", Value: "users"}, {Key: "pipeline", Value: bson.A{bson.D{{Key: "$match", Value: filter}}}}}, want: want},
+		{name: "aggregate without $match", command: "aggregate", cmd: bson.D{{Key: "aggregate", Value: "users"}, {Key: "pipeline", Value: bson.A{bson.D{{Key: "$limit", Value: 1}}}}}},
+		{name: "insert", command: "insert", cmd: bson.D{{Key: "insert", Value: "users"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, filterShape(tt.command, marshalRaw(t, tt.cmd)))
+		})
+	}
+}
+
+func TestSummarizeExplain(t *testing.T) {
+	ixscan := bson.D{{Key: "stage", Value: "IXSCAN"}, {Key: "indexName", Value: "email_1"}}
+
+	tests := []struct {
+		name   string
+		result bson.D
+		want   *ExplainSummary
+	}{
+		{
+			name:   "classic plan",
+			result: bson.D{{Key: "queryPlanner", Value: bson.D{{Key: "winningPlan", Value: bson.D{{Key: "stage", Value: "FETCH"}, {Key: "inputStage", Value: ixscan}}}}}},
+			want:   &ExplainSummary{Stages: []string{"FETCH", "IXSCAN"}, Indexes: []string{"email_1"}},
+		},
+		{
+			name:   "slot based plan",
+			result: bson.D{{Key: "queryPlanner", Value: bson.D{{Key: "winningPlan", Value: bson.D{{Key: "queryPlan", Value: bson.D{{Key: "stage", Value: "COLLSCAN"}}}}}}}},
+			want:   &ExplainSummary{Stages: []string{"COLLSCAN"}, CollectionScan: true},
+		},
+		{
+			name: "aggregate",
+			result: bson.D{{Key: "stages", Value: bson.A{bson.D{{Key: "$cursor", Value: bson.D{
+				{Key: "queryPlanner", Value: bson.D{{Key: "winningPlan", Value: bson.D{{Key: "stage", Value: "OR"}, {Key: "inputStages", Value: bson.A{ixscan}}}}}},
+			}}}}}},
+			want: &ExplainSummary{Stages: []string{"OR", "IXSCAN"}, Indexes: []string{"email_1"}},
+		},
+		{
+			name:   "empty input stages",
+			result: bson.D{{Key: "queryPlanner", Value: bson.D{{Key: "winningPlan", Value: bson.D{{Key: "stage", Value: "OR"}, {Key: "inputStages", Value: bson.A{}}}}}}},
+			want:   &ExplainSummary{Stages: []string{"OR"}},
+		},
+		{
+			name:   "no plan",
+			result: bson.D{{Key: "ok", Value: 1}},
+			want:   &ExplainSummary{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, summarizeExplain(marshalRaw(t, tt.result)))
+		})
+	}
+}
+
+func TestLogSlowQuerySink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewLogSlowQuerySink(slog.New(slog.NewJSONHandler(&buf, nil)))
+
+	sink.Report(context.Background(), SlowQuery{
+		Command:    "find",
+		Database:   "testdb",
+		Collection: "users",
+		Duration:   time.Second,
+		Filter:     bson.D{{Key: "email", Value: "?"}},
+		Plan:       &ExplainSummary{Stages: []string{"COLLSCAN"}, CollectionScan: true},
+	})
+
+	records := logRecords(t, &buf)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "MongoDB slow query", records[0]["msg"])
+	assert.Equal(t, "WARN", records[0]["level"])
+	assert.Equal(t, "testdb.users", records[0]["namespace"])
+	assert.Equal(t, `{"email":"?"}`, records[0]["filter"])
+	assert.Equal(t, true, records[0]["collection_scan"])
+}
+
+func TestMemorySlowQuerySink(t *testing.T) {
+	sink := NewMemorySlowQuerySink()
+	sink.Report(context.Background(), SlowQuery{Command: "find"})
+	sink.Report(context.Background(), SlowQuery{Command: "aggregate"})
+	assert.Len(t, sink.Queries(), 2)
+	assert.Equal(t, "aggregate", sink.Queries()[1].Command)
+
+	sink.Reset()
+	assert.Empty(t, sink.Queries())
+}
+
+func TestWithSlowQuerySink(t *testing.T) {
+	sink := NewMemorySlowQuerySink()
+	mgr, err := newManager(Config{URI: "mongodb://localhost:27017", SlowQuery: SlowQueryConfig{Threshold: 10}}, WithSlowQuerySink(sink))
+	assert.NoError(t, err)
+	assert.True(t, mgr.config.SlowQuery.Enabled)
+
+	runSlowCommand(mgr.commandMonitor(), 1, "find", findCommand(t), time.Second)
+	assert.Len(t, sink.Queries(), 1)
+
+	_, err = newManager(Config{URI: "mongodb://localhost:27017"}, WithSlowQuerySink(sink))
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
@@ -0,0 +1,273 @@
+package mongodb
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/youmark/pkcs8"
+)
+
+// newTLSConfig builds the *tls.Config used by the driver from the TLS configuration.
+//
+// The CA bundle and client key pair are loaded eagerly so that missing or malformed
+// files are reported before any connection attempt. When Reload is enabled the files
+// are re-read on the next TLS handshake after they change on disk.
+func newTLSConfig(cfg TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.KeyFile != "" && cfg.CertFile == "" {
+		return nil, errors.New("TLS key_file requires cert_file to be set")
+	}
+	if cfg.CAFile == "" && cfg.CertFile == "" {
+		return tlsConfig, nil
+	}
+
+	loader := &certLoader{config: cfg}
+	if err := loader.load(); err != nil {
+		return nil, err
+	}
+
+	if !cfg.Reload {
+		tlsConfig.RootCAs = loader.roots
+		if loader.cert != nil {
+			tlsConfig.Certificates = []tls.Certificate{*loader.cert}
+		}
+		return tlsConfig, nil
+	}
+
+	if cfg.CertFile != "" {
+		tlsConfig.GetClientCertificate = loader.getClientCertificate
+	}
+	if cfg.CAFile != "" && !cfg.InsecureSkipVerify {
+		// RootCAs cannot be swapped on a tls.Config the driver has already cloned,
+		// so the server chain is verified against the reloadable pool instead.
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = loader.verifyConnection
+	}
+	return tlsConfig, nil
+}
+
+// certLoader loads TLS material from disk and reloads it when the files change.
+type certLoader struct {
+	config TLSConfig
+
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	roots    *x509.CertPool
+	modTimes map[string]time.Time
+}
+
+// load reads the CA bundle and client key pair unconditionally. The loaded
+// material is only replaced when every file was read successfully.
+func (l *certLoader) load() error {
+	modTimes, err := l.statFiles()
+	if err != nil {
+		return err
+	}
+
+	var roots *x509.CertPool
+	if l.config.CAFile != "" {
+		if roots, err = loadCertPool(l.config.CAFile); err != nil {
+			return err
+		}
+	}
+
+	var cert *tls.Certificate
+	if l.config.CertFile != "" {
+		pair, err := loadKeyPair(l.config.CertFile, l.config.KeyFile, l.config.KeyPassword)
+		if err != nil {
+			return err
+		}
+		cert = &pair
+	}
+
+	l.roots = roots
+	l.cert = cert
+	l.modTimes = modTimes
+	return nil
+}
+
+// reloadIfChanged reloads the TLS material when any of the files was modified.
+// A failed reload keeps the previously loaded material so that a rotation caught
+// half-written does not break new connections.
+func (l *certLoader) reloadIfChanged() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	modTimes, err := l.statFiles()
+	if err != nil {
+		return
+	}
+	changed := false
+	for path, modTime := range modTimes {
+		if !modTime.Equal(l.modTimes[path]) {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return
+	}
+
+	_ = l.load()
+}
+
+// statFiles returns the modification time of every configured TLS file.
+func (l *certLoader) statFiles() (map[string]time.Time, error) {
+	modTimes := make(map[string]time.Time, 3)
+	for _, path := range []string{l.config.CAFile, l.config.CertFile, l.config.KeyFile} {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read TLS file %q: %w", path, err)
+		}
+		modTimes[path] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+// getClientCertificate implements tls.Config.GetClientCertificate.
+func (l *certLoader) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	l.reloadIfChanged()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.cert, nil
+}
+
+// verifyConnection implements tls.Config.VerifyConnection against the current CA pool.
+func (l *certLoader) verifyConnection(cs tls.ConnectionState) error {
+	l.reloadIfChanged()
+
+	l.mu.Lock()
+	roots := l.roots
+	l.mu.Unlock()
+
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("server did not present a TLS certificate")
+	}
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		DNSName:       cs.ServerName,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	_, err := cs.PeerCertificates[0].Verify(opts)
+	return err
+}
+
+// loadCertPool reads a PEM encoded CA bundle.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TLS CA file %q: %w", path, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("TLS CA file %q does not contain any PEM encoded certificate", path)
+	}
+	return pool, nil
+}
+
+// loadKeyPair reads a client certificate and its private key.
+//
+// When keyFile is empty the key is expected in certFile (combined PEM). Encrypted
+// keys, either PKCS#8 "ENCRYPTED PRIVATE KEY" blocks or legacy PEM blocks with a
+// DEK-Info header, are decrypted with password.
+func loadKeyPair(certFile, keyFile, password string) (tls.Certificate, error) {
+	certData, err := os.ReadFile(certFile)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to read TLS certificate file %q: %w", certFile, err)
+	}
+	keyData := certData
+	if keyFile != "" && keyFile != certFile {
+		if keyData, err = os.ReadFile(keyFile); err != nil {
+			return tls.Certificate{}, fmt.Errorf("failed to read TLS key file %q: %w", keyFile, err)
+		}
+	} else {
+		keyFile = certFile
+	}
+
+	var certPEM bytes.Buffer
+	for rest := certData; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			_ = pem.Encode(&certPEM, block)
+		}
+	}
+	if certPEM.Len() == 0 {
+		return tls.Certificate{}, fmt.Errorf("TLS certificate file %q does not contain a CERTIFICATE block", certFile)
+	}
+
+	var keyPEM []byte
+	for rest := keyData; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+		if !strings.HasSuffix(block.Type, "PRIVATE KEY") {
+			continue
+		}
+		if keyPEM, err = decodePrivateKey(block, password); err != nil {
+			return tls.Certificate{}, fmt.Errorf("failed to decode TLS key in %q: %w", keyFile, err)
+		}
+		break
+	}
+	if keyPEM == nil {
+		return tls.Certificate{}, fmt.Errorf("TLS key file %q does not contain a PRIVATE KEY block", keyFile)
+	}
+
+	cert, err := tls.X509KeyPair(certPEM.Bytes(), keyPEM)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("invalid TLS key pair (%q, %q): %w", certFile, keyFile, err)
+	}
+	return cert, nil
+}
+
+// decodePrivateKey returns the PEM encoding of a private key block, decrypting it first if needed.
+func decodePrivateKey(block *pem.Block, password string) ([]byte, error) {
+	//nolint:staticcheck // Legacy encrypted PEM keys are still produced by common tooling
+	legacy := x509.IsEncryptedPEMBlock(block)
+	if !legacy && block.Type != "ENCRYPTED PRIVATE KEY" {
+		return pem.EncodeToMemory(block), nil
+	}
+	if password == "" {
+		return nil, errors.New("private key is encrypted but no key_password is configured")
+	}
+
+	if legacy {
+		//nolint:staticcheck // Legacy encrypted PEM keys are still produced by common tooling
+		der, err := x509.DecryptPEMBlock(block, []byte(password))
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: der}), nil
+	}
+
+	key, err := pkcs8.ParsePKCS8PrivateKey(block.Bytes, []byte(password))
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
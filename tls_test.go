@@ -0,0 +1,217 @@
+package mongodb
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/youmark/pkcs8"
+)
+
+// testPKI holds PEM encoded test certificates generated on the fly
+type testPKI struct {
+	caPEM   []byte
+	certPEM []byte
+	keyPEM  []byte
+	key     *ecdsa.PrivateKey
+}
+
+// newTestPKI generates a CA and a client certificate signed by it
+func newTestPKI(t *testing.T, commonName string) *testPKI {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	assert.NoError(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, caTemplate, &key.PublicKey, caKey)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+
+	return &testPKI{
+		caPEM:   pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+		key:     key,
+	}
+}
+
+// writeTestFile writes data into dir/name and returns the path
+func writeTestFile(t *testing.T, dir, name string, data []byte) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	assert.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+// leafCommonName returns the subject common name of the first certificate in cert
+func leafCommonName(t *testing.T, cert *tls.Certificate) string {
+	t.Helper()
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	assert.NoError(t, err)
+	return leaf.Subject.CommonName
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	pki := newTestPKI(t, "client")
+
+	t.Run("insecure only", func(t *testing.T) {
+		tlsConfig, err := newTLSConfig(TLSConfig{Enabled: true, InsecureSkipVerify: true})
+		assert.NoError(t, err)
+		assert.True(t, tlsConfig.InsecureSkipVerify)
+		assert.Nil(t, tlsConfig.RootCAs)
+		assert.Empty(t, tlsConfig.Certificates)
+	})
+
+	t.Run("ca and separate key pair", func(t *testing.T) {
+		dir := t.TempDir()
+		tlsConfig, err := newTLSConfig(TLSConfig{
+			Enabled:  true,
+			CAFile:   writeTestFile(t, dir, "ca.pem", pki.caPEM),
+			CertFile: writeTestFile(t, dir, "client.crt", pki.certPEM),
+			KeyFile:  writeTestFile(t, dir, "client.key", pki.keyPEM),
+		})
+		assert.NoError(t, err)
+		assert.NotNil(t, tlsConfig.RootCAs)
+		assert.Len(t, tlsConfig.Certificates, 1)
+		assert.Equal(t, "client", leafCommonName(t, &tlsConfig.Certificates[0]))
+	})
+
+	t.Run("combined pem file", func(t *testing.T) {
+		dir := t.TempDir()
+		combined := append(append([]byte{}, pki.keyPEM...), pki.certPEM...)
+		tlsConfig, err := newTLSConfig(TLSConfig{
+			Enabled:  true,
+			CertFile: writeTestFile(t, dir, "client.pem", combined),
+		})
+		assert.NoError(t, err)
+		assert.Len(t, tlsConfig.Certificates, 1)
+	})
+
+	t.Run("encrypted pkcs8 key", func(t *testing.T) {
+		dir := t.TempDir()
+		encrypted, err := pkcs8.MarshalPrivateKey(pki.key, []byte("secret"), nil)
+		assert.NoError(t, err)
+		keyFile := writeTestFile(t, dir, "client.key", pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: encrypted}))
+		certFile := writeTestFile(t, dir, "client.crt", pki.certPEM)
+
+		tlsConfig, err := newTLSConfig(TLSConfig{Enabled: true, CertFile: certFile, KeyFile: keyFile, KeyPassword: "secret"})
+		assert.NoError(t, err)
+		assert.Len(t, tlsConfig.Certificates, 1)
+
+		_, err = newTLSConfig(TLSConfig{Enabled: true, CertFile: certFile, KeyFile: keyFile, KeyPassword: "wrong"})
+		assert.Error(t, err)
+
+		_, err = newTLSConfig(TLSConfig{Enabled: true, CertFile: certFile, KeyFile: keyFile})
+		assert.ErrorContains(t, err, "key_password")
+	})
+
+	t.Run("missing ca file", func(t *testing.T) {
+		_, err := newTLSConfig(TLSConfig{Enabled: true, CAFile: filepath.Join(t.TempDir(), "missing.pem")})
+		assert.ErrorContains(t, err, "missing.pem")
+	})
+
+	t.Run("ca file without certificates", func(t *testing.T) {
+		dir := t.TempDir()
+		_, err := newTLSConfig(TLSConfig{Enabled: true, CAFile: writeTestFile(t, dir, "ca.pem", []byte("not a certificate"))})
+		assert.ErrorContains(t, err, "does not contain any PEM encoded certificate")
+	})
+
+	t.Run("mismatched key pair", func(t *testing.T) {
+		dir := t.TempDir()
+		other := newTestPKI(t, "other")
+		_, err := newTLSConfig(TLSConfig{
+			Enabled:  true,
+			CertFile: writeTestFile(t, dir, "client.crt", pki.certPEM),
+			KeyFile:  writeTestFile(t, dir, "client.key", other.keyPEM),
+		})
+		assert.ErrorContains(t, err, "invalid TLS key pair")
+	})
+
+	t.Run("key file without cert file", func(t *testing.T) {
+		_, err := newTLSConfig(TLSConfig{Enabled: true, KeyFile: "client.key"})
+		assert.Error(t, err)
+	})
+}
+
+func TestNewTLSConfig_Reload(t *testing.T) {
+	dir := t.TempDir()
+	first := newTestPKI(t, "first")
+	certFile := writeTestFile(t, dir, "client.crt", first.certPEM)
+	keyFile := writeTestFile(t, dir, "client.key", first.keyPEM)
+	caFile := writeTestFile(t, dir, "ca.pem", first.caPEM)
+
+	tlsConfig, err := newTLSConfig(TLSConfig{Enabled: true, CAFile: caFile, CertFile: certFile, KeyFile: keyFile, Reload: true})
+	assert.NoError(t, err)
+	assert.NotNil(t, tlsConfig.GetClientCertificate)
+	assert.NotNil(t, tlsConfig.VerifyConnection)
+
+	cert, err := tlsConfig.GetClientCertificate(&tls.CertificateRequestInfo{})
+	assert.NoError(t, err)
+	assert.Equal(t, "first", leafCommonName(t, cert))
+
+	// Rotate the key pair on disk and make sure the modification time moves forward
+	second := newTestPKI(t, "second")
+	writeTestFile(t, dir, "client.crt", second.certPEM)
+	writeTestFile(t, dir, "client.key", second.keyPEM)
+	future := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(certFile, future, future))
+	assert.NoError(t, os.Chtimes(keyFile, future, future))
+
+	cert, err = tlsConfig.GetClientCertificate(&tls.CertificateRequestInfo{})
+	assert.NoError(t, err)
+	assert.Equal(t, "second", leafCommonName(t, cert))
+
+	// A broken rotation keeps serving the last valid pair
+	writeTestFile(t, dir, "client.key", []byte("garbage"))
+	later := future.Add(time.Minute)
+	assert.NoError(t, os.Chtimes(keyFile, later, later))
+
+	cert, err = tlsConfig.GetClientCertificate(&tls.CertificateRequestInfo{})
+	assert.NoError(t, err)
+	assert.Equal(t, "second", leafCommonName(t, cert))
+}
+
+func TestNewManagerWithConfig_InvalidTLS(t *testing.T) {
+	cfg := Config{
+		URI: "mongodb://localhost:27017",
+		TLS: TLSConfig{
+			Enabled: true,
+			CAFile:  filepath.Join(t.TempDir(), "missing.pem"),
+		},
+	}
+
+	assert.Panics(t, func() {
+		NewManagerWithConfig(cfg)
+	})
+}
@@ -0,0 +1,315 @@
+package mongodb
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo/description"
+)
+
+// TopologyKind is the kind of deployment the client is connected to
+type TopologyKind string
+
+// Topology kinds reported by Manager.Topology
+const (
+	TopologyKindUnknown      TopologyKind = "unknown"
+	TopologyKindStandalone   TopologyKind = "standalone"
+	TopologyKindReplicaSet   TopologyKind = "replica_set"
+	TopologyKindSharded      TopologyKind = "sharded"
+	TopologyKindLoadBalanced TopologyKind = "load_balanced"
+)
+
+// ServerType is the role of a server in the deployment
+type ServerType string
+
+// Server types reported in ServerState
+const (
+	ServerTypeUnknown      ServerType = "unknown"
+	ServerTypeStandalone   ServerType = "standalone"
+	ServerTypePrimary      ServerType = "primary"
+	ServerTypeSecondary    ServerType = "secondary"
+	ServerTypeArbiter      ServerType = "arbiter"
+	ServerTypeOther        ServerType = "other" // Replica set member that is hidden, starting up or recovering
+	ServerTypeGhost        ServerType = "ghost" // Replica set member without a replica set configuration
+	ServerTypeMongos       ServerType = "mongos"
+	ServerTypeLoadBalancer ServerType = "load_balancer"
+)
+
+// Topology is the state of the deployment as last seen by the client's server monitoring
+type Topology struct {
+	Kind    TopologyKind  // Kind of deployment
+	SetName string        // Replica set name, empty outside replica sets
+	Primary string        // Address of the primary, empty when there is none
+	Servers []ServerState // Known servers sorted by address
+}
+
+// ServerState is the state of a server as last seen by the client's server monitoring
+type ServerState struct {
+	Address    string            // Server address
+	Type       ServerType        // Role of the server
+	RTT        time.Duration     // Average round trip time of heartbeats
+	Tags       map[string]string // Replica set member tags
+	Lag        time.Duration     // Replication lag of a secondary behind the primary, 0 when unknown
+	LastError  error             // Error of the last heartbeat, nil when it succeeded
+	LastUpdate time.Time         // When the state was last updated
+}
+
+// TopologyEventType is the type of a TopologyEvent
+type TopologyEventType string
+
+// Topology event types delivered to SubscribeTopology channels
+const (
+	// TopologyEventPrimaryElected is sent when a server becomes the primary
+	TopologyEventPrimaryElected TopologyEventType = "primary_elected"
+
+	// TopologyEventServerChanged is sent when the type of a server changes or its heartbeat starts or stops failing
+	TopologyEventServerChanged TopologyEventType = "server_changed"
+)
+
+// TopologyEvent notifies a primary election or a server state change
+type TopologyEvent struct {
+	Type     TopologyEventType
+	Address  string      // Server the event is about, the new primary for TopologyEventPrimaryElected
+	Previous ServerState // State before the change; the previous primary for TopologyEventPrimaryElected (zero without one)
+	Current  ServerState // State after the change
+	Time     time.Time   // When the change was observed
+}
+
+// minTopologyBuffer is the smallest buffer of a SubscribeTopology channel, so that an
+// election and the server changes around it are not lost between two receives
+const minTopologyBuffer = 16
+
+// topologyTracker keeps the latest topology description and notifies subscribers of changes.
+//
+// The zero value is ready to use.
+type topologyTracker struct {
+	mu          sync.Mutex
+	current     description.Topology
+	subscribers map[chan TopologyEvent]struct{}
+}
+
+// monitor returns a server monitor feeding the tracker
+func (t *topologyTracker) monitor() *event.ServerMonitor {
+	return &event.ServerMonitor{
+		ServerDescriptionChanged:   t.serverChanged,
+		TopologyDescriptionChanged: t.topologyChanged,
+	}
+}
+
+// topologyChanged stores the new topology and publishes primary elections
+func (t *topologyTracker) topologyChanged(evt *event.TopologyDescriptionChangedEvent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.current = evt.NewDescription
+
+	previous, hadPrimary := primaryOf(evt.PreviousDescription)
+	primary, hasPrimary := primaryOf(evt.NewDescription)
+	if !hasPrimary || (hadPrimary && previous.Addr == primary.Addr) {
+		return
+	}
+
+	elected := TopologyEvent{
+		Type:    TopologyEventPrimaryElected,
+		Address: primary.Addr.String(),
+		Current: newServerState(primary, primary, true),
+		Time:    time.Now(),
+	}
+	if hadPrimary {
+		elected.Previous = newServerState(previous, previous, true)
+	}
+	t.publish(elected)
+}
+
+// serverChanged publishes changes of a server's type or heartbeat health
+//
+// The driver reports a server change before the topology change including it, so the
+// stored topology is updated with the server first; otherwise Current would be computed
+// against the primary before the change.
+func (t *topologyTracker) serverChanged(evt *event.ServerDescriptionChangedEvent) {
+	previous, next := evt.PreviousDescription, evt.NewDescription
+	if previous.Kind == next.Kind && (previous.LastError == nil) == (next.LastError == nil) {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	previousPrimary, hadPrimary := primaryOf(t.current)
+	t.current = withServer(t.current, next)
+	primary, hasPrimary := primaryOf(t.current)
+	if next.Kind == description.RSPrimary {
+		// A stale primary may still be listed until the topology change arrives
+		primary, hasPrimary = next, true
+	}
+	t.publish(TopologyEvent{
+		Type:     TopologyEventServerChanged,
+		Address:  evt.Address.String(),
+		Previous: newServerState(previous, previousPrimary, hadPrimary),
+		Current:  newServerState(next, primary, hasPrimary),
+		Time:     time.Now(),
+	})
+}
+
+// withServer returns a copy of topology with the description of server replaced or added
+func withServer(topology description.Topology, server description.Server) description.Topology {
+	servers := make([]description.Server, 0, len(topology.Servers)+1)
+	for _, known := range topology.Servers {
+		if known.Addr != server.Addr {
+			servers = append(servers, known)
+		}
+	}
+	topology.Servers = append(servers, server)
+	return topology
+}
+
+// reset forgets the topology, e.g. after the client disconnected; subscriptions are kept
+func (t *topologyTracker) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.current = description.Topology{}
+}
+
+// publish sends an event to every subscriber without blocking, subscribers whose buffer
+// is full miss the event; t.mu must be held
+func (t *topologyTracker) publish(evt TopologyEvent) {
+	for ch := range t.subscribers {
+		select {
+		case ch <- evt:
+		default:
+		}
+	}
+}
+
+// subscribe registers a channel receiving the topology events, buffer is raised to
+// minTopologyBuffer
+func (t *topologyTracker) subscribe(buffer int) (<-chan TopologyEvent, func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.subscribers == nil {
+		t.subscribers = make(map[chan TopologyEvent]struct{})
+	}
+	ch := make(chan TopologyEvent, max(buffer, minTopologyBuffer))
+	t.subscribers[ch] = struct{}{}
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			delete(t.subscribers, ch)
+			close(ch)
+		})
+	}
+}
+
+// topology converts the latest topology description
+func (t *topologyTracker) topology() Topology {
+	t.mu.Lock()
+	current := t.current
+	t.mu.Unlock()
+
+	topology := Topology{
+		Kind:    topologyKind(current.Kind),
+		SetName: current.SetName,
+		Servers: make([]ServerState, 0, len(current.Servers)),
+	}
+	primary, hasPrimary := primaryOf(current)
+	if hasPrimary {
+		topology.Primary = primary.Addr.String()
+	}
+	for _, server := range current.Servers {
+		topology.Servers = append(topology.Servers, newServerState(server, primary, hasPrimary))
+	}
+	sort.Slice(topology.Servers, func(i, j int) bool { return topology.Servers[i].Address < topology.Servers[j].Address })
+	return topology
+}
+
+// primaryOf returns the primary of a topology
+func primaryOf(topology description.Topology) (description.Server, bool) {
+	for _, server := range topology.Servers {
+		if server.Kind == description.RSPrimary {
+			return server, true
+		}
+	}
+	return description.Server{}, false
+}
+
+// newServerState converts a server description, lag is computed against primary when known
+func newServerState(server, primary description.Server, hasPrimary bool) ServerState {
+	state := ServerState{
+		Address:    server.Addr.String(),
+		Type:       serverType(server.Kind),
+		RTT:        server.AverageRTT,
+		LastError:  server.LastError,
+		LastUpdate: server.LastUpdateTime,
+	}
+	if len(server.Tags) > 0 {
+		state.Tags = make(map[string]string, len(server.Tags))
+		for _, tag := range server.Tags {
+			state.Tags[tag.Name] = tag.Value
+		}
+	}
+	if hasPrimary && server.Kind == description.RSSecondary && !server.LastWriteTime.IsZero() {
+		state.Lag = max(primary.LastWriteTime.Sub(server.LastWriteTime), 0)
+	}
+	return state
+}
+
+// topologyKind maps a driver topology kind
+func topologyKind(kind description.TopologyKind) TopologyKind {
+	switch kind {
+	case description.Single:
+		return TopologyKindStandalone
+	case description.ReplicaSet, description.ReplicaSetNoPrimary, description.ReplicaSetWithPrimary:
+		return TopologyKindReplicaSet
+	case description.Sharded:
+		return TopologyKindSharded
+	case description.LoadBalanced:
+		return TopologyKindLoadBalanced
+	}
+	return TopologyKindUnknown
+}
+
+// serverType maps a driver server kind
+func serverType(kind description.ServerKind) ServerType {
+	switch kind {
+	case description.Standalone:
+		return ServerTypeStandalone
+	case description.RSPrimary:
+		return ServerTypePrimary
+	case description.RSSecondary:
+		return ServerTypeSecondary
+	case description.RSArbiter:
+		return ServerTypeArbiter
+	case description.RSMember:
+		return ServerTypeOther
+	case description.RSGhost:
+		return ServerTypeGhost
+	case description.Mongos:
+		return ServerTypeMongos
+	case description.LoadBalancer:
+		return ServerTypeLoadBalancer
+	}
+	return ServerTypeUnknown
+}
+
+// Topology returns the deployment state gathered from server monitoring events
+//
+// The topology kind is unknown before the manager connects and after it disconnects.
+func (m *manager) Topology() Topology {
+	return m.topology.topology()
+}
+
+// SubscribeTopology returns a channel notified of primary elections and server state changes,
+// and a function ending the subscription
+//
+// The buffer is at least 16 events; events are dropped rather than delaying server
+// monitoring when it is full. Subscriptions survive Disconnect and reconnects. Calling
+// the returned function closes the channel.
+func (m *manager) SubscribeTopology(buffer int) (<-chan TopologyEvent, func()) {
+	return m.topology.subscribe(buffer)
+}
@@ -0,0 +1,170 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/address"
+	"go.mongodb.org/mongo-driver/mongo/description"
+	"go.mongodb.org/mongo-driver/tag"
+)
+
+// replicaSet returns a replica set description with primary and secondary as the given servers
+func replicaSet(primary, secondary string, lastWrite time.Time) description.Topology {
+	return description.Topology{
+		Kind:    description.ReplicaSetWithPrimary,
+		SetName: "rs0",
+		Servers: []description.Server{
+			{Addr: address.Address(secondary), Kind: description.RSSecondary, LastWriteTime: lastWrite.Add(-2 * time.Second), Tags: tag.Set{{Name: "dc", Value: "east"}}},
+			{Addr: address.Address(primary), Kind: description.RSPrimary, LastWriteTime: lastWrite, AverageRTT: time.Millisecond},
+		},
+	}
+}
+
+func TestTopologyTracker_Topology(t *testing.T) {
+	var tracker topologyTracker
+	assert.Equal(t, Topology{Kind: TopologyKindUnknown, Servers: []ServerState{}}, tracker.topology())
+
+	now := time.Now()
+	tracker.monitor().TopologyDescriptionChanged(&event.TopologyDescriptionChangedEvent{NewDescription: replicaSet("db-1:27017", "db-2:27017", now)})
+
+	topology := tracker.topology()
+	assert.Equal(t, TopologyKindReplicaSet, topology.Kind)
+	assert.Equal(t, "rs0", topology.SetName)
+	assert.Equal(t, "db-1:27017", topology.Primary)
+	assert.Equal(t, []ServerState{
+		{Address: "db-1:27017", Type: ServerTypePrimary, RTT: time.Millisecond},
+		{Address: "db-2:27017", Type: ServerTypeSecondary, Tags: map[string]string{"dc": "east"}, Lag: 2 * time.Second},
+	}, topology.Servers)
+}
+
+func TestTopologyTracker_Subscribe(t *testing.T) {
+	var tracker topologyTracker
+	monitor := tracker.monitor()
+	events, unsubscribe := tracker.subscribe(10)
+
+	now := time.Now()
+	first := replicaSet("db-1:27017", "db-2:27017", now)
+	monitor.TopologyDescriptionChanged(&event.TopologyDescriptionChangedEvent{NewDescription: first})
+	// A change without a new primary is not an election
+	monitor.TopologyDescriptionChanged(&event.TopologyDescriptionChangedEvent{PreviousDescription: first, NewDescription: first})
+	monitor.TopologyDescriptionChanged(&event.TopologyDescriptionChangedEvent{PreviousDescription: first, NewDescription: replicaSet("db-2:27017", "db-1:27017", now)})
+
+	elected := <-events
+	assert.Equal(t, TopologyEventPrimaryElected, elected.Type)
+	assert.Equal(t, "db-1:27017", elected.Address)
+	assert.Empty(t, elected.Previous.Address)
+
+	elected = <-events
+	assert.Equal(t, "db-2:27017", elected.Address)
+	assert.Equal(t, "db-1:27017", elected.Previous.Address)
+	assert.Equal(t, ServerTypePrimary, elected.Current.Type)
+
+	// Only type changes and heartbeat failures are server state changes
+	server := description.Server{Addr: "db-3:27017", Kind: description.RSSecondary}
+	monitor.ServerDescriptionChanged(&event.ServerDescriptionChangedEvent{Address: server.Addr, PreviousDescription: server, NewDescription: server})
+	failed := description.Server{Addr: "db-3:27017", LastError: errors.New("connection refused")}
+	monitor.ServerDescriptionChanged(&event.ServerDescriptionChangedEvent{Address: server.Addr, PreviousDescription: server, NewDescription: failed})
+
+	changed := <-events
+	assert.Equal(t, TopologyEventServerChanged, changed.Type)
+	assert.Equal(t, "db-3:27017", changed.Address)
+	assert.Equal(t, ServerTypeSecondary, changed.Previous.Type)
+	assert.Equal(t, ServerTypeUnknown, changed.Current.Type)
+	assert.EqualError(t, changed.Current.LastError, "connection refused")
+	assert.Empty(t, events)
+
+	unsubscribe()
+	unsubscribe()
+	_, open := <-events
+	assert.False(t, open)
+}
+
+func TestTopologyTracker_ServerChangedBeforeTopology(t *testing.T) {
+	var tracker topologyTracker
+	monitor := tracker.monitor()
+	events, unsubscribe := tracker.subscribe(10)
+	defer unsubscribe()
+
+	now := time.Now()
+	monitor.TopologyDescriptionChanged(&event.TopologyDescriptionChangedEvent{NewDescription: replicaSet("db-1:27017", "db-2:27017", now)})
+	<-events
+
+	// The primary steps down: its lag must not be computed against its own former description
+	primary := description.Server{Addr: "db-1:27017", Kind: description.RSPrimary, LastWriteTime: now}
+	steppedDown := description.Server{Addr: "db-1:27017", Kind: description.RSSecondary, LastWriteTime: now.Add(-5 * time.Second)}
+	monitor.ServerDescriptionChanged(&event.ServerDescriptionChangedEvent{Address: primary.Addr, PreviousDescription: primary, NewDescription: steppedDown})
+
+	changed := <-events
+	assert.Equal(t, ServerTypeSecondary, changed.Current.Type)
+	assert.Zero(t, changed.Current.Lag)
+	assert.Empty(t, tracker.topology().Primary)
+
+	// The secondary is elected before the topology change reports it
+	secondary := description.Server{Addr: "db-2:27017", Kind: description.RSSecondary, LastWriteTime: now.Add(-2 * time.Second)}
+	elected := description.Server{Addr: "db-2:27017", Kind: description.RSPrimary, LastWriteTime: now}
+	monitor.ServerDescriptionChanged(&event.ServerDescriptionChangedEvent{Address: secondary.Addr, PreviousDescription: secondary, NewDescription: elected})
+
+	changed = <-events
+	assert.Equal(t, ServerTypeSecondary, changed.Previous.Type)
+	assert.Equal(t, ServerTypePrimary, changed.Current.Type)
+	assert.Equal(t, "db-2:27017", tracker.topology().Primary)
+}
+
+func TestTopologyTracker_SlowSubscriber(t *testing.T) {
+	var tracker topologyTracker
+	events, unsubscribe := tracker.subscribe(0)
+	defer unsubscribe()
+
+	// Server monitoring is never blocked by a subscriber that does not receive, and an
+	// unbuffered subscription is given the minimum buffer
+	server := description.Server{Addr: "db-1:27017", Kind: description.RSSecondary}
+	failed := description.Server{Addr: "db-1:27017", LastError: errors.New("connection refused")}
+	for i := 0; i < minTopologyBuffer+2; i++ {
+		tracker.monitor().ServerDescriptionChanged(&event.ServerDescriptionChangedEvent{Address: server.Addr, PreviousDescription: server, NewDescription: failed})
+	}
+	assert.Len(t, events, minTopologyBuffer)
+}
+
+func TestTopologyKind(t *testing.T) {
+	tests := map[description.TopologyKind]TopologyKind{
+		description.Single:              TopologyKindStandalone,
+		description.ReplicaSetNoPrimary: TopologyKindReplicaSet,
+		description.Sharded:             TopologyKindSharded,
+		description.LoadBalanced:        TopologyKindLoadBalanced,
+		description.TopologyKind(0):     TopologyKindUnknown,
+	}
+	for kind, want := range tests {
+		assert.Equal(t, want, topologyKind(kind), kind.String())
+	}
+}
+
+func TestManager_Topology(t *testing.T) {
+	mgr, err := newManager(Config{URI: "mongodb://localhost:27017"})
+	assert.NoError(t, err)
+	events, unsubscribe := mgr.SubscribeTopology(1)
+	defer unsubscribe()
+
+	mgr.serverMonitor().TopologyDescriptionChanged(&event.TopologyDescriptionChangedEvent{NewDescription: replicaSet("db-1:27017", "db-2:27017", time.Now())})
+	assert.Equal(t, "db-1:27017", mgr.Topology().Primary)
+	assert.Equal(t, TopologyEventPrimaryElected, (<-events).Type)
+
+	t.Run("disconnect forgets the topology", func(t *testing.T) {
+		// mongo.Connect does not wait for the server, so no MongoDB instance is required
+		client, err := mongo.Connect(context.Background())
+		assert.NoError(t, err)
+		mgr.client = client
+
+		assert.NoError(t, mgr.Disconnect(context.Background()))
+		assert.Equal(t, Topology{Kind: TopologyKindUnknown, Servers: []ServerState{}}, mgr.Topology())
+
+		// Subscriptions carry on after a reconnect
+		mgr.serverMonitor().TopologyDescriptionChanged(&event.TopologyDescriptionChangedEvent{NewDescription: replicaSet("db-2:27017", "db-1:27017", time.Now())})
+		assert.Equal(t, "db-2:27017", (<-events).Address)
+	})
+}
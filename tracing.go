@@ -0,0 +1,98 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// CommandInfo describes a command a Tracer starts a span for
+type CommandInfo struct {
+	Command       string // Command name, e.g. find or aggregate
+	Database      string // Database the command runs against
+	Collection    string // Collection the command targets, empty for database level commands
+	ServerAddress string // Host of the server the command is sent to
+	ServerPort    int    // Port of the server, 0 when unknown
+	RequestID     int64  // Driver request ID
+	ConnectionID  string // Driver connection ID
+}
+
+// CommandSpan is a span started by a Tracer for a command
+type CommandSpan interface {
+	// End ends the span, err is nil when the command succeeded
+	End(err error)
+}
+
+// Tracer starts a span per MongoDB command.
+//
+// Spans are started from the context of the operation, so they are children of the span
+// the application started; the mongootel subpackage provides an OpenTelemetry tracer.
+// Without WithTracer no tracing monitor is installed.
+type Tracer interface {
+	// StartCommand starts a span for a command about to be sent
+	StartCommand(ctx context.Context, info CommandInfo) CommandSpan
+}
+
+// commandTracer ends the spans started for commands when they finish
+type commandTracer struct {
+	tracer Tracer
+
+	// spans keeps the spans of started commands by request ID
+	spans sync.Map
+}
+
+// newCommandTracer creates a command tracer starting spans with tracer
+func newCommandTracer(tracer Tracer) *commandTracer {
+	return &commandTracer{tracer: tracer}
+}
+
+// monitor returns a command monitor starting and ending the spans
+func (t *commandTracer) monitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, evt *event.CommandStartedEvent) {
+			host, port := splitAddress(connectionAddress(evt.ConnectionID))
+			span := t.tracer.StartCommand(ctx, CommandInfo{
+				Command:       evt.CommandName,
+				Database:      evt.DatabaseName,
+				Collection:    commandCollection(evt.Command),
+				ServerAddress: host,
+				ServerPort:    port,
+				RequestID:     evt.RequestID,
+				ConnectionID:  evt.ConnectionID,
+			})
+			if span != nil {
+				t.spans.Store(evt.RequestID, span)
+			}
+		},
+		Succeeded: func(_ context.Context, evt *event.CommandSucceededEvent) {
+			t.end(evt.RequestID, nil)
+		},
+		Failed: func(_ context.Context, evt *event.CommandFailedEvent) {
+			t.end(evt.RequestID, errors.New(evt.Failure))
+		},
+	}
+}
+
+// end ends the span of a finished command
+func (t *commandTracer) end(requestID int64, err error) {
+	if span, ok := t.spans.LoadAndDelete(requestID); ok {
+		span.(CommandSpan).End(err)
+	}
+}
+
+// splitAddress splits a server address into host and port, the port is 0 when missing
+func splitAddress(address string) (string, int) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return address, 0
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil {
+		return host, 0
+	}
+	return host, p
+}
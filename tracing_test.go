@@ -0,0 +1,86 @@
+package mongodb
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// tracedCommand is a span started by fakeTracer
+type tracedCommand struct {
+	info  CommandInfo
+	ended bool
+	err   error
+}
+
+func (c *tracedCommand) End(err error) {
+	c.ended = true
+	c.err = err
+}
+
+// fakeTracer keeps the spans it starts and the context values they were started from
+type fakeTracer struct {
+	mu      sync.Mutex
+	spans   []*tracedCommand
+	parents []interface{}
+}
+
+type parentKey struct{}
+
+func (t *fakeTracer) StartCommand(ctx context.Context, info CommandInfo) CommandSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	span := &tracedCommand{info: info}
+	t.spans = append(t.spans, span)
+	t.parents = append(t.parents, ctx.Value(parentKey{}))
+	return span
+}
+
+func TestCommandTracer(t *testing.T) {
+	tracer := &fakeTracer{}
+	monitor := newCommandTracer(tracer).monitor()
+	ctx := context.WithValue(context.Background(), parentKey{}, "request-span")
+
+	monitor.Started(ctx, &event.CommandStartedEvent{Command: findCommand(t), CommandName: "find", DatabaseName: "testdb", RequestID: 1, ConnectionID: "db-1:27017[-2]"})
+	monitor.Started(ctx, &event.CommandStartedEvent{Command: bsonDoc(t, "ping"), CommandName: "ping", DatabaseName: "admin", RequestID: 2, ConnectionID: "db-1"})
+	monitor.Succeeded(ctx, &event.CommandSucceededEvent{CommandFinishedEvent: event.CommandFinishedEvent{RequestID: 1}})
+	monitor.Failed(ctx, &event.CommandFailedEvent{CommandFinishedEvent: event.CommandFinishedEvent{RequestID: 2}, Failure: "not primary"})
+
+	assert.Len(t, tracer.spans, 2)
+	assert.Equal(t, []interface{}{"request-span", "request-span"}, tracer.parents)
+
+	find := tracer.spans[0]
+	assert.Equal(t, CommandInfo{
+		Command:       "find",
+		Database:      "testdb",
+		Collection:    "users",
+		ServerAddress: "db-1",
+		ServerPort:    27017,
+		RequestID:     1,
+		ConnectionID:  "db-1:27017[-2]",
+	}, find.info)
+	assert.True(t, find.ended)
+	assert.NoError(t, find.err)
+
+	ping := tracer.spans[1]
+	assert.Equal(t, "db-1", ping.info.ServerAddress)
+	assert.Equal(t, 0, ping.info.ServerPort)
+	assert.True(t, ping.ended)
+	assert.EqualError(t, ping.err, "not primary")
+}
+
+func TestWithTracer(t *testing.T) {
+	mgr, err := newManager(Config{URI: "mongodb://localhost:27017"}, WithTracer(nil))
+	assert.NoError(t, err)
+	assert.Nil(t, mgr.tracer)
+
+	tracer := &fakeTracer{}
+	mgr, err = newManager(Config{URI: "mongodb://localhost:27017"}, WithTracer(tracer))
+	assert.NoError(t, err)
+
+	mgr.commandMonitor().Started(context.Background(), &event.CommandStartedEvent{Command: findCommand(t), CommandName: "find", RequestID: 1})
+	assert.Len(t, tracer.spans, 1)
+}